@@ -0,0 +1,255 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Difference is a difference between two values, see Diff.
+type Difference struct {
+	// Path is the path of the differing nested values, in the syntax of Query.
+	// The path is empty if the given values themselves differ.
+	Path string
+	// Message describes the difference, e.g. `10 != 12`
+	Message string
+}
+
+func (d Difference) String() string {
+	if d.Path == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %s", d.Path, d.Message)
+}
+
+const diffMissing = "<missing>"
+
+// Diff returns the differences between the two given values, e.g. an expected and an actual value.
+//
+// Composites are compared field by field, arrays element by element,
+// and dictionaries by key, reporting the path of each difference, e.g. `owner.balance: 10 != 12`.
+// Fields and keys present in only one of the values are reported as missing,
+// and arrays of different lengths additionally report the length difference.
+// Optionals are unwrapped implicitly. All other values are compared by their string representation.
+//
+// Diff returns no differences if the values are equal.
+func Diff(a, b Value) []Difference {
+	var differences []Difference
+	diffValues("", a, b, &differences)
+	return differences
+}
+
+func diffValues(path string, a, b Value, differences *[]Difference) {
+	report := func(format string, args ...any) {
+		*differences = append(*differences, Difference{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if a == nil || b == nil {
+		if a != b {
+			report("%s != %s", diffString(a), diffString(b))
+		}
+		return
+	}
+
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		report("type %s != %s", diffTypeString(a), diffTypeString(b))
+		return
+	}
+
+	switch a := a.(type) {
+	case Optional:
+		b := b.(Optional)
+		if a.Value == nil || b.Value == nil {
+			if a.Value != b.Value {
+				report("%s != %s", a, b)
+			}
+			return
+		}
+		diffValues(path, a.Value, b.Value, differences)
+
+	case Array:
+		b := b.(Array)
+
+		if len(a.Values) != len(b.Values) {
+			report("length %d != %d", len(a.Values), len(b.Values))
+		}
+
+		for i := 0; i < len(a.Values) && i < len(b.Values); i++ {
+			elementPath := path + querySegment{
+				kind:  querySegmentKindIndex,
+				index: i,
+			}.String()
+			diffValues(elementPath, a.Values[i], b.Values[i], differences)
+		}
+
+	case Dictionary:
+		b := b.(Dictionary)
+
+		bValues := make(map[string]Value, len(b.Pairs))
+		for _, pair := range b.Pairs {
+			bValues[pair.Key.String()] = pair.Value
+		}
+
+		aKeys := make(map[string]struct{}, len(a.Pairs))
+
+		for _, pair := range a.Pairs {
+			key := pair.Key.String()
+			aKeys[key] = struct{}{}
+
+			valuePath := path + diffKeySegment(pair.Key)
+
+			bValue, ok := bValues[key]
+			if !ok {
+				*differences = append(*differences, Difference{
+					Path:    valuePath,
+					Message: fmt.Sprintf("%s != %s", pair.Value, diffMissing),
+				})
+				continue
+			}
+
+			diffValues(valuePath, pair.Value, bValue, differences)
+		}
+
+		for _, pair := range b.Pairs {
+			if _, ok := aKeys[pair.Key.String()]; ok {
+				continue
+			}
+
+			*differences = append(*differences, Difference{
+				Path:    path + diffKeySegment(pair.Key),
+				Message: fmt.Sprintf("%s != %s", diffMissing, pair.Value),
+			})
+		}
+
+	case Struct, Resource, Event, Contract, Enum:
+		aTypeID, aTyped := diffTypeID(a)
+		bTypeID, bTyped := diffTypeID(b)
+		if aTyped && bTyped && aTypeID != bTypeID {
+			report("type %s != %s", aTypeID, bTypeID)
+			return
+		}
+
+		aFields, aValues, _ := compositeFieldsAndValues(a)
+		bFields, bValues, _ := compositeFieldsAndValues(b)
+
+		bFieldValues := make(map[string]Value, len(bFields))
+		for i, field := range bFields {
+			if i < len(bValues) {
+				bFieldValues[field.Identifier] = bValues[i]
+			}
+		}
+
+		aFieldNames := make(map[string]struct{}, len(aFields))
+
+		for i, field := range aFields {
+			if i >= len(aValues) {
+				break
+			}
+			aFieldNames[field.Identifier] = struct{}{}
+
+			fieldPath := diffFieldPath(path, field.Identifier)
+
+			bValue, ok := bFieldValues[field.Identifier]
+			if !ok {
+				*differences = append(*differences, Difference{
+					Path:    fieldPath,
+					Message: fmt.Sprintf("%s != %s", aValues[i], diffMissing),
+				})
+				continue
+			}
+
+			diffValues(fieldPath, aValues[i], bValue, differences)
+		}
+
+		for i, field := range bFields {
+			if i >= len(bValues) {
+				break
+			}
+			if _, ok := aFieldNames[field.Identifier]; ok {
+				continue
+			}
+
+			*differences = append(*differences, Difference{
+				Path:    diffFieldPath(path, field.Identifier),
+				Message: fmt.Sprintf("%s != %s", diffMissing, bValues[i]),
+			})
+		}
+
+	default:
+		aString := a.String()
+		bString := b.String()
+		if aString != bString {
+			report("%s != %s", aString, bString)
+		}
+	}
+}
+
+func diffFieldPath(path string, field string) string {
+	// Top-level fields have no leading dot, like in Query paths
+	if path == "" {
+		return field
+	}
+	return path + querySegment{
+		kind:  querySegmentKindField,
+		field: field,
+	}.String()
+}
+
+func diffKeySegment(key Value) string {
+	if key, ok := key.(String); ok {
+		return querySegment{
+			kind: querySegmentKindKey,
+			key:  string(key),
+		}.String()
+	}
+	return fmt.Sprintf("[%s]", key)
+}
+
+func diffString(value Value) string {
+	if value == nil {
+		return diffMissing
+	}
+	return value.String()
+}
+
+func diffTypeString(value Value) string {
+	if typeID, ok := diffTypeID(value); ok {
+		return typeID
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// diffTypeID returns the ID of the type of the given value, if the value has a type.
+func diffTypeID(value Value) (string, bool) {
+	ty := value.Type()
+	if ty == nil {
+		return "", false
+	}
+
+	// Composite values without a type return a nil pointer
+	if reflectedType := reflect.ValueOf(ty); reflectedType.Kind() == reflect.Ptr && reflectedType.IsNil() {
+		return "", false
+	}
+
+	return ty.ID(), true
+}
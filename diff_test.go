@@ -0,0 +1,216 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestDiff(t *testing.T) {
+
+	t.Parallel()
+
+	ownerType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Owner",
+		Fields: []Field{
+			{
+				Identifier: "name",
+				Type:       StringType{},
+			},
+			{
+				Identifier: "balance",
+				Type:       IntType{},
+			},
+		},
+	}
+
+	accountType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Account",
+		Fields: []Field{
+			{
+				Identifier: "owner",
+				Type:       NewOptionalType(ownerType),
+			},
+			{
+				Identifier: "tags",
+				Type: VariableSizedArrayType{
+					ElementType: StringType{},
+				},
+			},
+		},
+	}
+
+	newAccount := func(balance int, tags ...string) Struct {
+		tagValues := make([]Value, len(tags))
+		for i, tag := range tags {
+			tagValues[i] = String(tag)
+		}
+
+		return NewStruct([]Value{
+			NewOptional(
+				NewStruct([]Value{
+					String("alice"),
+					NewInt(balance),
+				}).WithType(ownerType),
+			),
+			NewArray(tagValues),
+		}).WithType(accountType)
+	}
+
+	t.Run("equal", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Empty(t, Diff(newAccount(10, "a"), newAccount(10, "a")))
+	})
+
+	t.Run("nested field", func(t *testing.T) {
+
+		t.Parallel()
+
+		differences := Diff(newAccount(10, "a"), newAccount(12, "a"))
+		require.Len(t, differences, 1)
+
+		assert.Equal(t,
+			Difference{
+				Path:    "owner.balance",
+				Message: "10 != 12",
+			},
+			differences[0],
+		)
+		assert.Equal(t, "owner.balance: 10 != 12", differences[0].String())
+
+		// The path can be used to query the differing values
+		value, err := Query(newAccount(10, "a"), differences[0].Path)
+		require.NoError(t, err)
+		assert.Equal(t, NewInt(10), value)
+	})
+
+	t.Run("array length", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			[]Difference{
+				{
+					Path:    "tags",
+					Message: "length 2 != 1",
+				},
+				{
+					Path:    "tags[0]",
+					Message: `"a" != "b"`,
+				},
+			},
+			Diff(newAccount(10, "a", "c"), newAccount(10, "b")),
+		)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewStruct([]Value{NewInt(1), NewInt(2)}).
+			WithType(&StructType{
+				QualifiedIdentifier: "S",
+				Fields: []Field{
+					{Identifier: "x", Type: IntType{}},
+					{Identifier: "y", Type: IntType{}},
+				},
+			})
+
+		b := NewStruct([]Value{NewInt(1), NewInt(3)}).
+			WithType(&StructType{
+				QualifiedIdentifier: "S",
+				Fields: []Field{
+					{Identifier: "x", Type: IntType{}},
+					{Identifier: "z", Type: IntType{}},
+				},
+			})
+
+		assert.Equal(t,
+			[]Difference{
+				{
+					Path:    "y",
+					Message: "2 != <missing>",
+				},
+				{
+					Path:    "z",
+					Message: "<missing> != 3",
+				},
+			},
+			Diff(a, b),
+		)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			[]Difference{
+				{
+					Message: "type Int != String",
+				},
+			},
+			Diff(NewInt(1), String("1")),
+		)
+
+		assert.Equal(t,
+			[]Difference{
+				{
+					Path:    `["a"]`,
+					Message: "type Int != Bool",
+				},
+			},
+			Diff(
+				NewDictionary([]KeyValuePair{{Key: String("a"), Value: NewInt(1)}}),
+				NewDictionary([]KeyValuePair{{Key: String("a"), Value: NewBool(true)}}),
+			),
+		)
+	})
+
+	t.Run("dictionary keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			[]Difference{
+				{
+					Path:    "[1]",
+					Message: `"x" != <missing>`,
+				},
+				{
+					Path:    "[2]",
+					Message: `<missing> != "y"`,
+				},
+			},
+			Diff(
+				NewDictionary([]KeyValuePair{{Key: NewInt(1), Value: String("x")}}),
+				NewDictionary([]KeyValuePair{{Key: NewInt(2), Value: String("y")}}),
+			),
+		)
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package msgpack encodes and decodes Cadence values to and from MessagePack,
+// for compact binary interoperability.
+//
+// The value is represented with the same structure as its JSON-Cadence representation,
+// see https://docs.onflow.org/cadence/json-cadence-spec/,
+// i.e. JSON objects are encoded as MessagePack maps, and JSON arrays as MessagePack arrays.
+// For example, the Cadence value `1 as UInt8` is represented as the map
+// `{"type": "UInt8", "value": "1"}`.
+//
+// As numbers are encoded as strings in JSON-Cadence, no precision is lost.
+package msgpack
+
+import (
+	goJSON "encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// Encode returns the MessagePack representation of the given value.
+//
+// This function returns an error if the Cadence value cannot be represented as JSON-Cadence.
+func Encode(value cadence.Value) ([]byte, error) {
+	encoded, err := json.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var representation any
+	err = goJSON.Unmarshal(encoded, &representation)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(representation)
+}
+
+// Decode returns the Cadence value of the given MessagePack representation, see Encode.
+//
+// This function returns an error if the bytes are not valid MessagePack,
+// or if the representation does not conform to the JSON Cadence specification.
+func Decode(gauge common.MemoryGauge, b []byte) (cadence.Value, error) {
+	var representation any
+	err := msgpack.Unmarshal(b, &representation)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := goJSON.Marshal(representation)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Decode(gauge, encoded)
+}
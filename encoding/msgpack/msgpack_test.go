@@ -0,0 +1,153 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package msgpack
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func testRoundTrip(t *testing.T, value cadence.Value) {
+	encoded, err := Encode(value)
+	require.NoError(t, err)
+
+	decoded, err := Decode(nil, encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, value, decoded)
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("scalars", func(t *testing.T) {
+
+		t.Parallel()
+
+		bigInt, ok := new(big.Int).SetString("-123456789012345678901234567890", 10)
+		require.True(t, ok)
+
+		for _, value := range []cadence.Value{
+			cadence.NewVoid(),
+			cadence.NewBool(true),
+			cadence.String("foo"),
+			cadence.NewAddress([8]byte{0, 0, 0, 0, 0, 0, 0, 1}),
+			cadence.NewIntFromBig(bigInt),
+			cadence.NewUInt8(42),
+			cadence.NewInt64(-42),
+			cadence.NewUInt64(18446744073709551615),
+			cadence.NewWord32(7),
+			cadence.Fix64(-123456789),
+			cadence.UFix64(123456789),
+			cadence.NewOptional(nil),
+			cadence.NewOptional(cadence.String("bar")),
+		} {
+			testRoundTrip(t, value)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+
+		t.Parallel()
+
+		testRoundTrip(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewInt(2),
+				cadence.NewInt(3),
+			}),
+		)
+	})
+
+	t.Run("dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		testRoundTrip(t,
+			cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key:   cadence.String("a"),
+					Value: cadence.NewInt(1),
+				},
+				{
+					Key:   cadence.String("b"),
+					Value: cadence.NewInt(2),
+				},
+			}),
+		)
+	})
+
+	t.Run("struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		structType := &cadence.StructType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Foo",
+			Fields: []cadence.Field{
+				{
+					Identifier: "a",
+					Type:       cadence.IntType{},
+				},
+				{
+					Identifier: "b",
+					Type:       cadence.StringType{},
+				},
+			},
+		}
+
+		testRoundTrip(t,
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.String("foo"),
+			}).WithType(structType),
+		)
+	})
+
+	t.Run("type", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The size of constant-sized array types is a number in JSON-Cadence
+
+		testRoundTrip(t,
+			cadence.TypeValue{
+				StaticType: cadence.ConstantSizedArrayType{
+					ElementType: cadence.IntType{},
+					Size:        3,
+				},
+			},
+		)
+	})
+}
+
+func TestDecodeInvalid(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := Decode(nil, []byte{0xc1})
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package proto converts Cadence values to and from protobuf messages,
+// so they can be carried as a `google.protobuf.Any`, e.g. in gRPC APIs.
+//
+// The value is represented as a `google.protobuf.Value`,
+// which has the same structure as the JSON-Cadence representation of the value,
+// see https://docs.onflow.org/cadence/json-cadence-spec/.
+// For example, the Cadence value `1 as UInt8` is represented as the struct value
+// `{"type": "UInt8", "value": "1"}`.
+//
+// As numbers are encoded as strings in JSON-Cadence, no precision is lost.
+package proto
+
+import (
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// ToAny returns the protobuf representation of the given value,
+// wrapped in a `google.protobuf.Any`.
+//
+// This function returns an error if the Cadence value cannot be represented as JSON-Cadence.
+func ToAny(value cadence.Value) (*anypb.Any, error) {
+	message, err := ToValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return anypb.New(message)
+}
+
+// FromAny returns the Cadence value of the given protobuf representation,
+// wrapped in a `google.protobuf.Any`, see ToAny.
+//
+// This function returns an error if the message is not a `google.protobuf.Value`,
+// or if it does not conform to the JSON Cadence specification.
+func FromAny(gauge common.MemoryGauge, message *anypb.Any) (cadence.Value, error) {
+	var value structpb.Value
+	err := message.UnmarshalTo(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromValue(gauge, &value)
+}
+
+// ToValue returns the protobuf representation of the given value.
+//
+// This function returns an error if the Cadence value cannot be represented as JSON-Cadence.
+func ToValue(value cadence.Value) (*structpb.Value, error) {
+	encoded, err := json.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var message structpb.Value
+	err = message.UnmarshalJSON(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// FromValue returns the Cadence value of the given protobuf representation, see ToValue.
+//
+// This function returns an error if the message does not conform to the JSON Cadence specification.
+func FromValue(gauge common.MemoryGauge, message *structpb.Value) (cadence.Value, error) {
+	encoded, err := message.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Decode(gauge, encoded)
+}
@@ -0,0 +1,129 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proto
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func testRoundTrip(t *testing.T, value cadence.Value) {
+	message, err := ToAny(value)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"type.googleapis.com/google.protobuf.Value",
+		message.GetTypeUrl(),
+	)
+
+	decoded, err := FromAny(nil, message)
+	require.NoError(t, err)
+
+	assert.Equal(t, value, decoded)
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("scalars", func(t *testing.T) {
+
+		t.Parallel()
+
+		bigInt, ok := new(big.Int).SetString("-123456789012345678901234567890", 10)
+		require.True(t, ok)
+
+		for _, value := range []cadence.Value{
+			cadence.NewVoid(),
+			cadence.NewBool(true),
+			cadence.String("foo"),
+			cadence.NewAddress([8]byte{0, 0, 0, 0, 0, 0, 0, 1}),
+			cadence.NewIntFromBig(bigInt),
+			cadence.NewUInt8(42),
+			cadence.NewInt64(-42),
+			cadence.NewUInt64(18446744073709551615),
+			cadence.NewWord32(7),
+			cadence.Fix64(-123456789),
+			cadence.UFix64(123456789),
+			cadence.NewOptional(nil),
+			cadence.NewOptional(cadence.String("bar")),
+		} {
+			testRoundTrip(t, value)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+
+		t.Parallel()
+
+		testRoundTrip(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewInt(2),
+				cadence.NewInt(3),
+			}),
+		)
+	})
+
+	t.Run("struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		structType := &cadence.StructType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Foo",
+			Fields: []cadence.Field{
+				{
+					Identifier: "a",
+					Type:       cadence.IntType{},
+				},
+				{
+					Identifier: "b",
+					Type:       cadence.StringType{},
+				},
+			},
+		}
+
+		testRoundTrip(t,
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.String("foo"),
+			}).WithType(structType),
+		)
+	})
+}
+
+func TestFromAnyInvalidMessage(t *testing.T) {
+
+	t.Parallel()
+
+	message, err := anypb.New(wrapperspb.String("foo"))
+	require.NoError(t, err)
+
+	_, err = FromAny(nil, message)
+	require.Error(t, err)
+}
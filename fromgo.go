@@ -0,0 +1,164 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// FromGoStructTag is the struct tag which names the Cadence field of a Go struct field,
+// see FromGoStruct.
+const FromGoStructTag = "cadence"
+
+// FromGoStruct converts the given Go struct, or pointer to a Go struct,
+// to a Cadence struct of the given type, e.g. to construct values in tests.
+//
+// The exported fields of the Go struct are mapped to the fields of the struct type by name,
+// which is the value of the FromGoStructTag tag of the field, e.g. `cadence:"id"`,
+// or the name of the Go field if the field has no tag.
+// Fields with the tag `cadence:"-"` are ignored.
+//
+// Field values which are Cadence values are used as-is,
+// all other values are converted using NewValue.
+// The struct must have a value for each field of the struct type,
+// and the converted struct must conform to the struct type, see ValidateValue.
+func FromGoStruct(gauge common.MemoryGauge, v any, ty *StructType) (Struct, error) {
+	if ty == nil {
+		return Struct{}, fmt.Errorf("cannot convert Go struct: missing struct type")
+	}
+
+	structValue := reflect.ValueOf(v)
+	if structValue.Kind() == reflect.Ptr {
+		if structValue.IsNil() {
+			return Struct{}, fmt.Errorf("cannot convert Go struct to `%s`: nil pointer", ty.ID())
+		}
+		structValue = structValue.Elem()
+	}
+
+	if structValue.Kind() != reflect.Struct {
+		return Struct{}, fmt.Errorf("cannot convert Go value of type %T to `%s`: not a struct", v, ty.ID())
+	}
+
+	goFields, err := goStructFields(structValue, ty)
+	if err != nil {
+		return Struct{}, fmt.Errorf("cannot convert Go struct to `%s`: %w", ty.ID(), err)
+	}
+
+	result, err := NewMeteredStruct(
+		gauge,
+		len(ty.Fields),
+		func() ([]Value, error) {
+			fields := make([]Value, len(ty.Fields))
+
+			for i, field := range ty.Fields {
+				goField, ok := goFields[field.Identifier]
+				if !ok {
+					return nil, fmt.Errorf("missing value for field `%s`", field.Identifier)
+				}
+
+				value, err := fromGoValue(goField)
+				if err != nil {
+					return nil, fmt.Errorf("field `%s`: %w", field.Identifier, err)
+				}
+
+				fields[i] = value
+			}
+
+			return fields, nil
+		},
+	)
+	if err != nil {
+		return Struct{}, fmt.Errorf("cannot convert Go struct to `%s`: %w", ty.ID(), err)
+	}
+
+	result = result.WithType(ty)
+
+	err = ValidateValue(result, ty)
+	if err != nil {
+		return Struct{}, fmt.Errorf("cannot convert Go struct to `%s`: %w", ty.ID(), err)
+	}
+
+	return result, nil
+}
+
+// goStructFields returns the values of the exported fields of the given Go struct,
+// keyed by the names of the fields of the given struct type, see FromGoStruct.
+func goStructFields(structValue reflect.Value, ty *StructType) (map[string]reflect.Value, error) {
+	fieldNames := make(map[string]struct{}, len(ty.Fields))
+	for _, field := range ty.Fields {
+		fieldNames[field.Identifier] = struct{}{}
+	}
+
+	structType := structValue.Type()
+
+	fields := map[string]reflect.Value{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		name, ok := goStructFieldName(structType.Field(i))
+		if !ok {
+			continue
+		}
+
+		if _, ok := fieldNames[name]; !ok {
+			return nil, fmt.Errorf("unknown field `%s`", name)
+		}
+
+		if _, ok := fields[name]; ok {
+			return nil, fmt.Errorf("duplicate field `%s`", name)
+		}
+
+		fields[name] = structValue.Field(i)
+	}
+
+	return fields, nil
+}
+
+// goStructFieldName returns the name of the Cadence field of the given Go struct field,
+// i.e. the value of the FromGoStructTag tag, or the name of the Go field, if it has no tag.
+// Unexported fields and fields with the tag `cadence:"-"` have no Cadence field.
+func goStructFieldName(field reflect.StructField) (name string, ok bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	if tag, ok := field.Tag.Lookup(FromGoStructTag); ok {
+		if tag == "-" {
+			return "", false
+		}
+		return tag, true
+	}
+
+	return field.Name, true
+}
+
+func fromGoValue(goValue reflect.Value) (Value, error) {
+	value := goValue.Interface()
+
+	switch value := value.(type) {
+	case Value:
+		return value, nil
+	case bool:
+		return NewBool(value), nil
+	default:
+		return NewValue(value)
+	}
+}
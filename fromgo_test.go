@@ -0,0 +1,144 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestFromGoStruct(t *testing.T) {
+
+	t.Parallel()
+
+	structType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []Field{
+			{
+				Identifier: "id",
+				Type:       UInt64Type{},
+			},
+			{
+				Identifier: "name",
+				Type:       StringType{},
+			},
+		},
+	}
+
+	t.Run("tagged struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		type foo struct {
+			ID       UInt64 `cadence:"id"`
+			Name     string `cadence:"name"`
+			Internal int    `cadence:"-"`
+			ignored  bool
+		}
+
+		actual, err := FromGoStruct(
+			nil,
+			foo{
+				ID:       NewUInt64(42),
+				Name:     "test",
+				Internal: 1,
+				ignored:  true,
+			},
+			structType,
+		)
+		require.NoError(t, err)
+
+		expected := NewStruct([]Value{
+			NewUInt64(42),
+			String("test"),
+		}).WithType(structType)
+
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("pointer, untagged", func(t *testing.T) {
+
+		t.Parallel()
+
+		type foo struct {
+			id   UInt64
+			Name string `cadence:"name"`
+		}
+
+		type bar struct {
+			Name string `cadence:"name"`
+			ID   UInt64 `cadence:"id"`
+		}
+
+		_, err := FromGoStruct(nil, &foo{Name: "test"}, structType)
+		require.EqualError(t, err, "cannot convert Go struct to `S.test.Foo`: missing value for field `id`")
+
+		actual, err := FromGoStruct(nil, &bar{ID: NewUInt64(1), Name: "test"}, structType)
+		require.NoError(t, err)
+		assert.Equal(t,
+			NewStruct([]Value{NewUInt64(1), String("test")}).WithType(structType),
+			actual,
+		)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+
+		t.Parallel()
+
+		type foo struct {
+			ID    UInt64 `cadence:"id"`
+			Name  string `cadence:"name"`
+			Extra string
+		}
+
+		_, err := FromGoStruct(nil, foo{}, structType)
+		require.EqualError(t, err, "cannot convert Go struct to `S.test.Foo`: unknown field `Extra`")
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		type foo struct {
+			ID   int    `cadence:"id"`
+			Name string `cadence:"name"`
+		}
+
+		_, err := FromGoStruct(nil, foo{ID: 1}, structType)
+		require.EqualError(t,
+			err,
+			"cannot convert Go struct to `S.test.Foo`: "+
+				"invalid value at `id`: expected value of type `UInt64`, got value of type `Int`",
+		)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := FromGoStruct(nil, 42, structType)
+		require.EqualError(t, err, "cannot convert Go value of type int to `S.test.Foo`: not a struct")
+	})
+
+}
@@ -0,0 +1,306 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// InferType returns the most specific type of the given value,
+// e.g. the expected type which is needed to import the value.
+//
+// The element types of arrays and the key and value types of dictionaries
+// are the least common supertypes of the types of the elements, keys, and values,
+// like the types inferred when arrays and dictionaries are imported without an expected type,
+// e.g. `[Int]` for an array of Int values, `[Integer]` for an array of Int and UInt8 values,
+// `[Int?]` for an array of Int values and nil, and `[AnyStruct]` for an array of Int and String values.
+// The element type of empty arrays, and the key and value types of empty dictionaries, is Never.
+//
+// Inferring the type fails if the value is a composite without a type,
+// or if the value contains a collection which has both resources and non-resources.
+func InferType(value Value) (Type, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("cannot infer type: missing value")
+
+	case Optional:
+		if v.Value == nil {
+			return OptionalType{Type: NeverType{}}, nil
+		}
+		innerType, err := InferType(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return OptionalType{Type: innerType}, nil
+
+	case Array:
+		elementTypes := make([]Type, len(v.Values))
+		for i, element := range v.Values {
+			elementType, err := InferType(element)
+			if err != nil {
+				return nil, err
+			}
+			elementTypes[i] = elementType
+		}
+
+		elementType, err := leastCommonSuperType(elementTypes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot infer element type of array: %w", err)
+		}
+
+		return VariableSizedArrayType{ElementType: elementType}, nil
+
+	case Dictionary:
+		keyTypes := make([]Type, len(v.Pairs))
+		valueTypes := make([]Type, len(v.Pairs))
+		for i, pair := range v.Pairs {
+			keyType, err := InferType(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			keyTypes[i] = keyType
+
+			valueType, err := InferType(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			valueTypes[i] = valueType
+		}
+
+		keyType, err := leastCommonSuperType(keyTypes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot infer key type of dictionary: %w", err)
+		}
+
+		valueType, err := leastCommonSuperType(valueTypes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot infer value type of dictionary: %w", err)
+		}
+
+		return DictionaryType{
+			KeyType:     keyType,
+			ElementType: valueType,
+		}, nil
+
+	case Struct, Resource, Event, Contract, Enum:
+		compositeType := compositeTypeOfValue(v)
+		if compositeType == nil {
+			return nil, fmt.Errorf("cannot infer type: missing type of composite")
+		}
+		return compositeType, nil
+
+	case Path:
+		switch common.PathDomainFromIdentifier(v.Domain) {
+		case common.PathDomainStorage:
+			return StoragePathType{}, nil
+		case common.PathDomainPublic:
+			return PublicPathType{}, nil
+		case common.PathDomainPrivate:
+			return PrivatePathType{}, nil
+		default:
+			return PathType{}, nil
+		}
+
+	case TypeValue:
+		return MetaType{}, nil
+
+	default:
+		return value.Type(), nil
+	}
+}
+
+// leastCommonSuperType returns the least common supertype of the given types,
+// using sema.LeastCommonSuperType, like the importer does for arrays and dictionaries.
+func leastCommonSuperType(types []Type) (Type, error) {
+	if len(types) == 0 {
+		return NeverType{}, nil
+	}
+
+	converter := superTypeConverter{
+		convertedTypes: map[sema.TypeID]Type{},
+	}
+
+	semaTypes := make([]sema.Type, len(types))
+	for i, ty := range types {
+		semaTypes[i] = converter.semaType(ty)
+	}
+
+	superType := sema.LeastCommonSuperType(semaTypes...)
+	if superType == sema.InvalidType {
+		return nil, fmt.Errorf("types are both resource and non-resource types")
+	}
+
+	return converter.cadenceType(superType)
+}
+
+// superTypeConverter converts types to sema types and back, see leastCommonSuperType.
+//
+// Only the types which are relevant for the least common supertype are converted structurally,
+// i.e. optionals, arrays, dictionaries, and the builtin types.
+// All other types, e.g. composites, are converted to placeholder composite types
+// of the same kind and with the same type ID, so they are only equal to themselves.
+type superTypeConverter struct {
+	// convertedTypes are the given types, by the type IDs of their sema types
+	convertedTypes map[sema.TypeID]Type
+}
+
+func (c superTypeConverter) semaType(ty Type) sema.Type {
+	var result sema.Type
+
+	switch ty := ty.(type) {
+	case OptionalType:
+		result = &sema.OptionalType{
+			Type: c.semaType(ty.Type),
+		}
+
+	case VariableSizedArrayType:
+		result = &sema.VariableSizedType{
+			Type: c.semaType(ty.ElementType),
+		}
+
+	case ConstantSizedArrayType:
+		result = &sema.ConstantSizedType{
+			Type: c.semaType(ty.ElementType),
+			Size: int64(ty.Size),
+		}
+
+	case DictionaryType:
+		result = &sema.DictionaryType{
+			KeyType:   c.semaType(ty.KeyType),
+			ValueType: c.semaType(ty.ElementType),
+		}
+
+	default:
+		if variable := sema.BaseTypeActivation.Find(ty.ID()); variable != nil {
+			result = variable.Type
+			break
+		}
+
+		kind := common.CompositeKindStructure
+		if isResourceType(ty) {
+			kind = common.CompositeKindResource
+		}
+
+		result = &sema.CompositeType{
+			Identifier: ty.ID(),
+			Kind:       kind,
+		}
+	}
+
+	c.convertedTypes[result.ID()] = ty
+
+	return result
+}
+
+func (c superTypeConverter) cadenceType(ty sema.Type) (Type, error) {
+	if convertedType, ok := c.convertedTypes[ty.ID()]; ok {
+		return convertedType, nil
+	}
+
+	switch ty := ty.(type) {
+	case *sema.OptionalType:
+		innerType, err := c.cadenceType(ty.Type)
+		if err != nil {
+			return nil, err
+		}
+		return OptionalType{Type: innerType}, nil
+
+	case *sema.VariableSizedType:
+		elementType, err := c.cadenceType(ty.Type)
+		if err != nil {
+			return nil, err
+		}
+		return VariableSizedArrayType{ElementType: elementType}, nil
+
+	case *sema.ConstantSizedType:
+		elementType, err := c.cadenceType(ty.Type)
+		if err != nil {
+			return nil, err
+		}
+		return ConstantSizedArrayType{
+			ElementType: elementType,
+			Size:        uint(ty.Size),
+		}, nil
+
+	case *sema.DictionaryType:
+		keyType, err := c.cadenceType(ty.KeyType)
+		if err != nil {
+			return nil, err
+		}
+		valueType, err := c.cadenceType(ty.ValueType)
+		if err != nil {
+			return nil, err
+		}
+		return DictionaryType{
+			KeyType:     keyType,
+			ElementType: valueType,
+		}, nil
+	}
+
+	switch ty {
+	case sema.NeverType:
+		return NeverType{}, nil
+	case sema.AnyStructType:
+		return AnyStructType{}, nil
+	case sema.AnyResourceType:
+		return AnyResourceType{}, nil
+	case sema.NumberType:
+		return NumberType{}, nil
+	case sema.SignedNumberType:
+		return SignedNumberType{}, nil
+	case sema.IntegerType:
+		return IntegerType{}, nil
+	case sema.SignedIntegerType:
+		return SignedIntegerType{}, nil
+	case sema.FixedPointType:
+		return FixedPointType{}, nil
+	case sema.SignedFixedPointType:
+		return SignedFixedPointType{}, nil
+	case sema.PathType:
+		return PathType{}, nil
+	case sema.CapabilityPathType:
+		return CapabilityPathType{}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported supertype `%s`", ty.QualifiedString())
+}
+
+// isResourceType returns true if the given type is a resource type,
+// or contains a resource type.
+func isResourceType(ty Type) bool {
+	switch ty := ty.(type) {
+	case *ResourceType, *ResourceInterfaceType, AnyResourceType:
+		return true
+	case OptionalType:
+		return isResourceType(ty.Type)
+	case VariableSizedArrayType:
+		return isResourceType(ty.ElementType)
+	case ConstantSizedArrayType:
+		return isResourceType(ty.ElementType)
+	case DictionaryType:
+		return isResourceType(ty.ElementType)
+	case *RestrictedType:
+		return isResourceType(ty.Type)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,241 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestInferType(t *testing.T) {
+
+	t.Parallel()
+
+	structType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "S",
+	}
+
+	resourceType := &ResourceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "R",
+	}
+
+	type inferTypeTest struct {
+		name     string
+		value    Value
+		expected Type
+	}
+
+	tests := []inferTypeTest{
+		{
+			name:     "Int",
+			value:    NewInt(1),
+			expected: IntType{},
+		},
+		{
+			name:     "nil",
+			value:    NewOptional(nil),
+			expected: OptionalType{Type: NeverType{}},
+		},
+		{
+			name:     "storage path",
+			value:    Path{Domain: "storage", Identifier: "foo"},
+			expected: StoragePathType{},
+		},
+		{
+			name:     "struct",
+			value:    NewStruct(nil).WithType(structType),
+			expected: structType,
+		},
+		{
+			name:     "empty array",
+			value:    NewArray(nil),
+			expected: VariableSizedArrayType{ElementType: NeverType{}},
+		},
+		{
+			name: "homogeneous array",
+			value: NewArray([]Value{
+				NewInt(1),
+				NewInt(2),
+			}),
+			expected: VariableSizedArrayType{ElementType: IntType{}},
+		},
+		{
+			name: "array of structs",
+			value: NewArray([]Value{
+				NewStruct(nil).WithType(structType),
+				NewStruct(nil).WithType(structType),
+			}),
+			expected: VariableSizedArrayType{ElementType: structType},
+		},
+		{
+			name: "array of signed integers",
+			value: NewArray([]Value{
+				NewInt(1),
+				NewInt8(2),
+			}),
+			expected: VariableSizedArrayType{ElementType: SignedIntegerType{}},
+		},
+		{
+			name: "array of integers",
+			value: NewArray([]Value{
+				NewInt(1),
+				NewUInt8(2),
+			}),
+			expected: VariableSizedArrayType{ElementType: IntegerType{}},
+		},
+		{
+			name: "array of numbers",
+			value: NewArray([]Value{
+				NewUInt8(1),
+				UFix64(2),
+			}),
+			expected: VariableSizedArrayType{ElementType: NumberType{}},
+		},
+		{
+			name: "array of optionals",
+			value: NewArray([]Value{
+				NewInt(1),
+				NewOptional(nil),
+				NewOptional(NewInt(2)),
+			}),
+			expected: VariableSizedArrayType{ElementType: OptionalType{Type: IntType{}}},
+		},
+		{
+			name: "array of capability paths",
+			value: NewArray([]Value{
+				Path{Domain: "public", Identifier: "foo"},
+				Path{Domain: "private", Identifier: "bar"},
+			}),
+			expected: VariableSizedArrayType{ElementType: CapabilityPathType{}},
+		},
+		{
+			name: "array of paths",
+			value: NewArray([]Value{
+				Path{Domain: "storage", Identifier: "foo"},
+				Path{Domain: "public", Identifier: "bar"},
+			}),
+			expected: VariableSizedArrayType{ElementType: PathType{}},
+		},
+		{
+			name: "array of different structs",
+			value: NewArray([]Value{
+				NewStruct(nil).WithType(structType),
+				NewStruct(nil).WithType(&StructType{
+					Location:            utils.TestLocation,
+					QualifiedIdentifier: "T",
+				}),
+			}),
+			expected: VariableSizedArrayType{ElementType: AnyStructType{}},
+		},
+		{
+			name: "nested arrays",
+			value: NewArray([]Value{
+				NewArray([]Value{NewInt(1)}),
+				NewArray([]Value{NewUInt8(2)}),
+				NewArray(nil),
+			}),
+			expected: VariableSizedArrayType{
+				ElementType: VariableSizedArrayType{ElementType: IntegerType{}},
+			},
+		},
+		{
+			name: "heterogeneous array",
+			value: NewArray([]Value{
+				NewInt(1),
+				String("two"),
+				NewOptional(nil),
+			}),
+			expected: VariableSizedArrayType{ElementType: AnyStructType{}},
+		},
+		{
+			name: "array of resources",
+			value: NewArray([]Value{
+				NewResource(nil).WithType(resourceType),
+				NewResource(nil).WithType(&ResourceType{
+					Location:            utils.TestLocation,
+					QualifiedIdentifier: "R2",
+				}),
+			}),
+			expected: VariableSizedArrayType{ElementType: AnyResourceType{}},
+		},
+		{
+			name: "homogeneous dictionary",
+			value: NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: String("b"), Value: NewInt(2)},
+			}),
+			expected: DictionaryType{
+				KeyType:     StringType{},
+				ElementType: IntType{},
+			},
+		},
+		{
+			name: "heterogeneous dictionary",
+			value: NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: NewInt(2), Value: String("b")},
+			}),
+			expected: DictionaryType{
+				KeyType:     AnyStructType{},
+				ElementType: AnyStructType{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			actual, err := InferType(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	t.Run("resources and structs", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := InferType(NewArray([]Value{
+			NewStruct(nil).WithType(structType),
+			NewResource(nil).WithType(resourceType),
+		}))
+		require.EqualError(t,
+			err,
+			"cannot infer element type of array: types are both resource and non-resource types",
+		)
+	})
+
+	t.Run("composite without type", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := InferType(NewStruct(nil))
+		require.EqualError(t, err, "cannot infer type: missing type of composite")
+	})
+}
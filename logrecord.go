@@ -0,0 +1,156 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LogRecordTypeKey is the reserved key of the event type ID entry of a log record,
+// see EventToLogRecord.
+const LogRecordTypeKey = "__type"
+
+// EventToLogRecord flattens the given event to a log record,
+// i.e. a map from the names of the top-level fields of the event to string values,
+// e.g. for indexers.
+//
+// Scalar values are stringified, e.g. `42`, `true`, or `0x1`, strings are not quoted.
+// Nested values, i.e. composites, arrays, and dictionaries, are encoded as JSON,
+// in which composites are objects from field names to values, dictionaries are objects
+// from stringified keys to values, and scalars are strings.
+// Nil is stringified as `nil` at the top-level, and encoded as JSON null in nested values.
+//
+// The event type ID is included under the reserved key LogRecordTypeKey.
+func EventToLogRecord(e Event) (map[string]string, error) {
+	if e.EventType == nil {
+		return nil, fmt.Errorf("cannot convert event to log record: missing event type")
+	}
+
+	fields := e.EventType.Fields
+	if len(fields) != len(e.Fields) {
+		return nil, fmt.Errorf(
+			"cannot convert event `%s` to log record: expected %d fields, got %d",
+			e.EventType.ID(),
+			len(fields),
+			len(e.Fields),
+		)
+	}
+
+	record := make(map[string]string, len(fields)+1)
+	record[LogRecordTypeKey] = e.EventType.ID()
+
+	for i, field := range fields {
+		if field.Identifier == LogRecordTypeKey {
+			return nil, fmt.Errorf(
+				"cannot convert event `%s` to log record: field `%s` has a reserved name",
+				e.EventType.ID(),
+				field.Identifier,
+			)
+		}
+
+		value, err := logRecordValue(e.Fields[i])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"cannot convert event `%s` to log record: field `%s`: %w",
+				e.EventType.ID(),
+				field.Identifier,
+				err,
+			)
+		}
+
+		record[field.Identifier] = value
+	}
+
+	return record, nil
+}
+
+func logRecordValue(value Value) (string, error) {
+	if optional, ok := value.(Optional); ok {
+		if optional.Value == nil {
+			return "nil", nil
+		}
+		return logRecordValue(optional.Value)
+	}
+
+	switch value.(type) {
+	case Struct, Resource, Event, Contract, Enum, Array, Dictionary:
+		encoded, err := json.Marshal(logRecordJSONValue(value))
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+
+	return logRecordScalar(value), nil
+}
+
+// logRecordJSONValue returns the representation of the given nested value
+// which is encoded as JSON, see EventToLogRecord.
+func logRecordJSONValue(value Value) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+
+	case Optional:
+		if v.Value == nil {
+			return nil
+		}
+		return logRecordJSONValue(v.Value)
+
+	case Array:
+		result := make([]any, len(v.Values))
+		for i, element := range v.Values {
+			result[i] = logRecordJSONValue(element)
+		}
+		return result
+
+	case Dictionary:
+		result := make(map[string]any, len(v.Pairs))
+		for _, pair := range v.Pairs {
+			result[logRecordScalar(pair.Key)] = logRecordJSONValue(pair.Value)
+		}
+		return result
+
+	case Struct, Resource, Event, Contract, Enum:
+		fields, values, _ := compositeFieldsAndValues(v)
+		result := make(map[string]any, len(fields))
+		for i, field := range fields {
+			if i >= len(values) {
+				break
+			}
+			result[field.Identifier] = logRecordJSONValue(values[i])
+		}
+		return result
+
+	default:
+		return logRecordScalar(value)
+	}
+}
+
+func logRecordScalar(value Value) string {
+	switch v := value.(type) {
+	case String:
+		return string(v)
+	case Character:
+		return string(v)
+	default:
+		return value.String()
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestEventToLogRecord(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []Field{
+			{
+				Identifier: "a",
+				Type:       IntType{},
+			},
+			{
+				Identifier: "b",
+				Type:       NewOptionalType(StringType{}),
+			},
+		},
+	}
+
+	t.Run("scalar and nested fields", func(t *testing.T) {
+
+		t.Parallel()
+
+		eventType := &EventType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Transferred",
+			Fields: []Field{
+				{Identifier: "amount", Type: UFix64Type{}},
+				{Identifier: "to", Type: AddressType{}},
+				{Identifier: "memo", Type: StringType{}},
+				{Identifier: "done", Type: BoolType{}},
+				{Identifier: "note", Type: NewOptionalType(StringType{})},
+				{Identifier: "foo", Type: fooType},
+				{Identifier: "ids", Type: VariableSizedArrayType{ElementType: UInt64Type{}}},
+				{
+					Identifier: "balances",
+					Type: DictionaryType{
+						KeyType:     StringType{},
+						ElementType: IntType{},
+					},
+				},
+			},
+		}
+
+		amount, err := NewUFix64("1.5")
+		require.NoError(t, err)
+
+		event := NewEvent([]Value{
+			amount,
+			BytesToAddress([]byte{0x1}),
+			String("hello"),
+			NewBool(true),
+			NewOptional(nil),
+			NewStruct([]Value{
+				NewInt(42),
+				NewOptional(String("x")),
+			}).WithType(fooType),
+			NewArray([]Value{
+				NewUInt64(1),
+				NewUInt64(2),
+			}),
+			NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+			}),
+		}).WithType(eventType)
+
+		record, err := EventToLogRecord(event)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			map[string]string{
+				LogRecordTypeKey: "S.test.Transferred",
+				"amount":         "1.50000000",
+				"to":             "0x0000000000000001",
+				"memo":           "hello",
+				"done":           "true",
+				"note":           "nil",
+				"foo":            `{"a":"42","b":"x"}`,
+				"ids":            `["1","2"]`,
+				"balances":       `{"a":"1"}`,
+			},
+			record,
+		)
+	})
+
+	t.Run("missing type", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := EventToLogRecord(NewEvent([]Value{NewInt(1)}))
+		require.EqualError(t, err, "cannot convert event to log record: missing event type")
+	})
+
+	t.Run("reserved field name", func(t *testing.T) {
+
+		t.Parallel()
+
+		eventType := &EventType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "E",
+			Fields: []Field{
+				{Identifier: LogRecordTypeKey, Type: IntType{}},
+			},
+		}
+
+		_, err := EventToLogRecord(NewEvent([]Value{NewInt(1)}).WithType(eventType))
+		require.EqualError(t, err,
+			"cannot convert event `S.test.E` to log record: field `__type` has a reserved name",
+		)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+)
+
+// MergeDictionaries returns the union of the pairs of the given dictionaries,
+// e.g. to combine the results of multiple scripts.
+//
+// The pairs of a come first, in order, followed by the pairs of b whose keys are not in a.
+// Keys are compared by type and string representation, like in Diff.
+// The value of a key which is in both dictionaries is the value returned by onConflict
+// for the key and the values of a and b. If onConflict is nil, a conflict is an error.
+//
+// The dictionaries must have the same type, if both have a type,
+// and the merged dictionary has the type of either.
+// Resolved values must conform to the element type, see ValidateValue.
+func MergeDictionaries(
+	a, b Dictionary,
+	onConflict func(key, aValue, bValue Value) Value,
+) (Dictionary, error) {
+	dictionaryType, err := mergedDictionaryType(a, b)
+	if err != nil {
+		return Dictionary{}, err
+	}
+
+	bIndices := make(map[string]int, len(b.Pairs))
+	for i, pair := range b.Pairs {
+		bIndices[mergeKey(pair.Key)] = i
+	}
+
+	pairs := make([]KeyValuePair, 0, len(a.Pairs)+len(b.Pairs))
+	aKeys := make(map[string]struct{}, len(a.Pairs))
+
+	for _, pair := range a.Pairs {
+		key := mergeKey(pair.Key)
+		aKeys[key] = struct{}{}
+
+		bIndex, ok := bIndices[key]
+		if ok {
+			if onConflict == nil {
+				return Dictionary{}, fmt.Errorf(
+					"cannot merge dictionaries: conflicting key %s",
+					pair.Key,
+				)
+			}
+
+			value := onConflict(pair.Key, pair.Value, b.Pairs[bIndex].Value)
+
+			if dictionaryType != nil {
+				err := ValidateValue(value, dictionaryType.ElementType)
+				if err != nil {
+					return Dictionary{}, fmt.Errorf(
+						"cannot merge dictionaries: resolved value for key %s: %w",
+						pair.Key,
+						err,
+					)
+				}
+			}
+
+			pair = KeyValuePair{
+				Key:   pair.Key,
+				Value: value,
+			}
+		}
+
+		pairs = append(pairs, pair)
+	}
+
+	for _, pair := range b.Pairs {
+		if _, ok := aKeys[mergeKey(pair.Key)]; ok {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+
+	result := NewDictionary(pairs)
+	if dictionaryType != nil {
+		result = result.WithType(*dictionaryType)
+	}
+
+	return result, nil
+}
+
+// mergedDictionaryType returns the type of the merged dictionary of the given dictionaries, if any.
+func mergedDictionaryType(a, b Dictionary) (*DictionaryType, error) {
+	aType, aTyped := a.DictionaryType.(DictionaryType)
+	bType, bTyped := b.DictionaryType.(DictionaryType)
+
+	switch {
+	case aTyped && bTyped:
+		if CanonicalTypeID(aType) != CanonicalTypeID(bType) {
+			return nil, fmt.Errorf(
+				"cannot merge dictionaries: incompatible types `%s` and `%s`",
+				aType.ID(),
+				bType.ID(),
+			)
+		}
+		return &aType, nil
+
+	case aTyped:
+		return &aType, nil
+
+	case bTyped:
+		return &bType, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// mergeKey returns the key by which the given dictionary key is compared, see MergeDictionaries.
+func mergeKey(key Value) string {
+	if key == nil {
+		return ""
+	}
+	typeID, _ := diffTypeID(key)
+	return typeID + ":" + key.String()
+}
@@ -0,0 +1,184 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDictionaries(t *testing.T) {
+
+	t.Parallel()
+
+	dictionaryType := DictionaryType{
+		KeyType:     StringType{},
+		ElementType: IntType{},
+	}
+
+	sum := func(_, aValue, bValue Value) Value {
+		return NewIntFromBig(new(big.Int).Add(
+			aValue.(Int).Value,
+			bValue.(Int).Value,
+		))
+	}
+
+	t.Run("disjoint", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(1)},
+			{Key: String("b"), Value: NewInt(2)},
+		}).WithType(dictionaryType)
+
+		b := NewDictionary([]KeyValuePair{
+			{Key: String("c"), Value: NewInt(3)},
+		})
+
+		merged, err := MergeDictionaries(a, b, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: String("b"), Value: NewInt(2)},
+				{Key: String("c"), Value: NewInt(3)},
+			}).WithType(dictionaryType),
+			merged,
+		)
+	})
+
+	t.Run("conflicting keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(1)},
+			{Key: String("b"), Value: NewInt(2)},
+		}).WithType(dictionaryType)
+
+		b := NewDictionary([]KeyValuePair{
+			{Key: String("b"), Value: NewInt(3)},
+			{Key: String("c"), Value: NewInt(4)},
+		}).WithType(dictionaryType)
+
+		var conflictingKeys []Value
+
+		merged, err := MergeDictionaries(a, b, func(key, aValue, bValue Value) Value {
+			conflictingKeys = append(conflictingKeys, key)
+			return sum(key, aValue, bValue)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Value{String("b")}, conflictingKeys)
+
+		assert.Equal(t,
+			NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: String("b"), Value: NewInt(5)},
+				{Key: String("c"), Value: NewInt(4)},
+			}).WithType(dictionaryType),
+			merged,
+		)
+	})
+
+	t.Run("keys of different types", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary([]KeyValuePair{
+			{Key: NewInt(1), Value: String("a")},
+		})
+
+		b := NewDictionary([]KeyValuePair{
+			{Key: NewUInt8(1), Value: String("b")},
+		})
+
+		merged, err := MergeDictionaries(a, b, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			NewDictionary([]KeyValuePair{
+				{Key: NewInt(1), Value: String("a")},
+				{Key: NewUInt8(1), Value: String("b")},
+			}),
+			merged,
+		)
+	})
+
+	t.Run("conflict without resolver", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(1)},
+		})
+
+		b := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(2)},
+		})
+
+		_, err := MergeDictionaries(a, b, nil)
+		require.EqualError(t, err, `cannot merge dictionaries: conflicting key "a"`)
+	})
+
+	t.Run("incompatible types", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary(nil).WithType(dictionaryType)
+
+		b := NewDictionary(nil).WithType(DictionaryType{
+			KeyType:     StringType{},
+			ElementType: StringType{},
+		})
+
+		_, err := MergeDictionaries(a, b, sum)
+		require.EqualError(t,
+			err,
+			"cannot merge dictionaries: incompatible types `{String:Int}` and `{String:String}`",
+		)
+	})
+
+	t.Run("invalid resolved value", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(1)},
+		}).WithType(dictionaryType)
+
+		b := NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewInt(2)},
+		}).WithType(dictionaryType)
+
+		_, err := MergeDictionaries(a, b, func(_, _, _ Value) Value {
+			return String("conflict")
+		})
+		require.EqualError(t,
+			err,
+			"cannot merge dictionaries: resolved value for key \"a\": "+
+				"invalid value: expected value of type `Int`, got value of type `String`",
+		)
+	})
+}
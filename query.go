@@ -0,0 +1,232 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type querySegmentKind uint8
+
+const (
+	querySegmentKindField querySegmentKind = iota
+	querySegmentKindIndex
+	querySegmentKindKey
+)
+
+type querySegment struct {
+	kind  querySegmentKind
+	field string
+	index int
+	key   string
+}
+
+func (s querySegment) String() string {
+	switch s.kind {
+	case querySegmentKindField:
+		return "." + s.field
+	case querySegmentKindIndex:
+		return fmt.Sprintf("[%d]", s.index)
+	case querySegmentKindKey:
+		return fmt.Sprintf("[%q]", s.key)
+	default:
+		panic("invalid query segment kind")
+	}
+}
+
+// Query returns the value nested in the given value at the given path.
+//
+// A path consists of a sequence of segments:
+// Dotted identifiers access composite fields (e.g. `owner.address`),
+// `[i]` accesses the element at index i of an array,
+// and `["k"]` accesses the value for the string key k of a dictionary.
+// Optionals are unwrapped implicitly. An empty path returns the given value.
+func Query(value Value, path string) (Value, error) {
+	segments, err := parseQueryPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var current strings.Builder
+
+	for _, segment := range segments {
+		current.WriteString(segment.String())
+
+		// Unwrap optionals
+		for {
+			optional, ok := value.(Optional)
+			if !ok {
+				break
+			}
+			if optional.Value == nil {
+				return nil, fmt.Errorf("cannot query %s: value is nil", current.String())
+			}
+			value = optional.Value
+		}
+
+		switch segment.kind {
+		case querySegmentKindField:
+			fields, values, ok := compositeFieldsAndValues(value)
+			if !ok {
+				return nil, fmt.Errorf(
+					"cannot query %s: expected composite, got %T",
+					current.String(),
+					value,
+				)
+			}
+
+			var found bool
+			for i, field := range fields {
+				if field.Identifier == segment.field && i < len(values) {
+					value = values[i]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("cannot query %s: missing field", current.String())
+			}
+
+		case querySegmentKindIndex:
+			array, ok := value.(Array)
+			if !ok {
+				return nil, fmt.Errorf(
+					"cannot query %s: expected array, got %T",
+					current.String(),
+					value,
+				)
+			}
+
+			if segment.index >= len(array.Values) {
+				return nil, fmt.Errorf(
+					"cannot query %s: index out of bounds, array has %d elements",
+					current.String(),
+					len(array.Values),
+				)
+			}
+			value = array.Values[segment.index]
+
+		case querySegmentKindKey:
+			dictionary, ok := value.(Dictionary)
+			if !ok {
+				return nil, fmt.Errorf(
+					"cannot query %s: expected dictionary, got %T",
+					current.String(),
+					value,
+				)
+			}
+
+			var found bool
+			for _, pair := range dictionary.Pairs {
+				if key, ok := pair.Key.(String); ok && string(key) == segment.key {
+					value = pair.Value
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("cannot query %s: missing key", current.String())
+			}
+		}
+	}
+
+	return value, nil
+}
+
+func parseQueryPath(path string) ([]querySegment, error) {
+	var segments []querySegment
+
+	offset := 0
+	for offset < len(path) {
+		switch path[offset] {
+		case '.':
+			if offset == 0 {
+				return nil, fmt.Errorf("invalid query path %q: unexpected leading '.'", path)
+			}
+			offset++
+			fallthrough
+
+		default:
+			end := offset
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			field := path[offset:end]
+			if field == "" {
+				return nil, fmt.Errorf("invalid query path %q: missing field name at offset %d", path, offset)
+			}
+			segments = append(segments, querySegment{
+				kind:  querySegmentKindField,
+				field: field,
+			})
+			offset = end
+
+		case '[':
+			start := offset + 1
+			end := start
+
+			// Skip over quoted keys, which may contain brackets
+			if end < len(path) && path[end] == '"' {
+				end++
+				for end < len(path) && path[end] != '"' {
+					if path[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end++
+			}
+
+			for end < len(path) && path[end] != ']' {
+				end++
+			}
+			if end >= len(path) {
+				return nil, fmt.Errorf("invalid query path %q: missing ']' at offset %d", path, offset)
+			}
+
+			content := path[start:end]
+
+			if strings.HasPrefix(content, `"`) {
+				key, err := strconv.Unquote(content)
+				if err != nil {
+					return nil, fmt.Errorf("invalid query path %q: invalid key %s", path, content)
+				}
+				segments = append(segments, querySegment{
+					kind: querySegmentKindKey,
+					key:  key,
+				})
+			} else {
+				index, err := strconv.Atoi(content)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid query path %q: invalid index %s", path, content)
+				}
+				segments = append(segments, querySegment{
+					kind:  querySegmentKindIndex,
+					index: index,
+				})
+			}
+
+			offset = end + 1
+		}
+	}
+
+	return segments, nil
+}
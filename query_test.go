@@ -0,0 +1,175 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestQuery(t *testing.T) {
+
+	t.Parallel()
+
+	ownerType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Owner",
+		Fields: []Field{
+			{
+				Identifier: "address",
+				Type:       AddressType{},
+			},
+		},
+	}
+
+	vaultType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Vault",
+		Fields: []Field{
+			{
+				Identifier: "owner",
+				Type:       NewOptionalType(ownerType),
+			},
+			{
+				Identifier: "balances",
+				Type: DictionaryType{
+					KeyType:     StringType{},
+					ElementType: IntType{},
+				},
+			},
+			{
+				Identifier: "owners",
+				Type: VariableSizedArrayType{
+					ElementType: ownerType,
+				},
+			},
+		},
+	}
+
+	address := NewAddress([8]byte{0, 0, 0, 0, 0, 0, 0, 1})
+
+	owner := NewStruct([]Value{address}).WithType(ownerType)
+
+	vault := NewStruct([]Value{
+		NewOptional(owner),
+		NewDictionary([]KeyValuePair{
+			{
+				Key:   String("a"),
+				Value: NewInt(1),
+			},
+			{
+				Key:   String("b]"),
+				Value: NewInt(2),
+			},
+		}),
+		NewArray([]Value{
+			owner,
+		}),
+	}).WithType(vaultType)
+
+	t.Run("empty path", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Query(vault, "")
+		require.NoError(t, err)
+		assert.Equal(t, vault, actual)
+	})
+
+	t.Run("nested field", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Query(vault, "owner.address")
+		require.NoError(t, err)
+		assert.Equal(t, address, actual)
+	})
+
+	t.Run("array element", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Query(vault, "owners[0].address")
+		require.NoError(t, err)
+		assert.Equal(t, address, actual)
+	})
+
+	t.Run("dictionary value", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Query(vault, `balances["a"]`)
+		require.NoError(t, err)
+		assert.Equal(t, NewInt(1), actual)
+
+		actual, err = Query(vault, `balances["b]"]`)
+		require.NoError(t, err)
+		assert.Equal(t, NewInt(2), actual)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Query(vault, "owner.name")
+		require.EqualError(t, err, "cannot query .owner.name: missing field")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Query(vault, `balances["c"]`)
+		require.EqualError(t, err, `cannot query .balances["c"]: missing key`)
+	})
+
+	t.Run("index out of bounds", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Query(vault, "owners[1]")
+		require.EqualError(t, err, "cannot query .owners[1]: index out of bounds, array has 1 elements")
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Query(vault, "balances[0]")
+		require.EqualError(t, err, "cannot query .balances[0]: expected array, got cadence.Dictionary")
+
+		_, err = Query(vault, `owners["a"]`)
+		require.EqualError(t, err, `cannot query .owners["a"]: expected dictionary, got cadence.Array`)
+
+		_, err = Query(vault, "owner.address.value")
+		require.EqualError(t, err, "cannot query .owner.address.value: expected composite, got cadence.Address")
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		t.Parallel()
+
+		for _, path := range []string{
+			".owner",
+			"owner.",
+			"owners[",
+			"owners[x]",
+			"owners[-1]",
+			`balances["a]`,
+		} {
+			_, err := Query(vault, path)
+			require.Error(t, err, path)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"math/big"
+	"reflect"
+	"unsafe"
+)
+
+// RetainedSize returns the approximate size in bytes of the Go heap memory
+// retained by the given value, e.g. to limit the size of a cache of exported values.
+//
+// The size includes the values themselves, and the strings, big integers,
+// and slices they contain, including those of nested values.
+// Types are not included, as they are usually shared between values.
+//
+// Unlike memory metering, which is performed while values are constructed,
+// the size is determined from an existing value.
+func RetainedSize(value Value) uint64 {
+	if value == nil {
+		return 0
+	}
+
+	size := uint64(reflect.TypeOf(value).Size())
+
+	switch v := value.(type) {
+	case Optional:
+		size += RetainedSize(v.Value)
+
+	case String:
+		size += uint64(len(v))
+
+	case Character:
+		size += uint64(len(v))
+
+	case Bytes:
+		size += uint64(cap(v))
+
+	case Int:
+		size += bigIntRetainedSize(v.Value)
+	case Int128:
+		size += bigIntRetainedSize(v.Value)
+	case Int256:
+		size += bigIntRetainedSize(v.Value)
+	case UInt:
+		size += bigIntRetainedSize(v.Value)
+	case UInt128:
+		size += bigIntRetainedSize(v.Value)
+	case UInt256:
+		size += bigIntRetainedSize(v.Value)
+
+	case Array:
+		size += valuesRetainedSize(v.Values)
+
+	case Dictionary:
+		size += uint64(cap(v.Pairs)) * uint64(unsafe.Sizeof(KeyValuePair{}))
+		for _, pair := range v.Pairs {
+			size += RetainedSize(pair.Key)
+			size += RetainedSize(pair.Value)
+		}
+
+	case Struct:
+		size += valuesRetainedSize(v.Fields)
+	case Resource:
+		size += valuesRetainedSize(v.Fields)
+		if v.Owner != nil {
+			size += uint64(unsafe.Sizeof(*v.Owner))
+		}
+	case Event:
+		size += valuesRetainedSize(v.Fields)
+	case Contract:
+		size += valuesRetainedSize(v.Fields)
+	case Enum:
+		size += valuesRetainedSize(v.Fields)
+
+	case Path:
+		size += pathRetainedSize(v)
+
+	case Link:
+		size += pathRetainedSize(v.TargetPath)
+		size += uint64(len(v.BorrowType))
+
+	case Capability:
+		size += pathRetainedSize(v.Path)
+	}
+
+	return size
+}
+
+// valuesRetainedSize returns the retained size of the given slice of values,
+// i.e. of the slice's backing array and of the values.
+func valuesRetainedSize(values []Value) uint64 {
+	var interfaceValue Value
+	size := uint64(cap(values)) * uint64(unsafe.Sizeof(interfaceValue))
+	for _, value := range values {
+		size += RetainedSize(value)
+	}
+	return size
+}
+
+func bigIntRetainedSize(i *big.Int) uint64 {
+	if i == nil {
+		return 0
+	}
+	var word big.Word
+	return uint64(unsafe.Sizeof(*i)) +
+		uint64(cap(i.Bits()))*uint64(unsafe.Sizeof(word))
+}
+
+func pathRetainedSize(path Path) uint64 {
+	return uint64(len(path.Domain) + len(path.Identifier))
+}
@@ -0,0 +1,82 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetainedSize(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, uint64(0), RetainedSize(nil))
+	})
+
+	t.Run("struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		empty := NewStruct(nil)
+		small := NewStruct([]Value{String("a")})
+		large := NewStruct([]Value{String(strings.Repeat("a", 1000))})
+
+		assert.Greater(t, RetainedSize(small), RetainedSize(empty))
+		assert.Greater(t, RetainedSize(large), RetainedSize(small))
+		assert.GreaterOrEqual(t, RetainedSize(large)-RetainedSize(small), uint64(999))
+	})
+
+	t.Run("big integer", func(t *testing.T) {
+
+		t.Parallel()
+
+		small := NewInt(1)
+		large := NewIntFromBig(new(big.Int).Lsh(big.NewInt(1), 1000))
+
+		assert.Greater(t, RetainedSize(large), RetainedSize(small))
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		element := NewOptional(String("foo"))
+
+		array := NewArray([]Value{element, element})
+
+		dictionary := NewDictionary([]KeyValuePair{
+			{
+				Key:   String("a"),
+				Value: array,
+			},
+		})
+
+		assert.Greater(t, RetainedSize(element), RetainedSize(NewOptional(nil)))
+		assert.Greater(t, RetainedSize(array), 2*RetainedSize(element))
+		assert.Greater(t, RetainedSize(dictionary), RetainedSize(array))
+	})
+}
@@ -79,6 +79,11 @@ func RunREPL() {
 		code += line + "\n"
 
 		inputIsComplete := repl.Accept(code)
+
+		for _, log := range repl.Logs() {
+			fmt.Println(log)
+		}
+
 		if !inputIsComplete {
 			lineIsContinuation = true
 			return
@@ -197,6 +197,7 @@ var (
 	CadencePathValueMemoryUsage         = NewConstantMemoryUsage(MemoryKindCadencePathValue)
 	CadenceVoidValueMemoryUsage         = NewConstantMemoryUsage(MemoryKindCadenceVoidValue)
 	CadenceTypeValueMemoryUsage         = NewConstantMemoryUsage(MemoryKindCadenceTypeValue)
+	CadenceExportedNodeMemoryUsage      = NewConstantMemoryUsage(MemoryKindCadenceExportedNode)
 
 	// Cadence external types
 
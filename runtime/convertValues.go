@@ -19,9 +19,13 @@
 package runtime
 
 import (
+	goErrors "errors"
 	"math/big"
 
+	"github.com/onflow/atree"
+
 	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/errors"
 	"github.com/onflow/cadence/runtime/interpreter"
@@ -29,6 +33,24 @@ import (
 	"github.com/onflow/cadence/runtime/stdlib"
 )
 
+// bigIntMemoryUsage returns the memory usage of a big integer with the given byte length,
+// which is copied when an Int or UInt value is imported or exported,
+// see SetBigIntMemoryUsage.
+var bigIntMemoryUsage = common.NewBigIntMemoryUsage
+
+// SetBigIntMemoryUsage sets the function which returns the memory usage
+// of the big integers copied when Int and UInt values are imported or exported,
+// given their byte length, e.g. to adapt the cost to the host's schedule.
+// A nil function restores the default, common.NewBigIntMemoryUsage.
+//
+// NOTE: the function is global, so it should be set before any values are converted.
+func SetBigIntMemoryUsage(memoryUsage func(byteLen int) common.MemoryUsage) {
+	if memoryUsage == nil {
+		memoryUsage = common.NewBigIntMemoryUsage
+	}
+	bigIntMemoryUsage = memoryUsage
+}
+
 // exportValue converts a runtime value to its native Go representation.
 func exportValue(
 	value exportableValue,
@@ -46,30 +68,1292 @@ func exportValue(
 }
 
 // ExportValue converts a runtime value to its native Go representation.
+//
+// The value is only read, i.e. resources are neither moved nor destroyed,
+// and can still be used after they were exported.
 func ExportValue(
 	value interpreter.Value,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
 ) (cadence.Value, error) {
-	return exportValueWithInterpreter(
+	return NewExporter().ExportValue(
+		value,
+		inter,
+		getLocationRange,
+	)
+}
+
+// ExportValueProjection converts a runtime value to its native Go representation,
+// like ExportValue, but only exports the given fields of the value, if it is a composite,
+// see Exporter.ExportValueProjection.
+func ExportValueProjection(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	fields []string,
+) (cadence.Value, error) {
+	return NewExporter().ExportValueProjection(
+		value,
+		inter,
+		getLocationRange,
+		fields,
+	)
+}
+
+// ExportArrayPage converts a page of the elements of a runtime array to their native Go representation,
+// see Exporter.ExportArrayPage.
+func ExportArrayPage(
+	v *interpreter.ArrayValue,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	offset int,
+	limit int,
+) (cadence.Array, bool, error) {
+	return NewExporter().ExportArrayPage(
+		v,
+		inter,
+		getLocationRange,
+		offset,
+		limit,
+	)
+}
+
+// ExportArrayValueIter returns an iterator which converts the elements of a runtime array
+// to their native Go representation one at a time, see Exporter.ExportArrayValueIter.
+func ExportArrayValueIter(
+	v *interpreter.ArrayValue,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+) (func() (cadence.Value, error), error) {
+	return NewExporter().ExportArrayValueIter(v, inter, getLocationRange)
+}
+
+// ExportValueWithTypes converts a runtime value to its native Go representation,
+// and also returns the dynamic type and the static type of the value.
+//
+// The dynamic type is the type of the exported value,
+// e.g. the type of the referenced value, if the value is a reference.
+// The static type is the type of the runtime value itself,
+// e.g. the reference type, if the value is a reference.
+func ExportValueWithTypes(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+) (
+	exportedValue cadence.Value,
+	dynamicType cadence.Type,
+	staticType cadence.Type,
+	err error,
+) {
+	exportedValue, err = ExportValue(value, inter, getLocationRange)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if exportedValue != nil {
+		dynamicType = exportedValue.Type()
+	}
+
+	semaType, err := inter.ConvertStaticToSemaType(value.StaticType(inter))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	staticType = ExportMeteredType(inter, semaType, map[sema.TypeID]cadence.Type{})
+
+	return exportedValue, dynamicType, staticType, nil
+}
+
+// ConversionTypeResolver returns the elaboration which declares the types of the given location,
+// or nil if the location is unknown, see NewConversionInterpreter.
+type ConversionTypeResolver func(location common.Location) *sema.Elaboration
+
+// NewConversionInterpreter returns a new interpreter which is only suitable
+// for converting values, i.e. for importing and exporting values, see Importer and Exporter,
+// and not for executing programs.
+//
+// The interpreter has no program. The values it constructs are stored in a new in-memory storage.
+// The types of user-defined composites are resolved using the given type resolvers, in order.
+// Converting a composite of a type that is not resolved fails.
+func NewConversionInterpreter(
+	gauge common.MemoryGauge,
+	typeResolvers ...ConversionTypeResolver,
+) (*interpreter.Interpreter, error) {
+
+	var uuid uint64
+
+	return interpreter.NewInterpreter(
+		nil,
+		nil,
+		interpreter.WithStorage(interpreter.NewInMemoryStorage(gauge)),
+		interpreter.WithMemoryGauge(gauge),
+		interpreter.WithUUIDHandler(func() (uint64, error) {
+			defer func() { uuid++ }()
+			return uuid, nil
+		}),
+		interpreter.WithImportLocationHandler(
+			func(_ *interpreter.Interpreter, location common.Location) interpreter.Import {
+				for _, resolveType := range typeResolvers {
+					elaboration := resolveType(location)
+					if elaboration != nil {
+						return interpreter.VirtualImport{
+							Elaboration: elaboration,
+						}
+					}
+				}
+
+				// NOTE: the location is loaded without an elaboration,
+				// so the types of the location fail to load
+
+				return interpreter.VirtualImport{}
+			},
+		),
+	)
+}
+
+// An Exporter converts runtime values to their native Go representation.
+//
+// The behaviour of the export can be configured using export options,
+// see NewExporter.
+type Exporter struct {
+	maxCollectionSize  int
+	typeCachingEnabled bool
+	typeCache          map[sema.TypeID]cadence.Type
+	// semaTypeCache contains the sema types of the static types of exported arrays and dictionaries,
+	// keyed by the string representation of the static type.
+	// semaTypeConversions counts the conversions that were not served by the cache
+	semaTypeCache       map[string]sema.Type
+	semaTypeConversions int
+	// compositeCycleDetectionEnabled determines if cycles through composites are detected.
+	// visitedComposites contains the storage IDs of the composites which are currently being exported
+	compositeCycleDetectionEnabled bool
+	visitedComposites              map[atree.StorageID]struct{}
+	// visitedResources contains the storage IDs of the resources which are currently being exported
+	visitedResources map[atree.StorageID]struct{}
+	// collectAllErrorsEnabled determines if the export continues after an error.
+	// collectedErrors contains the errors which occurred during the current export, in traversal order
+	collectAllErrorsEnabled bool
+	collectedErrors         []error
+	// fieldNameMapper maps the names of the fields of exported composite types.
+	// fieldNameMappedTypes contains the mapped copies of the exported composite types
+	fieldNameMapper      func(string) string
+	fieldNameMappedTypes map[cadence.CompositeType]cadence.CompositeType
+	includeOwner         bool
+	includeStorageID     bool
+	// onlyPublicFields determines if only the public fields of composites are exported.
+	// docCommentsEnabled determines if the fields of exported composite types have doc comments.
+	// publicFieldTypes contains the copies of the exported composite types
+	// with only public fields and/or doc comments
+	onlyPublicFields   bool
+	docCommentsEnabled bool
+	publicFieldTypes   map[cadence.CompositeType]cadence.CompositeType
+	// enumsAsRawValue determines if enums are exported as their raw values.
+	// rawValueEnumTypes contains the copies of the exported composite types with enum types replaced
+	enumsAsRawValue   bool
+	rawValueEnumTypes map[cadence.CompositeType]cadence.CompositeType
+	// typeSubstitutions contains the types which are used in place of the stored types
+	// of exported composites, keyed by the ID of the stored type.
+	// renamedFields maps the field names of the substitute types
+	// to the field names of the stored values, keyed by the ID of the stored type
+	typeSubstitutions map[sema.TypeID]*sema.CompositeType
+	renamedFields     map[sema.TypeID]map[string]string
+	// maxNodes is the maximum number of values an export may visit.
+	// nodeCount is the number of values the current export visited
+	maxNodes  int
+	nodeCount int
+	// maxDepth is the maximum nesting depth of exported values.
+	// depth is the nesting depth of the value which is currently being exported
+	maxDepth int
+	depth    int
+	// valueInterningEnabled determines if exported composites are reused.
+	// internedValues contains the exported non-resource composites of the current export
+	valueInterningEnabled bool
+	internedValues        map[*interpreter.CompositeValue]cadence.Value
+	// projectedFields contains the names of the fields of the top-level composite
+	// which are exported by the current export, if it is a projection, see Exporter.ExportValueProjection
+	projectedFields []string
+	// arrayPage is the page of the top-level array which is exported by the current export,
+	// if it is paginated, see Exporter.ExportArrayPage
+	arrayPage *arrayPage
+	// redactedTypes contains the IDs of the types of the values which are redacted,
+	// see WithRedactType
+	redactedTypes map[sema.TypeID]struct{}
+	// onMemoryLimit determines the action when the memory limit is exceeded during an export.
+	// truncated determines if the last export was truncated,
+	// and aborted determines if the last export was aborted
+	onMemoryLimit func() MemoryLimitAction
+	truncated     bool
+	aborted       bool
+}
+
+// ExportOption configures an Exporter.
+type ExportOption func(*Exporter)
+
+// WithMaxCollectionSize returns an export option that limits the number of elements
+// an exported array or dictionary may have.
+//
+// Exporting a collection with more elements fails with a user error.
+// A size of zero disables the limit.
+func WithMaxCollectionSize(size int) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.maxCollectionSize = size
+	}
+}
+
+// WithTypeCaching returns an export option that enables or disables
+// the caching of exported types across exports performed by the exporter.
+//
+// Cached types are not re-derived (and not metered again) when they are exported again.
+// The sema types of the static types of exported arrays and dictionaries are cached as well,
+// so they are not recomputed for each exported value that has the same static type.
+// Long-lived exporters must invalidate cached types when the underlying types change,
+// e.g. after a contract update, see Exporter.InvalidateType and Exporter.ClearCache.
+func WithTypeCaching(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.typeCachingEnabled = enabled
+	}
+}
+
+// WithCompositeCycleDetection returns an export option that enables or disables
+// the detection of cycles through composite values.
+//
+// By default, only cycles through ephemeral references are detected.
+// When enabled, composites are tracked by storage ID,
+// so cycles through other values, e.g. storage references, are detected too.
+// A composite that is encountered again while it is being exported
+// is exported as a placeholder (nil), like a cyclic ephemeral reference.
+func WithCompositeCycleDetection(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.compositeCycleDetectionEnabled = enabled
+	}
+}
+
+// WithCollectAllErrors returns an export option that enables or disables
+// the collection of all errors that occur during an export.
+//
+// By default, the export stops at the first error.
+// When enabled, the export continues with the remaining elements, fields, keys and values,
+// and fails with an *ExportErrors error containing all errors that occurred.
+//
+// The errors are in a deterministic order: the order in which the values are traversed,
+// i.e. depth-first, and left-to-right (e.g. array elements by index,
+// composite fields in declaration order, and dictionary keys before their values).
+func WithCollectAllErrors(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.collectAllErrorsEnabled = enabled
+	}
+}
+
+// WithFieldNameMapper returns an export option that maps the names of the fields
+// of exported composites, e.g. to convert them to snake case.
+//
+// The field names are mapped in the exported composite types,
+// so the mapped names are consistent for the values and all types of the export,
+// e.g. also for the composite element type of an exported array.
+func WithFieldNameMapper(mapper func(string) string) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.fieldNameMapper = mapper
+	}
+}
+
+// WithIncludeOwner returns an export option that enables or disables
+// the inclusion of the owner of exported resources, see cadence.Resource.Owner.
+//
+// Resources which are not stored in an account, e.g. resources which were just created,
+// have no owner.
+func WithIncludeOwner(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.includeOwner = enabled
+	}
+}
+
+// WithIncludeStorageID returns an export option that enables or disables
+// the inclusion of the storage ID of exported stored composites,
+// e.g. cadence.Struct.StorageID and cadence.Resource.StorageID.
+//
+// Composites which are not stored in an account, e.g. composites which were just created,
+// have no storage ID.
+func WithIncludeStorageID(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.includeStorageID = enabled
+	}
+}
+
+// WithOnlyPublicFields returns an export option that enables or disables
+// the exclusion of non-public fields, e.g. `priv` or `access(contract)` fields,
+// from exported composites, i.e. from both the exported types and values.
+func WithOnlyPublicFields(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.onlyPublicFields = enabled
+	}
+}
+
+// WithDocComments returns an export option that enables or disables
+// the doc comments of the fields of exported composite types,
+// e.g. for tooling which generates API documentation.
+//
+// When enabled, the DocString of each field of an exported composite type
+// is the doc comment of the field member in the program, see sema.Member.DocString.
+func WithDocComments(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.docCommentsEnabled = enabled
+	}
+}
+
+// WithEnumsAsRawValue returns an export option that enables or disables
+// the export of enums as their raw values, e.g. a cadence.UInt8,
+// instead of as a cadence.Enum with a raw value field.
+//
+// Enum types contained in exported types, e.g. the element type of an array of enums,
+// are replaced by their raw types accordingly.
+func WithEnumsAsRawValue(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.enumsAsRawValue = enabled
+	}
+}
+
+// WithTypeSubstitutions returns an export option that exports composites
+// using substitute types in place of their stored types,
+// e.g. to export values stored before a contract upgrade using the upgraded types.
+//
+// The substitute types are keyed by the ID of the stored type.
+// The fields of the substitute type are read from the stored value by name.
+// Fields which were renamed can be mapped using renamedFields,
+// which maps the field names of the substitute type to the field names of the stored value,
+// keyed by the ID of the stored type.
+//
+// Only the types of exported composite values are substituted,
+// not composite types contained in other exported types, e.g. array element types.
+func WithTypeSubstitutions(
+	substitutions map[sema.TypeID]*sema.CompositeType,
+	renamedFields map[sema.TypeID]map[string]string,
+) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.typeSubstitutions = substitutions
+		exporter.renamedFields = renamedFields
+	}
+}
+
+// WithMaxNodes returns an export option that limits the total number of values
+// an export may visit, i.e. the exported value and all values contained in it,
+// e.g. array elements, dictionary keys and values, and composite fields.
+//
+// Each visited value is metered as a MemoryKindCadenceExportedNode,
+// so the export is also bounded by the memory limit of the interpreter.
+// An export which exceeds the limit fails with an *ExportNodeLimitExceededError,
+// even if all errors are collected, see WithCollectAllErrors.
+// A limit of zero disables the limit and the metering of nodes.
+func WithMaxNodes(maxNodes int) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.maxNodes = maxNodes
+	}
+}
+
+// DefaultMaxExportDepth is the default maximum nesting depth of exported values,
+// see WithMaxExportDepth.
+const DefaultMaxExportDepth = 1024
+
+// WithMaxExportDepth returns an export option that limits the nesting depth of exported values,
+// i.e. the number of nested optionals, arrays, dictionaries, and composites,
+// so deeply nested values fail to export instead of overflowing the stack.
+//
+// An export which exceeds the limit fails with a user error.
+// The default limit is DefaultMaxExportDepth. A limit of zero disables the limit.
+func WithMaxExportDepth(depth int) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.maxDepth = depth
+	}
+}
+
+// WithValueInterning returns an export option that enables or disables
+// the reuse of exported composites.
+//
+// When enabled, a non-resource composite which is encountered multiple times in an export,
+// e.g. a struct which is shared by multiple fields, is only exported once,
+// and the exported value is reused for all further occurrences.
+// Composites are only reused within an export, not across exports,
+// as they may be mutated in between.
+// Composites with errors are not reused, see WithCollectAllErrors.
+func WithValueInterning(enabled bool) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.valueInterningEnabled = enabled
+	}
+}
+
+// WithRedactType returns an export option that redacts all values of the given type,
+// e.g. for compliance reasons.
+//
+// Any value in the exported tree whose type has the given ID, e.g. `A.0000000000000001.C.S`,
+// including the exported value itself, is exported as nil, i.e. `cadence.Optional{}`,
+// and the values contained in it are not exported.
+// The exported types, e.g. the field types of composites, are not changed.
+// The option may be given multiple times to redact values of multiple types.
+func WithRedactType(typeID string) ExportOption {
+	return func(exporter *Exporter) {
+		if exporter.redactedTypes == nil {
+			exporter.redactedTypes = map[sema.TypeID]struct{}{}
+		}
+		exporter.redactedTypes[sema.TypeID(typeID)] = struct{}{}
+	}
+}
+
+// MemoryLimitAction is the action an exporter takes when the memory limit is exceeded
+// during an export, see WithOnMemoryLimit.
+type MemoryLimitAction uint8
+
+const (
+	// MemoryLimitActionAbort aborts the export, i.e. the memory error is propagated
+	MemoryLimitActionAbort MemoryLimitAction = iota
+	// MemoryLimitActionTruncateHere exports the value which exceeded the memory limit as a placeholder (nil),
+	// and continues the export
+	MemoryLimitActionTruncateHere
+)
+
+// WithOnMemoryLimit returns an export option that sets the handler which is called
+// when the memory limit is exceeded while a value is exported,
+// i.e. when the memory gauge of the interpreter fails.
+//
+// By default, and if the handler returns MemoryLimitActionAbort, the whole export fails.
+// If the handler returns MemoryLimitActionTruncateHere, the value that was being exported is
+// exported as a placeholder (nil), and the export returns the partial value.
+// The handler is called again for each further value which exceeds the limit.
+// Exporter.Truncated reports if the last export was truncated.
+func WithOnMemoryLimit(handler func() MemoryLimitAction) ExportOption {
+	return func(exporter *Exporter) {
+		exporter.onMemoryLimit = handler
+	}
+}
+
+// NewExporter returns a new exporter, configured with the given options.
+func NewExporter(options ...ExportOption) *Exporter {
+	exporter := &Exporter{
+		maxDepth: DefaultMaxExportDepth,
+	}
+	for _, option := range options {
+		option(exporter)
+	}
+	return exporter
+}
+
+// ExportValue converts a runtime value to its native Go representation.
+func (e *Exporter) ExportValue(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+) (exported cadence.Value, err error) {
+	e.truncated = false
+	e.aborted = false
+	e.nodeCount = 0
+	e.depth = 0
+	e.internedValues = nil
+	defer e.handleMemoryLimit(&exported, &err)
+
+	if !e.collectAllErrorsEnabled {
+		return e.exportValueWithInterpreter(
+			value,
+			inter,
+			getLocationRange,
+			seenReferences{},
+		)
+	}
+
+	e.collectedErrors = nil
+	defer func() {
+		e.collectedErrors = nil
+	}()
+
+	exported, err = e.exportValueWithInterpreter(
 		value,
 		inter,
 		getLocationRange,
 		seenReferences{},
 	)
+	if err != nil {
+		e.collectedErrors = append(e.collectedErrors, err)
+	}
+
+	if len(e.collectedErrors) > 0 {
+		return nil, &ExportErrors{
+			Errors: e.collectedErrors,
+		}
+	}
+
+	return exported, nil
+}
+
+// ExportValueProjection converts a runtime value to its native Go representation,
+// like Exporter.ExportValue, but only exports the given fields of the value, if it is a composite.
+//
+// The exported composite only has the given fields, in declaration order,
+// and the fields are exported with all their nested values.
+// Exporting a field which the composite does not have, e.g. a non-public field
+// when only public fields are exported, fails with a user error.
+// All other values are exported whole.
+func (e *Exporter) ExportValueProjection(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	fields []string,
+) (cadence.Value, error) {
+	if fields == nil {
+		fields = []string{}
+	}
+
+	e.projectedFields = fields
+	defer func() {
+		e.projectedFields = nil
+	}()
+
+	return e.ExportValue(value, inter, getLocationRange)
+}
+
+// arrayPage is a page of the elements of an array, see Exporter.ExportArrayPage.
+type arrayPage struct {
+	offset int
+	limit  int
+}
+
+// ExportArrayPage converts a page of the elements of a runtime array to their native Go representation,
+// like Exporter.ExportValue, e.g. for APIs which paginate large array results.
+//
+// Only the elements in the range [offset, offset+limit) are exported,
+// the elements before and after the page are not visited.
+// The page is exported as a variable-sized array, even if the given array is constant-sized.
+// The returned boolean is true if the array has elements after the page.
+// If the array is redacted, see WithRedactType, or the export is truncated, see WithOnMemoryLimit,
+// the returned array is empty.
+func (e *Exporter) ExportArrayPage(
+	v *interpreter.ArrayValue,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	offset int,
+	limit int,
+) (cadence.Array, bool, error) {
+	if offset < 0 || limit <= 0 {
+		return cadence.Array{}, false, errors.NewDefaultUserError(
+			"cannot export array page: invalid offset %d and limit %d",
+			offset,
+			limit,
+		)
+	}
+
+	e.arrayPage = &arrayPage{
+		offset: offset,
+		limit:  limit,
+	}
+	defer func() {
+		e.arrayPage = nil
+	}()
+
+	exported, err := e.ExportValue(v, inter, getLocationRange)
+	if err != nil {
+		return cadence.Array{}, false, err
+	}
+
+	more := v.Count()-offset > limit
+
+	array, _ := exported.(cadence.Array)
+	return array, more, nil
+}
+
+// ExportArrayValueIter returns an iterator which converts the elements of a runtime array
+// to their native Go representation one at a time, like Exporter.ExportValue,
+// e.g. to stream very large arrays without materializing all exported elements at once.
+//
+// Each call of the iterator exports the next element, in index order.
+// If the export of an element fails, the call returns the error,
+// wrapped in an *ExportArrayElementError, and all further calls return the same error.
+// Once all elements are exported, the iterator returns nil, nil.
+//
+// Like for Exporter.ExportValue, an element which exceeds the memory limit
+// is exported as a placeholder (nil) if the memory limit handler decides to truncate the export,
+// see WithOnMemoryLimit, so the number of elements of the array determines the end of the iteration.
+// Exporter.Truncated reports if any element of the iteration was truncated.
+//
+// The iterator only yields the exported elements. The type of the array is not exported,
+// and must be attached separately, e.g. using ExportType with the sema type of the array.
+// The array must not be modified while it is iterated,
+// and the exporter must not be used for other exports until the iteration finishes.
+func (e *Exporter) ExportArrayValueIter(
+	v *interpreter.ArrayValue,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+) (func() (cadence.Value, error), error) {
+	e.truncated = false
+	e.aborted = false
+	e.nodeCount = 0
+	e.internedValues = nil
+	e.collectedErrors = nil
+
+	// The array itself is visited once, its elements are visited by the iterator
+
+	err := e.visitNode(inter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.checkCollectionSize(v.Count())
+	if err != nil {
+		return nil, err
+	}
+
+	index := 0
+	var iterationErr error
+
+	next := func() (exported cadence.Value, err error) {
+		if iterationErr != nil {
+			return nil, iterationErr
+		}
+
+		if index >= v.Count() {
+			return nil, nil
+		}
+
+		defer func() {
+			if err != nil {
+				err = &ExportArrayElementError{
+					Index: index,
+					Err:   err,
+				}
+				iterationErr = err
+			}
+			index++
+		}()
+
+		// Handle the memory limit like ExportValue, also when the element is retrieved.
+		// NOTE: deferred after the wrapping of the error, so it is handled first
+		defer e.handleMemoryLimit(&exported, &err)
+
+		// The elements are nested in the array
+
+		e.depth = 0
+		err = e.enterNestedValue()
+		if err != nil {
+			return nil, err
+		}
+		defer e.leaveNestedValue()
+
+		exported, err = e.exportElementValue(
+			v.Get(inter, getLocationRange, index),
+			inter,
+			getLocationRange,
+			seenReferences{},
+		)
+		if err == nil && len(e.collectedErrors) > 0 {
+			err = &ExportErrors{
+				Errors: e.collectedErrors,
+			}
+			e.collectedErrors = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return exported, nil
+	}
+
+	return next, nil
+}
+
+// Truncated returns true if the last export was truncated,
+// because the memory limit was exceeded, see WithOnMemoryLimit.
+func (e *Exporter) Truncated() bool {
+	return e.truncated
+}
+
+// handleMemoryLimit must be deferred.
+// It recovers from a memory error, if the memory limit handler decides to truncate the export,
+// and replaces the exported value with a placeholder (nil).
+func (e *Exporter) handleMemoryLimit(exported *cadence.Value, err *error) {
+	if e.onMemoryLimit == nil {
+		return
+	}
+
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	// NOTE: once aborted, the memory error is propagated
+	// through the exports of all containing values, without calling the handler again
+
+	if _, ok := r.(errors.MemoryError); !ok || e.aborted {
+		panic(r)
+	}
+
+	if e.onMemoryLimit() != MemoryLimitActionTruncateHere {
+		e.aborted = true
+		panic(r)
+	}
+
+	e.truncated = true
+	*exported = nil
+	*err = nil
+}
+
+// InvalidateType removes the type with the given type ID from the type cache,
+// so it is re-derived when it is exported again.
+//
+// Cached types which refer to the invalidated type, e.g. array types,
+// are not invalidated. Use ClearCache to invalidate all cached types.
+//
+// As cached sema types of static types may refer to the invalidated type,
+// all of them are invalidated.
+func (e *Exporter) InvalidateType(typeID sema.TypeID) {
+	delete(e.typeCache, typeID)
+	e.semaTypeCache = nil
+	e.fieldNameMappedTypes = nil
+	e.publicFieldTypes = nil
+	e.rawValueEnumTypes = nil
+}
+
+// ClearCache removes all types from the type cache.
+func (e *Exporter) ClearCache() {
+	e.typeCache = nil
+	e.semaTypeCache = nil
+	e.fieldNameMappedTypes = nil
+	e.publicFieldTypes = nil
+	e.rawValueEnumTypes = nil
+}
+
+// typeResults returns the results map used for exporting types.
+// If type caching is enabled, the map is the exporter's type cache,
+// otherwise it is a new map.
+func (e *Exporter) typeResults() map[sema.TypeID]cadence.Type {
+	if !e.typeCachingEnabled {
+		return map[sema.TypeID]cadence.Type{}
+	}
+
+	if e.typeCache == nil {
+		e.typeCache = map[sema.TypeID]cadence.Type{}
+	}
+	return e.typeCache
+}
+
+// semaType returns the sema type for the given static type, determined using the given function.
+// If type caching is enabled, the result is cached, keyed by the static type.
+func (e *Exporter) semaType(staticType interpreter.StaticType, convert func() sema.Type) sema.Type {
+	if !e.typeCachingEnabled {
+		e.semaTypeConversions++
+		return convert()
+	}
+
+	key := staticType.String()
+
+	semaType, ok := e.semaTypeCache[key]
+	if ok {
+		return semaType
+	}
+
+	e.semaTypeConversions++
+	semaType = convert()
+
+	if e.semaTypeCache == nil {
+		e.semaTypeCache = map[string]sema.Type{}
+	}
+	e.semaTypeCache[key] = semaType
+
+	return semaType
+}
+
+// mapFieldNames returns the given exported type with the field names of all contained composite types
+// mapped using the field name mapper, if any.
+//
+// The given type is not modified, mapped composite types are copies.
+// The copies are reused, so types which are shared, e.g. through the type cache, stay shared.
+func (e *Exporter) mapFieldNames(t cadence.Type) cadence.Type {
+	if e.fieldNameMapper == nil {
+		return t
+	}
+
+	if !e.typeCachingEnabled {
+		// Without type caching, exported types are not reused across exports,
+		// so there is no need to keep their copies
+		defer func() {
+			e.fieldNameMappedTypes = nil
+		}()
+	}
+
+	return e.mapTypeFieldNames(t)
+}
+
+func (e *Exporter) mapTypeFieldNames(t cadence.Type) cadence.Type {
+	if t, ok := t.(cadence.CompositeType); ok {
+		return e.mapCompositeTypeFieldNames(t)
+	}
+
+	return mapContainedTypes(t, e.mapTypeFieldNames)
+}
+
+// mapContainedTypes returns the given exported type with the types it contains,
+// e.g. the element type of an array type, mapped using the given function.
+// Composite types and all other types are returned as is.
+func mapContainedTypes(t cadence.Type, f func(cadence.Type) cadence.Type) cadence.Type {
+	switch t := t.(type) {
+	case cadence.OptionalType:
+		return cadence.OptionalType{
+			Type: f(t.Type),
+		}
+
+	case cadence.VariableSizedArrayType:
+		return cadence.VariableSizedArrayType{
+			ElementType: f(t.ElementType),
+		}
+
+	case cadence.ConstantSizedArrayType:
+		return cadence.ConstantSizedArrayType{
+			ElementType: f(t.ElementType),
+			Size:        t.Size,
+		}
+
+	case cadence.DictionaryType:
+		return cadence.DictionaryType{
+			KeyType:     f(t.KeyType),
+			ElementType: f(t.ElementType),
+		}
+
+	case cadence.ReferenceType:
+		return cadence.ReferenceType{
+			Authorized: t.Authorized,
+			Type:       f(t.Type),
+		}
+
+	case cadence.CapabilityType:
+		return cadence.CapabilityType{
+			BorrowType: f(t.BorrowType),
+		}
+
+	default:
+		return t
+	}
+}
+
+// mapEnumTypes returns the given exported type with all contained enum types
+// replaced by their raw types, if enums are exported as their raw values.
+//
+// The given type is not modified, mapped composite types are copies.
+// The copies are reused, so types which are shared, e.g. through the type cache, stay shared.
+func (e *Exporter) mapEnumTypes(t cadence.Type) cadence.Type {
+	if !e.enumsAsRawValue {
+		return t
+	}
+
+	if !e.typeCachingEnabled {
+		// Without type caching, exported types are not reused across exports,
+		// so there is no need to keep their copies
+		defer func() {
+			e.rawValueEnumTypes = nil
+		}()
+	}
+
+	return e.mapTypeEnums(t)
+}
+
+func (e *Exporter) mapTypeEnums(t cadence.Type) cadence.Type {
+	switch t := t.(type) {
+	case *cadence.EnumType:
+		return t.RawType
+
+	case cadence.CompositeType:
+		return e.mapCompositeTypeEnums(t)
+
+	default:
+		return mapContainedTypes(t, e.mapTypeEnums)
+	}
+}
+
+func (e *Exporter) mapCompositeTypeEnums(t cadence.CompositeType) cadence.CompositeType {
+	if mapped, ok := e.rawValueEnumTypes[t]; ok {
+		return mapped
+	}
+
+	mapped := copyCompositeType(t)
+
+	// NOTE: record the copy before mapping the field types,
+	// as composite types may be recursive
+
+	if e.rawValueEnumTypes == nil {
+		e.rawValueEnumTypes = map[cadence.CompositeType]cadence.CompositeType{}
+	}
+	e.rawValueEnumTypes[t] = mapped
+
+	fields := t.CompositeFields()
+	mappedFields := make([]cadence.Field, len(fields))
+	for i, field := range fields {
+		field.Type = e.mapTypeEnums(field.Type)
+		mappedFields[i] = field
+	}
+	mapped.SetCompositeFields(mappedFields)
+
+	return mapped
+}
+
+func (e *Exporter) mapCompositeTypeFieldNames(t cadence.CompositeType) cadence.CompositeType {
+	if mapped, ok := e.fieldNameMappedTypes[t]; ok {
+		return mapped
+	}
+
+	mapped := copyCompositeType(t)
+
+	// NOTE: record the copy before mapping the field types,
+	// as composite types may be recursive
+
+	if e.fieldNameMappedTypes == nil {
+		e.fieldNameMappedTypes = map[cadence.CompositeType]cadence.CompositeType{}
+	}
+	e.fieldNameMappedTypes[t] = mapped
+
+	fields := t.CompositeFields()
+	mappedFields := make([]cadence.Field, len(fields))
+	for i, field := range fields {
+		field.Identifier = e.fieldNameMapper(field.Identifier)
+		field.Type = e.mapTypeFieldNames(field.Type)
+		mappedFields[i] = field
+	}
+	mapped.SetCompositeFields(mappedFields)
+
+	return mapped
+}
+
+// filterPublicFields returns the given exported type with all non-public fields
+// of all contained composite types removed, if only public fields are exported,
+// and with the doc comments of the fields added, if doc comments are enabled.
+// The given sema type is the type from which the given type was exported.
+//
+// The given type is not modified, filtered composite types are copies.
+// The copies are reused, so types which are shared, e.g. through the type cache, stay shared.
+func (e *Exporter) filterPublicFields(t cadence.Type, semaType sema.Type) cadence.Type {
+	if !e.onlyPublicFields && !e.docCommentsEnabled {
+		return t
+	}
+
+	if !e.typeCachingEnabled {
+		// Without type caching, exported types are not reused across exports,
+		// so there is no need to keep their copies
+		defer func() {
+			e.publicFieldTypes = nil
+		}()
+	}
+
+	return e.filterTypePublicFields(t, semaType)
+}
+
+func (e *Exporter) filterTypePublicFields(t cadence.Type, semaType sema.Type) cadence.Type {
+	switch t := t.(type) {
+	case cadence.CompositeType:
+		compositeType, ok := semaType.(*sema.CompositeType)
+		if !ok {
+			return t
+		}
+		return e.filterCompositeTypePublicFields(t, compositeType)
+
+	case cadence.OptionalType:
+		optionalType, ok := semaType.(*sema.OptionalType)
+		if !ok {
+			return t
+		}
+		return cadence.OptionalType{
+			Type: e.filterTypePublicFields(t.Type, optionalType.Type),
+		}
+
+	case cadence.VariableSizedArrayType:
+		arrayType, ok := semaType.(*sema.VariableSizedType)
+		if !ok {
+			return t
+		}
+		return cadence.VariableSizedArrayType{
+			ElementType: e.filterTypePublicFields(t.ElementType, arrayType.Type),
+		}
+
+	case cadence.ConstantSizedArrayType:
+		arrayType, ok := semaType.(*sema.ConstantSizedType)
+		if !ok {
+			return t
+		}
+		return cadence.ConstantSizedArrayType{
+			ElementType: e.filterTypePublicFields(t.ElementType, arrayType.Type),
+			Size:        t.Size,
+		}
+
+	case cadence.DictionaryType:
+		dictionaryType, ok := semaType.(*sema.DictionaryType)
+		if !ok {
+			return t
+		}
+		return cadence.DictionaryType{
+			KeyType:     e.filterTypePublicFields(t.KeyType, dictionaryType.KeyType),
+			ElementType: e.filterTypePublicFields(t.ElementType, dictionaryType.ValueType),
+		}
+
+	case cadence.ReferenceType:
+		referenceType, ok := semaType.(*sema.ReferenceType)
+		if !ok {
+			return t
+		}
+		return cadence.ReferenceType{
+			Authorized: t.Authorized,
+			Type:       e.filterTypePublicFields(t.Type, referenceType.Type),
+		}
+
+	case cadence.CapabilityType:
+		capabilityType, ok := semaType.(*sema.CapabilityType)
+		if !ok {
+			return t
+		}
+		return cadence.CapabilityType{
+			BorrowType: e.filterTypePublicFields(t.BorrowType, capabilityType.BorrowType),
+		}
+
+	default:
+		return t
+	}
+}
+
+func (e *Exporter) filterCompositeTypePublicFields(
+	t cadence.CompositeType,
+	semaType *sema.CompositeType,
+) cadence.CompositeType {
+	if filtered, ok := e.publicFieldTypes[t]; ok {
+		return filtered
+	}
+
+	filtered := copyCompositeType(t)
+
+	// NOTE: record the copy before filtering the field types,
+	// as composite types may be recursive
+
+	if e.publicFieldTypes == nil {
+		e.publicFieldTypes = map[cadence.CompositeType]cadence.CompositeType{}
+	}
+	e.publicFieldTypes[t] = filtered
+
+	fields := t.CompositeFields()
+	filteredFields := make([]cadence.Field, 0, len(fields))
+	for _, field := range fields {
+		member, ok := semaType.Members.Get(field.Identifier)
+		if !ok {
+			panic(errors.NewUnreachableError())
+		}
+
+		if e.onlyPublicFields &&
+			member.Access != ast.AccessPublic &&
+			member.Access != ast.AccessPublicSettable {
+
+			continue
+		}
+
+		field.Type = e.filterTypePublicFields(field.Type, member.TypeAnnotation.Type)
+		if e.docCommentsEnabled {
+			field.DocString = member.DocString
+		}
+
+		filteredFields = append(filteredFields, field)
+	}
+	filtered.SetCompositeFields(filteredFields)
+
+	return filtered
+}
+
+// projectCompositeType returns a copy of the given composite type
+// which only has the given fields, see Exporter.ExportValueProjection.
+func projectCompositeType(
+	t cadence.CompositeType,
+	projectedFields []string,
+) (cadence.CompositeType, error) {
+	fieldNames := make(map[string]struct{}, len(projectedFields))
+	for _, fieldName := range projectedFields {
+		fieldNames[fieldName] = struct{}{}
+	}
+
+	fields := t.CompositeFields()
+
+	projected := make([]cadence.Field, 0, len(projectedFields))
+	for _, field := range fields {
+		if _, ok := fieldNames[field.Identifier]; ok {
+			projected = append(projected, field)
+			delete(fieldNames, field.Identifier)
+		}
+	}
+
+	for _, fieldName := range projectedFields {
+		if _, ok := fieldNames[fieldName]; ok {
+			return nil, errors.NewDefaultUserError(
+				"cannot export field `%s` of `%s`: no such field",
+				fieldName,
+				t.ID(),
+			)
+		}
+	}
+
+	projectedType := copyCompositeType(t)
+	projectedType.SetCompositeFields(projected)
+
+	return projectedType, nil
+}
+
+// copyCompositeType returns a shallow copy of the given composite type.
+func copyCompositeType(t cadence.CompositeType) cadence.CompositeType {
+	switch t := t.(type) {
+	case *cadence.StructType:
+		copied := *t
+		return &copied
+	case *cadence.ResourceType:
+		copied := *t
+		return &copied
+	case *cadence.EventType:
+		copied := *t
+		return &copied
+	case *cadence.ContractType:
+		copied := *t
+		return &copied
+	case *cadence.EnumType:
+		copied := *t
+		return &copied
+	default:
+		panic(errors.NewUnreachableError())
+	}
 }
 
 // NOTE: Do not generalize to map[interpreter.Value],
 // as not all values are Go hashable, i.e. this might lead to run-time panics
 type seenReferences map[*interpreter.EphemeralReferenceValue]struct{}
 
+// exportValueWithInterpreter exports the given internal (interpreter) value to an external value,
+// using an exporter with the default configuration.
+func exportValueWithInterpreter(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	seenReferences seenReferences,
+) (
+	cadence.Value,
+	error,
+) {
+	return NewExporter().exportValueWithInterpreter(
+		value,
+		inter,
+		getLocationRange,
+		seenReferences,
+	)
+}
+
+// exportElementValue exports the given value, which is contained in another value,
+// e.g. an array element or a composite field.
+//
+// If all errors are collected, the error is recorded, and a nil placeholder is returned,
+// so the export of the containing value continues.
+// Likewise, a nil placeholder is returned if the memory limit is exceeded
+// and the memory limit handler decides to truncate the export.
+func (e *Exporter) exportElementValue(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	seenReferences seenReferences,
+) (
+	exported cadence.Value,
+	err error,
+) {
+	defer e.handleMemoryLimit(&exported, &err)
+
+	exported, err = e.exportValueWithInterpreter(
+		value,
+		inter,
+		getLocationRange,
+		seenReferences,
+	)
+	if err != nil && e.collectAllErrorsEnabled && !isExportNodeLimitExceededError(err) {
+		e.collectedErrors = append(e.collectedErrors, err)
+		return nil, nil
+	}
+	return exported, err
+}
+
+// visitNode counts a value visited by the current export,
+// and meters it, if the number of nodes is limited, see WithMaxNodes.
+func (e *Exporter) visitNode(inter *interpreter.Interpreter) error {
+	if e.maxNodes <= 0 {
+		return nil
+	}
+
+	common.UseMemory(inter, common.CadenceExportedNodeMemoryUsage)
+
+	e.nodeCount++
+	if e.nodeCount > e.maxNodes {
+		return &ExportNodeLimitExceededError{
+			MaxNodes: e.maxNodes,
+		}
+	}
+
+	return nil
+}
+
+// isRedacted returns true if the given value has one of the redacted types, see WithRedactType.
+func (e *Exporter) isRedacted(value interpreter.Value, inter *interpreter.Interpreter) bool {
+	if len(e.redactedTypes) == 0 {
+		return false
+	}
+
+	var typeID sema.TypeID
+
+	switch value := value.(type) {
+	case *interpreter.CompositeValue:
+		typeID = value.TypeID()
+
+	case interpreter.NilValue, interpreter.VoidValue:
+		return false
+
+	default:
+		staticType := value.StaticType(inter)
+		semaType := e.semaType(staticType, func() sema.Type {
+			return inter.MustConvertStaticToSemaType(staticType)
+		})
+		typeID = semaType.ID()
+	}
+
+	_, ok := e.redactedTypes[typeID]
+	return ok
+}
+
+// enterNestedValue increases the nesting depth of the current export,
+// if the depth is limited, see WithMaxExportDepth.
+// If no error is returned, leaveNestedValue must be called once the nested value is exported.
+func (e *Exporter) enterNestedValue() error {
+	if e.maxDepth <= 0 {
+		return nil
+	}
+
+	if e.depth >= e.maxDepth {
+		return errors.NewDefaultUserError(
+			"cannot export value: maximum depth of %d exceeded",
+			e.maxDepth,
+		)
+	}
+
+	e.depth++
+	return nil
+}
+
+// leaveNestedValue decreases the nesting depth of the current export, see enterNestedValue.
+func (e *Exporter) leaveNestedValue() {
+	if e.maxDepth <= 0 {
+		return
+	}
+
+	e.depth--
+}
+
+func isExportNodeLimitExceededError(err error) bool {
+	var nodeLimitErr *ExportNodeLimitExceededError
+	return goErrors.As(err, &nodeLimitErr)
+}
+
 // exportValueWithInterpreter exports the given internal (interpreter) value to an external value.
 //
 // The export is recursive, the results parameter prevents cycles:
 // it is checked at the start of the recursively called function,
 // and pre-set before a recursive call.
 //
-func exportValueWithInterpreter(
+func (e *Exporter) exportValueWithInterpreter(
 	value interpreter.Value,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -79,13 +1363,36 @@ func exportValueWithInterpreter(
 	error,
 ) {
 
+	err := e.visitNode(inter)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.isRedacted(value, inter) {
+		return cadence.NewMeteredOptional(inter, nil), nil
+	}
+
+	if e.projectedFields != nil {
+		// Only the top-level value is projected, if it is a composite
+		if _, ok := value.(*interpreter.CompositeValue); !ok {
+			e.projectedFields = nil
+		}
+	}
+
+	if e.arrayPage != nil {
+		// Only the top-level value is paginated
+		if _, ok := value.(*interpreter.ArrayValue); !ok {
+			e.arrayPage = nil
+		}
+	}
+
 	switch v := value.(type) {
 	case interpreter.VoidValue:
 		return cadence.NewMeteredVoid(inter), nil
 	case interpreter.NilValue:
 		return cadence.NewMeteredOptional(inter, nil), nil
 	case *interpreter.SomeValue:
-		return exportSomeValue(v, inter, getLocationRange, seenReferences)
+		return e.exportSomeValue(v, inter, getLocationRange, seenReferences)
 	case interpreter.BoolValue:
 		return cadence.NewMeteredBool(inter, bool(v)), nil
 	case *interpreter.StringValue:
@@ -105,14 +1412,15 @@ func exportValueWithInterpreter(
 			},
 		)
 	case *interpreter.ArrayValue:
-		return exportArrayValue(
+		return e.exportArrayValue(
 			v,
 			inter,
 			getLocationRange,
 			seenReferences,
 		)
 	case interpreter.IntValue:
-		bigInt := v.ToBigInt(inter)
+		common.UseMemory(inter, bigIntMemoryUsage(v.ByteLength()))
+		bigInt := new(big.Int).Set(v.BigInt)
 		return cadence.NewMeteredIntFromBig(
 			inter,
 			common.NewCadenceIntMemoryUsage(
@@ -145,7 +1453,8 @@ func exportValueWithInterpreter(
 			},
 		)
 	case interpreter.UIntValue:
-		bigInt := v.ToBigInt(inter)
+		common.UseMemory(inter, bigIntMemoryUsage(v.ByteLength()))
+		bigInt := new(big.Int).Set(v.BigInt)
 		return cadence.NewMeteredUIntFromBig(
 			inter,
 			common.NewCadenceIntMemoryUsage(
@@ -190,21 +1499,32 @@ func exportValueWithInterpreter(
 	case interpreter.UFix64Value:
 		return cadence.UFix64(v), nil
 	case *interpreter.CompositeValue:
-		return exportCompositeValue(
+		if e.valueInterningEnabled &&
+			e.projectedFields == nil &&
+			v.Kind != common.CompositeKindResource {
+
+			return e.exportInternedCompositeValue(
+				v,
+				inter,
+				getLocationRange,
+				seenReferences,
+			)
+		}
+		return e.exportCompositeValue(
 			v,
 			inter,
 			getLocationRange,
 			seenReferences,
 		)
 	case *interpreter.SimpleCompositeValue:
-		return exportSimpleCompositeValue(
+		return e.exportSimpleCompositeValue(
 			v,
 			inter,
 			getLocationRange,
 			seenReferences,
 		)
 	case *interpreter.DictionaryValue:
-		return exportDictionaryValue(
+		return e.exportDictionaryValue(
 			v,
 			inter,
 			getLocationRange,
@@ -227,7 +1547,7 @@ func exportValueWithInterpreter(
 		}
 		defer delete(seenReferences, v)
 		seenReferences[v] = struct{}{}
-		return exportValueWithInterpreter(
+		return e.exportValueWithInterpreter(
 			v.Value,
 			inter,
 			getLocationRange,
@@ -238,7 +1558,7 @@ func exportValueWithInterpreter(
 		if referencedValue == nil {
 			return nil, nil
 		}
-		return exportValueWithInterpreter(
+		return e.exportValueWithInterpreter(
 			*referencedValue,
 			inter,
 			getLocationRange,
@@ -249,7 +1569,7 @@ func exportValueWithInterpreter(
 	}
 }
 
-func exportSomeValue(
+func (e *Exporter) exportSomeValue(
 	v *interpreter.SomeValue,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -258,26 +1578,34 @@ func exportSomeValue(
 	cadence.Optional,
 	error,
 ) {
+	err := e.enterNestedValue()
+	if err != nil {
+		return cadence.Optional{}, err
+	}
+	defer e.leaveNestedValue()
+
 	innerValue := v.InnerValue(inter, getLocationRange)
 
 	if innerValue == nil {
 		return cadence.NewMeteredOptional(inter, nil), nil
 	}
 
-	value, err := exportValueWithInterpreter(
+	value, err := e.exportElementValue(
 		innerValue,
 		inter,
 		getLocationRange,
 		seenReferences,
 	)
 	if err != nil {
-		return cadence.Optional{}, err
+		return cadence.Optional{}, &ExportOptionalValueError{
+			Err: err,
+		}
 	}
 
 	return cadence.NewMeteredOptional(inter, value), nil
 }
 
-func exportArrayValue(
+func (e *Exporter) exportArrayValue(
 	v *interpreter.ArrayValue,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -286,22 +1614,55 @@ func exportArrayValue(
 	cadence.Array,
 	error,
 ) {
+	err := e.enterNestedValue()
+	if err != nil {
+		return cadence.Array{}, err
+	}
+	defer e.leaveNestedValue()
+
+	// Only the top-level array is paginated, see ExportArrayPage
+	page := e.arrayPage
+	e.arrayPage = nil
+
+	start := 0
+	end := v.Count()
+	if page != nil {
+		if page.offset < end {
+			start = page.offset
+		} else {
+			start = end
+		}
+		if page.limit < end-start {
+			end = start + page.limit
+		}
+	}
+	count := end - start
+
+	err = e.checkCollectionSize(count)
+	if err != nil {
+		return cadence.Array{}, err
+	}
+
 	array, err := cadence.NewMeteredArray(
 		inter,
-		v.Count(),
+		count,
 		func() ([]cadence.Value, error) {
-			values := make([]cadence.Value, 0, v.Count())
+			values := make([]cadence.Value, 0, count)
 
 			var err error
-			v.Iterate(inter, func(value interpreter.Value) (resume bool) {
+			exportElement := func(value interpreter.Value) (resume bool) {
 				var exportedValue cadence.Value
-				exportedValue, err = exportValueWithInterpreter(
+				exportedValue, err = e.exportElementValue(
 					value,
 					inter,
 					getLocationRange,
 					seenReferences,
 				)
 				if err != nil {
+					err = &ExportArrayElementError{
+						Index: start + len(values),
+						Err:   err,
+					}
 					return false
 				}
 				values = append(
@@ -309,7 +1670,19 @@ func exportArrayValue(
 					exportedValue,
 				)
 				return true
-			})
+			}
+
+			if page == nil {
+				v.Iterate(inter, exportElement)
+			} else {
+				// NOTE: the elements are accessed by index,
+				// so the elements before the page are not visited
+				for index := start; index < end; index++ {
+					if !exportElement(v.Get(inter, getLocationRange, index)) {
+						break
+					}
+				}
+			}
 
 			if err != nil {
 				return nil, err
@@ -321,12 +1694,60 @@ func exportArrayValue(
 		return cadence.Array{}, err
 	}
 
-	exportType := ExportType(v.SemaType(inter), map[sema.TypeID]cadence.Type{}).(cadence.ArrayType)
+	semaType := e.semaType(v.Type, func() sema.Type {
+		return v.SemaType(inter)
+	})
+	exportType := e.mapFieldNames(
+		e.mapEnumTypes(
+			e.filterPublicFields(ExportType(semaType, e.typeResults()), semaType),
+		),
+	).(cadence.ArrayType)
+
+	if constantSizedType, ok := exportType.(cadence.ConstantSizedArrayType); ok && page != nil {
+		exportType = cadence.VariableSizedArrayType{
+			ElementType: constantSizedType.ElementType,
+		}
+	}
 
 	return array.WithType(exportType), err
 }
 
-func exportCompositeValue(
+// exportInternedCompositeValue exports the given composite,
+// or reuses the exported value if the composite was already exported, see WithValueInterning.
+func (e *Exporter) exportInternedCompositeValue(
+	v *interpreter.CompositeValue,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	seenReferences seenReferences,
+) (
+	cadence.Value,
+	error,
+) {
+	if exported, ok := e.internedValues[v]; ok {
+		return exported, nil
+	}
+
+	errorCount := len(e.collectedErrors)
+
+	exported, err := e.exportCompositeValue(
+		v,
+		inter,
+		getLocationRange,
+		seenReferences,
+	)
+	if err != nil || exported == nil || len(e.collectedErrors) != errorCount {
+		return exported, err
+	}
+
+	if e.internedValues == nil {
+		e.internedValues = map[*interpreter.CompositeValue]cadence.Value{}
+	}
+	e.internedValues[v] = exported
+
+	return exported, nil
+}
+
+func (e *Exporter) exportCompositeValue(
 	v *interpreter.CompositeValue,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -336,6 +1757,46 @@ func exportCompositeValue(
 	error,
 ) {
 
+	err := e.enterNestedValue()
+	if err != nil {
+		return nil, err
+	}
+	defer e.leaveNestedValue()
+
+	if e.compositeCycleDetectionEnabled {
+		// Break recursion through composites
+		storageID := v.StorageID()
+		if _, ok := e.visitedComposites[storageID]; ok {
+			return nil, nil
+		}
+		if e.visitedComposites == nil {
+			e.visitedComposites = map[atree.StorageID]struct{}{}
+		}
+		defer delete(e.visitedComposites, storageID)
+		e.visitedComposites[storageID] = struct{}{}
+	}
+
+	if v.Kind == common.CompositeKindResource {
+		// Resources cannot form cycles in well-formed programs,
+		// so a resource which is encountered again while it is being exported
+		// indicates an invalid state, which must be reported instead of recursing infinitely
+		storageID := v.StorageID()
+		if _, ok := e.visitedResources[storageID]; ok {
+			return nil, errors.NewDefaultUserError(
+				"cannot export resource of type `%s`: cyclic resource",
+				v.TypeID(),
+			)
+		}
+		if e.visitedResources == nil {
+			e.visitedResources = map[atree.StorageID]struct{}{}
+		}
+		defer delete(e.visitedResources, storageID)
+		e.visitedResources[storageID] = struct{}{}
+	}
+
+	projectedFields := e.projectedFields
+	e.projectedFields = nil
+
 	staticType, err := inter.ConvertStaticToSemaType(v.StaticType(inter))
 	if err != nil {
 		return nil, err
@@ -346,8 +1807,38 @@ func exportCompositeValue(
 		panic(errors.NewUnreachableError())
 	}
 
-	// TODO: consider making the results map "global", by moving it up to exportValueWithInterpreter
-	t := exportCompositeType(inter, compositeType, map[sema.TypeID]cadence.Type{})
+	storedTypeID := compositeType.ID()
+	if substituteType, ok := e.typeSubstitutions[storedTypeID]; ok {
+		compositeType = substituteType
+	}
+	renamedFields := e.renamedFields[storedTypeID]
+
+	if e.enumsAsRawValue && compositeType.Kind == common.CompositeKindEnum {
+		rawValue := v.GetField(inter, getLocationRange, sema.EnumRawValueFieldName)
+		return e.exportElementValue(
+			rawValue,
+			inter,
+			getLocationRange,
+			seenReferences,
+		)
+	}
+
+	t := e.filterPublicFields(
+		ExportMeteredType(inter, compositeType, e.typeResults()),
+		compositeType,
+	).(cadence.CompositeType)
+
+	if projectedFields != nil {
+		t, err = projectCompositeType(t, projectedFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if compositeType == sema.PublicKeyType && projectedFields == nil {
+		// PublicKey has a dedicated constructor when imported, see importPublicKey
+		return e.exportPublicKeyValue(v, t, inter, getLocationRange, seenReferences)
+	}
 
 	// NOTE: use the exported type's fields to ensure fields in type
 	// and value are in sync
@@ -359,6 +1850,9 @@ func exportCompositeValue(
 
 		for i, field := range fieldNames {
 			fieldName := field.Identifier
+			if storedFieldName, ok := renamedFields[fieldName]; ok {
+				fieldName = storedFieldName
+			}
 
 			fieldValue := v.GetField(inter, getLocationRange, fieldName)
 			if fieldValue == nil && v.ComputedFields != nil {
@@ -367,7 +1861,7 @@ func exportCompositeValue(
 				}
 			}
 
-			exportedFieldValue, err := exportValueWithInterpreter(
+			exportedFieldValue, err := e.exportElementValue(
 				fieldValue,
 				inter,
 				getLocationRange,
@@ -397,7 +1891,8 @@ func exportCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return structure.WithType(t.(*cadence.StructType)), nil
+		structure.StorageID = e.exportStorageID(v, inter)
+		return structure.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.StructType)), nil
 	case common.CompositeKindResource:
 		resource, err := cadence.NewMeteredResource(
 			inter,
@@ -409,7 +1904,15 @@ func exportCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return resource.WithType(t.(*cadence.ResourceType)), nil
+		resource = resource.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.ResourceType))
+		resource.StorageID = e.exportStorageID(v, inter)
+		if e.includeOwner {
+			owner := v.GetOwner()
+			if owner != (common.Address{}) {
+				resource = resource.WithOwner(cadence.NewMeteredAddress(inter, owner))
+			}
+		}
+		return resource, nil
 	case common.CompositeKindEvent:
 		event, err := cadence.NewMeteredEvent(
 			inter,
@@ -421,7 +1924,7 @@ func exportCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return event.WithType(t.(*cadence.EventType)), nil
+		return event.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.EventType)), nil
 	case common.CompositeKindContract:
 		contract, err := cadence.NewMeteredContract(
 			inter,
@@ -433,7 +1936,8 @@ func exportCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return contract.WithType(t.(*cadence.ContractType)), nil
+		contract.StorageID = e.exportStorageID(v, inter)
+		return contract.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.ContractType)), nil
 	case common.CompositeKindEnum:
 		enum, err := cadence.NewMeteredEnum(
 			inter,
@@ -445,7 +1949,8 @@ func exportCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return enum.WithType(t.(*cadence.EnumType)), nil
+		enum.StorageID = e.exportStorageID(v, inter)
+		return enum.WithType(e.mapFieldNames(t).(*cadence.EnumType)), nil
 	}
 
 	return nil, errors.NewDefaultUserError(
@@ -464,7 +1969,98 @@ func exportCompositeValue(
 	)
 }
 
-func exportSimpleCompositeValue(
+// exportStorageID returns the storage ID of the given composite,
+// if storage IDs are included and the composite is stored in an account,
+// see WithIncludeStorageID.
+func (e *Exporter) exportStorageID(
+	v *interpreter.CompositeValue,
+	gauge common.MemoryGauge,
+) *cadence.StorageID {
+	if !e.includeStorageID {
+		return nil
+	}
+
+	storageID := v.StorageID()
+	if storageID.Address == (atree.Address{}) {
+		return nil
+	}
+
+	return &cadence.StorageID{
+		Address: cadence.NewMeteredAddress(gauge, common.Address(storageID.Address)),
+		Index:   storageID.Index,
+	}
+}
+
+// exportPublicKeyValue exports the given PublicKey value, which has the given exported type.
+//
+// The exported struct has the fields `publicKey` and `signatureAlgorithm`, in that order,
+// so it can be imported again, see importPublicKey.
+// The public key is a computed field, see interpreter.NewPublicKeyValue.
+func (e *Exporter) exportPublicKeyValue(
+	v *interpreter.CompositeValue,
+	t cadence.CompositeType,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	seenReferences seenReferences,
+) (
+	cadence.Value,
+	error,
+) {
+	fields := t.CompositeFields()
+	if len(fields) != 2 ||
+		fields[0].Identifier != sema.PublicKeyPublicKeyField ||
+		fields[1].Identifier != sema.PublicKeySignAlgoField {
+
+		return nil, errors.NewUnexpectedError(
+			"unexportable public key value: invalid fields of type `%s`",
+			t.ID(),
+		)
+	}
+
+	computedPublicKey, ok := v.ComputedFields[sema.PublicKeyPublicKeyField]
+	if !ok {
+		return nil, errors.NewUnexpectedError(
+			"unexportable public key value: missing field `%s`",
+			sema.PublicKeyPublicKeyField,
+		)
+	}
+
+	publicKey, err := e.exportElementValue(
+		computedPublicKey(inter, getLocationRange),
+		inter,
+		getLocationRange,
+		seenReferences,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	signAlgo, err := e.exportElementValue(
+		v.GetField(inter, getLocationRange, sema.PublicKeySignAlgoField),
+		inter,
+		getLocationRange,
+		seenReferences,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	structure, err := cadence.NewMeteredStruct(
+		inter,
+		len(fields),
+		func() ([]cadence.Value, error) {
+			return []cadence.Value{publicKey, signAlgo}, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	structure.StorageID = e.exportStorageID(v, inter)
+
+	return structure.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.StructType)), nil
+}
+
+func (e *Exporter) exportSimpleCompositeValue(
 	v *interpreter.SimpleCompositeValue,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -486,8 +2082,10 @@ func exportSimpleCompositeValue(
 		)
 	}
 
-	// TODO: consider making the results map "global", by moving it up to exportValueWithInterpreter
-	t := exportCompositeType(inter, compositeType, map[sema.TypeID]cadence.Type{})
+	t := e.filterPublicFields(
+		ExportMeteredType(inter, compositeType, e.typeResults()),
+		compositeType,
+	).(cadence.CompositeType)
 
 	// NOTE: use the exported type's fields to ensure fields in type
 	// and value are in sync
@@ -507,7 +2105,7 @@ func exportSimpleCompositeValue(
 				}
 			}
 
-			exportedFieldValue, err := exportValueWithInterpreter(
+			exportedFieldValue, err := e.exportElementValue(
 				fieldValue,
 				inter,
 				getLocationRange,
@@ -537,7 +2135,7 @@ func exportSimpleCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return structure.WithType(t.(*cadence.StructType)), nil
+		return structure.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.StructType)), nil
 	case common.CompositeKindResource:
 		resource, err := cadence.NewMeteredResource(
 			inter,
@@ -549,7 +2147,7 @@ func exportSimpleCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return resource.WithType(t.(*cadence.ResourceType)), nil
+		return resource.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.ResourceType)), nil
 	case common.CompositeKindEvent:
 		event, err := cadence.NewMeteredEvent(
 			inter,
@@ -561,7 +2159,7 @@ func exportSimpleCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return event.WithType(t.(*cadence.EventType)), nil
+		return event.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.EventType)), nil
 	case common.CompositeKindContract:
 		contract, err := cadence.NewMeteredContract(
 			inter,
@@ -573,7 +2171,7 @@ func exportSimpleCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return contract.WithType(t.(*cadence.ContractType)), nil
+		return contract.WithType(e.mapFieldNames(e.mapEnumTypes(t)).(*cadence.ContractType)), nil
 	case common.CompositeKindEnum:
 		enum, err := cadence.NewMeteredEnum(
 			inter,
@@ -585,7 +2183,7 @@ func exportSimpleCompositeValue(
 		if err != nil {
 			return nil, err
 		}
-		return enum.WithType(t.(*cadence.EnumType)), nil
+		return enum.WithType(e.mapFieldNames(t).(*cadence.EnumType)), nil
 	}
 
 	return nil, errors.NewUnexpectedError(
@@ -604,7 +2202,16 @@ func exportSimpleCompositeValue(
 	)
 }
 
-func exportDictionaryValue(
+// exportDictionaryValue exports the given dictionary value.
+//
+// The type of the exported dictionary is the static type of the dictionary value,
+// e.g. `{AnyStruct: Int}`, it is not narrowed to the types of the contained keys and values.
+// Each exported key and value has its own, concrete type.
+//
+// The pairs are exported in the iteration order of the dictionary value.
+// NOTE: dictionary values do not maintain the insertion order,
+// so the order of the pairs of an imported dictionary is not preserved when it is exported again.
+func (e *Exporter) exportDictionaryValue(
 	v *interpreter.DictionaryValue,
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
@@ -613,6 +2220,17 @@ func exportDictionaryValue(
 	cadence.Dictionary,
 	error,
 ) {
+	err := e.enterNestedValue()
+	if err != nil {
+		return cadence.Dictionary{}, err
+	}
+	defer e.leaveNestedValue()
+
+	err = e.checkCollectionSize(v.Count())
+	if err != nil {
+		return cadence.Dictionary{}, err
+	}
+
 	dictionary, err := cadence.NewMeteredDictionary(
 		inter,
 		v.Count(),
@@ -623,7 +2241,7 @@ func exportDictionaryValue(
 			v.Iterate(inter, func(key, value interpreter.Value) (resume bool) {
 
 				var convertedKey cadence.Value
-				convertedKey, err = exportValueWithInterpreter(
+				convertedKey, err = e.exportElementValue(
 					key,
 					inter,
 					getLocationRange,
@@ -634,7 +2252,7 @@ func exportDictionaryValue(
 				}
 
 				var convertedValue cadence.Value
-				convertedValue, err = exportValueWithInterpreter(
+				convertedValue, err = e.exportElementValue(
 					value,
 					inter,
 					getLocationRange,
@@ -646,10 +2264,11 @@ func exportDictionaryValue(
 
 				pairs = append(
 					pairs,
-					cadence.KeyValuePair{
-						Key:   convertedKey,
-						Value: convertedValue,
-					},
+					cadence.NewMeteredKeyValuePair(
+						inter,
+						convertedKey,
+						convertedValue,
+					),
 				)
 
 				return true
@@ -666,11 +2285,31 @@ func exportDictionaryValue(
 		return cadence.Dictionary{}, err
 	}
 
-	exportType := ExportType(v.SemaType(inter), map[sema.TypeID]cadence.Type{}).(cadence.DictionaryType)
+	semaType := e.semaType(v.Type, func() sema.Type {
+		return v.SemaType(inter)
+	})
+	exportType := e.mapFieldNames(
+		e.mapEnumTypes(
+			e.filterPublicFields(ExportType(semaType, e.typeResults()), semaType),
+		),
+	).(cadence.DictionaryType)
 
 	return dictionary.WithType(exportType), err
 }
 
+// checkCollectionSize returns a user error if the given element count
+// exceeds the maximum collection size of the exporter, if any.
+func (e *Exporter) checkCollectionSize(count int) error {
+	if e.maxCollectionSize > 0 && count > e.maxCollectionSize {
+		return errors.NewDefaultUserError(
+			"cannot export collection: element count %d exceeds maximum collection size %d",
+			count,
+			e.maxCollectionSize,
+		)
+	}
+	return nil
+}
+
 func exportLinkValue(v interpreter.LinkValue, inter *interpreter.Interpreter) cadence.Link {
 	path := exportPathValue(inter, v.TargetPath)
 	ty := string(inter.MustConvertStaticToSemaType(v.Type).ID())
@@ -759,6 +2398,195 @@ func exportEvent(
 	return exported.WithType(eventType), nil
 }
 
+// An Importer converts Cadence values to runtime values.
+//
+// The behaviour of the import can be configured using import options,
+// see NewImporter.
+type Importer struct {
+	strictOptionalDepthEnabled bool
+	strictFieldTypesEnabled    bool
+	// elaboration is used to resolve the types of imported composites, if any
+	elaboration *sema.Elaboration
+	// compositeTypeCache contains the resolved types of imported composites, keyed by type ID.
+	// compositeTypeLookups counts the lookups that were not served by the cache
+	typeCachingEnabled   bool
+	compositeTypeCache   map[common.TypeID]*sema.CompositeType
+	compositeTypeLookups int
+	// rejectZeroAddressCapabilities determines if capabilities with the zero address are rejected
+	rejectZeroAddressCapabilities bool
+	// stringInterningEnabled determines if imported strings with the same content are reused.
+	// internedStrings contains the imported strings of the current import, keyed by content
+	stringInterningEnabled bool
+	internedStrings        map[cadence.String]*interpreter.StringValue
+	// maxDepth is the maximum nesting depth of imported values.
+	// depth is the nesting depth of the value which is currently being imported
+	maxDepth int
+	depth    int
+}
+
+// ImportOption configures an Importer.
+type ImportOption func(*Importer)
+
+// WithStrictOptionalDepth returns an import option that enables or disables
+// the strict checking of the optional nesting depth of imported values.
+//
+// By default, a value that is less deeply nested in optionals than the expected type,
+// e.g. `Some(1)` for the expected type `Int??`, is imported as is,
+// and is only coerced to the expected type later.
+// When enabled, the optional nesting depth of the value must match the expected type,
+// otherwise the import fails with a user error.
+func WithStrictOptionalDepth(enabled bool) ImportOption {
+	return func(importer *Importer) {
+		importer.strictOptionalDepthEnabled = enabled
+	}
+}
+
+// WithStrictFieldTypes returns an import option that enables or disables
+// the strict checking of the types of imported composite field values.
+//
+// By default, the declared type of a composite field is only used as the expected type
+// when importing the field value, and the value is not checked to conform to it.
+// When enabled, the static type of each imported field value must be a subtype
+// of the declared type of the field, otherwise the import fails with a user error.
+func WithStrictFieldTypes(enabled bool) ImportOption {
+	return func(importer *Importer) {
+		importer.strictFieldTypesEnabled = enabled
+	}
+}
+
+// WithElaboration returns an import option that resolves the types of imported composites
+// using the given elaboration, e.g. the elaboration of a program other than the program
+// of the interpreter which is used to construct the imported values.
+//
+// Types which are not declared in the elaboration are resolved using the interpreter.
+func WithElaboration(elaboration *sema.Elaboration) ImportOption {
+	return func(importer *Importer) {
+		importer.elaboration = elaboration
+	}
+}
+
+// WithImportTypeCaching returns an import option that enables or disables
+// the caching of the resolved types of imported composites across imports performed by the importer.
+//
+// Cached types are not resolved again when a composite of the same type is imported again,
+// e.g. when many values are imported using the same interpreter.
+// Long-lived importers must invalidate cached types when the underlying types change,
+// e.g. after a contract update, see Importer.InvalidateType and Importer.ClearCache.
+func WithImportTypeCaching(enabled bool) ImportOption {
+	return func(importer *Importer) {
+		importer.typeCachingEnabled = enabled
+	}
+}
+
+// WithRejectZeroAddressCapabilities returns an import option that enables or disables
+// the rejection of capabilities with the zero address, which can never be borrowed,
+// e.g. to validate arguments.
+//
+// When enabled, importing such a capability fails with a user error.
+func WithRejectZeroAddressCapabilities(enabled bool) ImportOption {
+	return func(importer *Importer) {
+		importer.rejectZeroAddressCapabilities = enabled
+	}
+}
+
+// WithStringInterning returns an import option that enables or disables
+// the interning of imported strings.
+//
+// When enabled, strings with the same content are only imported once per import,
+// and all occurrences are imported as the same string value,
+// e.g. to reduce the allocations when importing large arrays of repetitive data.
+// Only the first occurrence of a string is metered.
+func WithStringInterning(enabled bool) ImportOption {
+	return func(importer *Importer) {
+		importer.stringInterningEnabled = enabled
+	}
+}
+
+// WithMaxImportDepth returns an import option that limits the nesting depth of imported values,
+// e.g. to bound the recursion when importing untrusted values.
+//
+// The imported value has depth zero, and each optional, array element, dictionary key and value,
+// and composite field is nested one level deeper than the value containing it.
+// An import which exceeds the limit fails with a user error.
+// A limit of zero disables the limit.
+func WithMaxImportDepth(depth int) ImportOption {
+	return func(importer *Importer) {
+		importer.maxDepth = depth
+	}
+}
+
+// NewImporter returns a new importer, configured with the given options.
+func NewImporter(options ...ImportOption) *Importer {
+	importer := &Importer{}
+	for _, option := range options {
+		option(importer)
+	}
+	return importer
+}
+
+// ImportValue converts a Cadence value to a runtime value.
+func (im *Importer) ImportValue(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	value cadence.Value,
+	expectedType sema.Type,
+) (interpreter.Value, error) {
+	if im.stringInterningEnabled {
+		im.internedStrings = nil
+		defer func() {
+			im.internedStrings = nil
+		}()
+	}
+
+	return im.importValue(inter, getLocationRange, value, expectedType)
+}
+
+// InvalidateType removes the composite type with the given type ID from the type cache,
+// so it is resolved again when a composite of the type is imported again.
+func (im *Importer) InvalidateType(typeID common.TypeID) {
+	delete(im.compositeTypeCache, typeID)
+}
+
+// ClearCache removes all composite types from the type cache.
+func (im *Importer) ClearCache() {
+	im.compositeTypeCache = nil
+}
+
+// ImportValue converts a Cadence value to a runtime value of the given expected type, if any,
+// e.g. to round-trip exported values without executing a transaction, see ExportValue.
+//
+// Composites, including enums, and capabilities are resolved using the given interpreter,
+// and the imported value must be a subtype of the expected type, otherwise the import fails
+// with a user error.
+func ImportValue(
+	inter *interpreter.Interpreter,
+	value cadence.Value,
+	expectedType sema.Type,
+) (interpreter.Value, error) {
+	imported, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		expectedType,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedType != nil {
+		staticType := imported.StaticType(inter)
+		if !inter.IsSubTypeOfSemaType(staticType, expectedType) {
+			return nil, errors.NewDefaultUserError(
+				"cannot import value of type `%s`: expected value of type `%s`",
+				staticType,
+				expectedType.QualifiedString(),
+			)
+		}
+	}
+
+	return imported, nil
+}
+
 // importValue converts a Cadence value to a runtime value.
 func importValue(
 	inter *interpreter.Interpreter,
@@ -766,11 +2594,45 @@ func importValue(
 	value cadence.Value,
 	expectedType sema.Type,
 ) (interpreter.Value, error) {
+	return NewImporter().importValue(inter, getLocationRange, value, expectedType)
+}
+
+func (im *Importer) importValue(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	value cadence.Value,
+	expectedType sema.Type,
+) (interpreter.Value, error) {
+	if im.maxDepth > 0 {
+		if im.depth > im.maxDepth {
+			return nil, errors.NewDefaultUserError(
+				"cannot import value: maximum nesting depth of %d exceeded",
+				im.maxDepth,
+			)
+		}
+		im.depth++
+		defer func() {
+			im.depth--
+		}()
+	}
+
+	if im.strictOptionalDepthEnabled {
+		if _, ok := value.(cadence.Optional); !ok {
+			if _, ok := expectedType.(*sema.OptionalType); ok {
+				return nil, newOptionalDepthMismatchError(expectedType)
+			}
+		}
+	}
+
+	if enumType, ok := expectedType.(*sema.CompositeType); ok && isEnumRawValue(value, enumType) {
+		return im.importEnumFromRawValue(inter, getLocationRange, value, enumType)
+	}
+
 	switch v := value.(type) {
 	case cadence.Void:
 		return interpreter.NewVoidValue(inter), nil
 	case cadence.Optional:
-		return importOptionalValue(
+		return im.importOptionalValue(
 			inter,
 			getLocationRange,
 			v,
@@ -779,10 +2641,15 @@ func importValue(
 	case cadence.Bool:
 		return interpreter.NewBoolValue(inter, bool(v)), nil
 	case cadence.String:
+		if im.stringInterningEnabled {
+			return im.importInternedString(inter, v), nil
+		}
 		return importString(inter, v), nil
 	case cadence.Character:
 		return importCharacter(inter, v), nil
 	case cadence.Bytes:
+		// NOTE: bytes are imported as a `[UInt8]` array,
+		// so they can be passed for parameters of that type
 		return interpreter.ByteSliceToByteArrayValue(inter, v), nil
 	case cadence.Address:
 		return importAddress(inter, v), nil
@@ -829,21 +2696,21 @@ func importValue(
 	case cadence.Path:
 		return importPathValue(inter, v), nil
 	case cadence.Array:
-		return importArrayValue(
+		return im.importArrayValue(
 			inter,
 			getLocationRange,
 			v,
 			expectedType,
 		)
 	case cadence.Dictionary:
-		return importDictionaryValue(
+		return im.importDictionaryValue(
 			inter,
 			getLocationRange,
 			v,
 			expectedType,
 		)
 	case cadence.Struct:
-		return importCompositeValue(
+		return im.importCompositeValue(
 			inter,
 			getLocationRange,
 			common.CompositeKindStructure,
@@ -853,7 +2720,7 @@ func importValue(
 			v.Fields,
 		)
 	case cadence.Resource:
-		return importCompositeValue(
+		return im.importCompositeValue(
 			inter,
 			getLocationRange,
 			common.CompositeKindResource,
@@ -863,7 +2730,7 @@ func importValue(
 			v.Fields,
 		)
 	case cadence.Event:
-		return importCompositeValue(
+		return im.importCompositeValue(
 			inter,
 			getLocationRange,
 			common.CompositeKindEvent,
@@ -873,7 +2740,7 @@ func importValue(
 			v.Fields,
 		)
 	case cadence.Enum:
-		return importCompositeValue(
+		return im.importCompositeValue(
 			inter,
 			getLocationRange,
 			common.CompositeKindEnum,
@@ -888,6 +2755,11 @@ func importValue(
 			v.StaticType,
 		)
 	case cadence.Capability:
+		if im.rejectZeroAddressCapabilities && v.Address == (cadence.Address{}) {
+			return nil, errors.NewDefaultUserError(
+				"cannot import capability: address must not be zero",
+			)
+		}
 		return importCapability(
 			inter,
 			v.Path,
@@ -955,7 +2827,7 @@ func importUInt256(inter *interpreter.Interpreter, v cadence.UInt256) interprete
 }
 
 func importInt(inter *interpreter.Interpreter, v cadence.Int) interpreter.IntValue {
-	memoryUsage := common.NewBigIntMemoryUsage(
+	memoryUsage := bigIntMemoryUsage(
 		common.BigIntByteLength(v.Value),
 	)
 	return interpreter.NewIntValueFromBigInt(
@@ -1022,7 +2894,7 @@ func importInt256(inter *interpreter.Interpreter, v cadence.Int256) interpreter.
 }
 
 func importUInt(inter *interpreter.Interpreter, v cadence.UInt) interpreter.UIntValue {
-	memoryUsage := common.NewBigIntMemoryUsage(
+	memoryUsage := bigIntMemoryUsage(
 		common.BigIntByteLength(v.Value),
 	)
 	return interpreter.NewUIntValueFromBigInt(
@@ -1099,6 +2971,24 @@ func importString(inter *interpreter.Interpreter, v cadence.String) *interpreter
 	)
 }
 
+// importInternedString imports the given string,
+// or returns the string with the same content which was already imported by the current import,
+// see WithStringInterning.
+func (im *Importer) importInternedString(inter *interpreter.Interpreter, v cadence.String) *interpreter.StringValue {
+	if interned, ok := im.internedStrings[v]; ok {
+		return interned
+	}
+
+	imported := importString(inter, v)
+
+	if im.internedStrings == nil {
+		im.internedStrings = map[cadence.String]*interpreter.StringValue{}
+	}
+	im.internedStrings[v] = imported
+
+	return imported
+}
+
 func importCharacter(inter *interpreter.Interpreter, v cadence.Character) interpreter.CharacterValue {
 	s := string(v)
 	memoryUsage := common.NewCharacterMemoryUsage(len(s))
@@ -1111,6 +3001,12 @@ func importCharacter(inter *interpreter.Interpreter, v cadence.Character) interp
 	)
 }
 
+// importAddress imports the given address.
+//
+// NOTE: addresses always have the canonical, fixed length form,
+// as short forms are left-padded when the address is constructed (see cadence.BytesToAddress).
+// Logically equal addresses are therefore imported as equal values,
+// e.g. they are the same key when imported as dictionary keys.
 func importAddress(inter *interpreter.Interpreter, v cadence.Address) interpreter.AddressValue {
 	return interpreter.NewAddressValue(
 		inter,
@@ -1180,51 +3076,131 @@ func importCapability(
 
 }
 
-func importOptionalValue(
+func (im *Importer) importOptionalValue(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	v cadence.Optional,
+	expectedType sema.Type,
+) (
+	interpreter.Value,
+	error,
+) {
+	var innerType sema.Type
+	if optionalType, ok := expectedType.(*sema.OptionalType); ok {
+		innerType = optionalType.Type
+	} else if im.strictOptionalDepthEnabled &&
+		expectedType != nil &&
+		!sema.IsSubType(&sema.OptionalType{Type: sema.NeverType}, expectedType) {
+
+		// The value is more deeply nested in optionals than the expected type
+		return nil, newOptionalDepthMismatchError(expectedType)
+	}
+
+	if v.Value == nil {
+		return interpreter.NewNilValue(inter), nil
+	}
+
+	innerValue, err := im.importValue(inter, getLocationRange, v.Value, innerType)
+	if err != nil {
+		return nil, err
+	}
+
+	return interpreter.NewSomeValueNonCopying(inter, innerValue), nil
+}
+
+func newOptionalDepthMismatchError(expectedType sema.Type) error {
+	return errors.NewDefaultUserError(
+		"cannot import value: optional nesting depth does not match expected type `%s`",
+		expectedType.QualifiedString(),
+	)
+}
+
+func (im *Importer) importArrayValue(
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
-	v cadence.Optional,
+	v cadence.Array,
 	expectedType sema.Type,
 ) (
-	interpreter.Value,
+	*interpreter.ArrayValue,
 	error,
 ) {
-	if v.Value == nil {
-		return interpreter.NewNilValue(inter), nil
-	}
+	arrayType, elementType := importArrayElementType(expectedType)
 
-	var innerType sema.Type
-	if optionalType, ok := expectedType.(*sema.OptionalType); ok {
-		innerType = optionalType.Type
+	if arrayType == nil && len(v.Values) == 0 && v.ArrayType != nil {
+		// The element type of an empty array cannot be inferred from its elements,
+		// so the type of the given array is used, if any
+		var err error
+		arrayType, err = importCarriedArrayType(inter, v.ArrayType)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	innerValue, err := importValue(inter, getLocationRange, v.Value, innerType)
+	err := checkImportedArrayLength(arrayType, len(v.Values))
 	if err != nil {
 		return nil, err
 	}
 
-	return interpreter.NewSomeValueNonCopying(inter, innerValue), nil
+	values := make([]interpreter.Value, len(v.Values))
+
+	for i, element := range v.Values {
+		value, err := im.importValue(
+			inter,
+			getLocationRange,
+			element,
+			elementType,
+		)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return newImportedArrayValue(inter, getLocationRange, arrayType, values)
 }
 
-func importArrayValue(
+// ImportArrayFrom imports an array whose elements are pulled one by one from the given generator function,
+// instead of being provided as an already materialized cadence.Array.
+//
+// The generator returns the next element and true, or false once there are no more elements.
+// If the expected type is not an array type, the element type is inferred from the imported elements.
+func ImportArrayFrom(
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
-	v cadence.Array,
 	expectedType sema.Type,
+	next func() (cadence.Value, bool, error),
 ) (
 	*interpreter.ArrayValue,
 	error,
 ) {
-	values := make([]interpreter.Value, len(v.Values))
+	return NewImporter().ImportArrayFrom(inter, getLocationRange, expectedType, next)
+}
 
-	var elementType sema.Type
-	arrayType, ok := expectedType.(sema.ArrayType)
-	if ok {
-		elementType = arrayType.ElementType(false)
-	}
+// ImportArrayFrom imports an array whose elements are pulled one by one from the given generator function,
+// see the function ImportArrayFrom.
+func (im *Importer) ImportArrayFrom(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	expectedType sema.Type,
+	next func() (cadence.Value, bool, error),
+) (
+	*interpreter.ArrayValue,
+	error,
+) {
+	var values []interpreter.Value
 
-	for i, element := range v.Values {
-		value, err := importValue(
+	arrayType, elementType := importArrayElementType(expectedType)
+
+	for {
+		element, ok, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		value, err := im.importValue(
 			inter,
 			getLocationRange,
 			element,
@@ -1233,14 +3209,76 @@ func importArrayValue(
 		if err != nil {
 			return nil, err
 		}
-		values[i] = value
+		values = append(values, value)
+	}
+
+	err := checkImportedArrayLength(arrayType, len(values))
+	if err != nil {
+		return nil, err
+	}
+
+	return newImportedArrayValue(inter, getLocationRange, arrayType, values)
+}
+
+func importArrayElementType(expectedType sema.Type) (arrayType sema.ArrayType, elementType sema.Type) {
+	arrayType, ok := expectedType.(sema.ArrayType)
+	if ok {
+		elementType = arrayType.ElementType(false)
+	}
+	return
+}
+
+// importCarriedArrayType returns the sema type of the given type of an imported array.
+func importCarriedArrayType(
+	inter *interpreter.Interpreter,
+	t cadence.ArrayType,
+) (
+	sema.ArrayType,
+	error,
+) {
+	semaType, err := inter.ConvertStaticToSemaType(ImportType(inter, t))
+	if err != nil {
+		return nil, err
+	}
+
+	arrayType, ok := semaType.(sema.ArrayType)
+	if !ok {
+		return nil, errors.NewUnexpectedError("cannot import array: invalid array type `%s`", t.ID())
 	}
 
+	return arrayType, nil
+}
+
+// checkImportedArrayLength checks that the length of an imported array
+// matches the size of the expected type, if it is a constant-sized array type.
+func checkImportedArrayLength(arrayType sema.ArrayType, length int) error {
+	constantSizedType, ok := arrayType.(*sema.ConstantSizedType)
+	if !ok || int64(length) == constantSizedType.Size {
+		return nil
+	}
+
+	return errors.NewDefaultUserError(
+		"cannot import array: expected %d elements for type `%s`, got %d",
+		constantSizedType.Size,
+		constantSizedType.QualifiedString(),
+		length,
+	)
+}
+
+func newImportedArrayValue(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	arrayType sema.ArrayType,
+	values []interpreter.Value,
+) (
+	*interpreter.ArrayValue,
+	error,
+) {
 	var staticArrayType interpreter.ArrayStaticType
 	if arrayType != nil {
 		staticArrayType = interpreter.ConvertSemaArrayTypeToStaticArrayType(inter, arrayType)
 	} else {
-		types := make([]sema.Type, len(v.Values))
+		types := make([]sema.Type, len(values))
 
 		for i, value := range values {
 			typ, err := inter.ConvertStaticToSemaType(value.StaticType(inter))
@@ -1252,7 +3290,9 @@ func importArrayValue(
 
 		elementSuperType := sema.LeastCommonSuperType(types...)
 		if elementSuperType == sema.InvalidType {
-			return nil, errors.NewUnexpectedError("cannot import array: elements do not belong to the same type")
+			return nil, &HeterogeneousArrayImportError{
+				ElementTypes: types,
+			}
 		}
 
 		staticArrayType = interpreter.NewVariableSizedStaticType(
@@ -1270,7 +3310,13 @@ func importArrayValue(
 	), nil
 }
 
-func importDictionaryValue(
+// importDictionaryValue imports the given dictionary.
+//
+// The pairs are inserted in the order of the given dictionary,
+// so for duplicate keys, the last value wins.
+// NOTE: the order of the pairs is not maintained by the imported dictionary value,
+// see Exporter.exportDictionaryValue.
+func (im *Importer) importDictionaryValue(
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
 	v cadence.Dictionary,
@@ -1288,10 +3334,17 @@ func importDictionaryValue(
 	if ok {
 		keyType = dictionaryType.KeyType
 		valueType = dictionaryType.ValueType
+
+		if !sema.IsValidDictionaryKeyType(keyType) {
+			return nil, errors.NewDefaultUserError(
+				"cannot import dictionary: invalid key type `%s`",
+				keyType.QualifiedString(),
+			)
+		}
 	}
 
 	for i, pair := range v.Pairs {
-		key, err := importValue(
+		key, err := im.importValue(
 			inter,
 			getLocationRange,
 			pair.Key,
@@ -1300,9 +3353,17 @@ func importDictionaryValue(
 		if err != nil {
 			return nil, err
 		}
+
+		if dictionaryType != nil {
+			key, err = coerceDictionaryKey(inter, getLocationRange, key, keyType)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		keysAndValues[i*2] = key
 
-		value, err := importValue(
+		value, err := im.importValue(
 			inter,
 			getLocationRange,
 			pair.Value,
@@ -1339,14 +3400,13 @@ func importDictionaryValue(
 		keySuperType := sema.LeastCommonSuperType(keyTypes...)
 		valueSuperType := sema.LeastCommonSuperType(valueTypes...)
 
-		if !sema.IsValidDictionaryKeyType(keySuperType) {
-			return nil, errors.NewDefaultUserError(
-				"cannot import dictionary: keys does not belong to the same type",
-			)
-		}
+		if !sema.IsValidDictionaryKeyType(keySuperType) ||
+			valueSuperType == sema.InvalidType {
 
-		if valueSuperType == sema.InvalidType {
-			return nil, errors.NewDefaultUserError("cannot import dictionary: values does not belong to the same type")
+			return nil, &HeterogeneousDictionaryImportError{
+				KeyTypes:   keyTypes,
+				ValueTypes: valueTypes,
+			}
 		}
 
 		dictionaryStaticType = interpreter.NewDictionaryStaticType(
@@ -1364,7 +3424,63 @@ func importDictionaryValue(
 	), nil
 }
 
-func importCompositeValue(
+// coerceDictionaryKey converts the given imported integer key to the given expected integer key type,
+// e.g. a UInt8 key of a dictionary that is expected to have the type `{Int: String}`,
+// so the key matches the key type of the imported dictionary.
+//
+// Keys which are out of the range of the expected key type are rejected.
+// All other keys are returned as-is.
+func coerceDictionaryKey(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	key interpreter.Value,
+	keyType sema.Type,
+) (
+	interpreter.Value,
+	error,
+) {
+	integerKeyType, ok := keyType.(*sema.NumericType)
+	if !ok || !sema.IsSubType(integerKeyType, sema.IntegerType) {
+		return key, nil
+	}
+
+	integerKey, ok := key.(interpreter.IntegerValue)
+	if !ok {
+		return key, nil
+	}
+
+	integerKeySemaType, err := inter.ConvertStaticToSemaType(integerKey.StaticType(inter))
+	if err != nil {
+		return nil, err
+	}
+
+	if integerKeySemaType.Equal(integerKeyType) {
+		return key, nil
+	}
+
+	bigKey := interpreter.ConvertInt(inter, integerKey).BigInt
+
+	minInt := integerKeyType.MinInt()
+	maxInt := integerKeyType.MaxInt()
+	if (minInt != nil && bigKey.Cmp(minInt) < 0) ||
+		(maxInt != nil && bigKey.Cmp(maxInt) > 0) {
+
+		return nil, errors.NewDefaultUserError(
+			"cannot import dictionary: key `%s` is out of the range of key type `%s`",
+			bigKey,
+			integerKeyType.QualifiedString(),
+		)
+	}
+
+	return inter.ConvertAndBox(
+		getLocationRange,
+		key,
+		integerKeySemaType,
+		integerKeyType,
+	), nil
+}
+
+func (im *Importer) importCompositeValue(
 	inter *interpreter.Interpreter,
 	getLocationRange func() interpreter.LocationRange,
 	kind common.CompositeKind,
@@ -1379,11 +3495,15 @@ func importCompositeValue(
 	var fields []interpreter.CompositeField
 
 	typeID := common.NewTypeIDFromQualifiedName(inter, location, qualifiedIdentifier)
-	compositeType, typeErr := inter.GetCompositeType(location, qualifiedIdentifier, typeID)
+	compositeType, typeErr := im.getCompositeType(inter, location, qualifiedIdentifier, typeID)
 	if typeErr != nil {
 		return nil, typeErr
 	}
 
+	// NOTE: the given field values are paired with the given field types of the value,
+	// and both are matched to the declared members by name, not by position.
+	// The order of the fields of the value may differ from the declaration order.
+
 	for i := 0; i < len(fieldTypes) && i < len(fieldValues); i++ {
 		fieldType := fieldTypes[i]
 		fieldValue := fieldValues[i]
@@ -1395,14 +3515,33 @@ func importCompositeValue(
 			expectedFieldType = member.TypeAnnotation.Type
 		}
 
-		importedFieldValue, err := importValue(
+		importedFieldValue, err := im.importValue(
 			inter,
 			getLocationRange,
 			fieldValue,
 			expectedFieldType,
 		)
 		if err != nil {
-			return nil, err
+			return nil, &ImportFieldError{
+				QualifiedIdentifier: qualifiedIdentifier,
+				FieldName:           fieldType.Identifier,
+				Err:                 err,
+			}
+		}
+
+		if im.strictFieldTypesEnabled && expectedFieldType != nil {
+			staticType := importedFieldValue.StaticType(inter)
+			if !inter.IsSubTypeOfSemaType(staticType, expectedFieldType) {
+				return nil, &ImportFieldError{
+					QualifiedIdentifier: qualifiedIdentifier,
+					FieldName:           fieldType.Identifier,
+					Err: errors.NewDefaultUserError(
+						"value of type `%s` does not conform to field type `%s`",
+						staticType,
+						expectedFieldType.QualifiedString(),
+					),
+				}
+			}
 		}
 
 		fields = append(fields,
@@ -1450,6 +3589,132 @@ func importCompositeValue(
 	), nil
 }
 
+// getCompositeType returns the composite type with the given type ID.
+// The type is resolved using the elaboration of the importer, if any,
+// and otherwise using the interpreter, see WithElaboration.
+// If type caching is enabled, the result is cached, keyed by the type ID.
+func (im *Importer) getCompositeType(
+	inter *interpreter.Interpreter,
+	location Location,
+	qualifiedIdentifier string,
+	typeID common.TypeID,
+) (
+	*sema.CompositeType,
+	error,
+) {
+	if !im.typeCachingEnabled {
+		im.compositeTypeLookups++
+		return im.resolveCompositeType(inter, location, qualifiedIdentifier, typeID)
+	}
+
+	compositeType, ok := im.compositeTypeCache[typeID]
+	if ok {
+		return compositeType, nil
+	}
+
+	im.compositeTypeLookups++
+	compositeType, err := im.resolveCompositeType(inter, location, qualifiedIdentifier, typeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if im.compositeTypeCache == nil {
+		im.compositeTypeCache = map[common.TypeID]*sema.CompositeType{}
+	}
+	im.compositeTypeCache[typeID] = compositeType
+
+	return compositeType, nil
+}
+
+func (im *Importer) resolveCompositeType(
+	inter *interpreter.Interpreter,
+	location Location,
+	qualifiedIdentifier string,
+	typeID common.TypeID,
+) (
+	*sema.CompositeType,
+	error,
+) {
+	if im.elaboration != nil {
+		if compositeType, ok := im.elaboration.CompositeTypes[typeID]; ok {
+			return compositeType, nil
+		}
+	}
+
+	return inter.GetCompositeType(location, qualifiedIdentifier, typeID)
+}
+
+// isEnumRawValue returns true if the given value is a bare raw value of the given enum type,
+// e.g. a UInt8 for an enum with raw type UInt8.
+func isEnumRawValue(value cadence.Value, enumType *sema.CompositeType) bool {
+	if enumType.Kind != common.CompositeKindEnum ||
+		enumType.EnumRawType == nil ||
+		value == nil {
+
+		return false
+	}
+
+	valueType := value.Type()
+	return valueType != nil &&
+		valueType.ID() == string(enumType.EnumRawType.ID())
+}
+
+// importEnumFromRawValue imports the given bare raw value as the case of the given enum type
+// with the raw value. The raw value must be the raw value of a case of the enum.
+func (im *Importer) importEnumFromRawValue(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	value cadence.Value,
+	enumType *sema.CompositeType,
+) (
+	*interpreter.CompositeValue,
+	error,
+) {
+	rawValue, err := im.importValue(inter, getLocationRange, value, enumType.EnumRawType)
+	if err != nil {
+		return nil, err
+	}
+
+	integerRawValue, ok := rawValue.(interpreter.IntegerValue)
+	if !ok {
+		return nil, errors.NewUnexpectedError("invalid enum raw value: %s", rawValue)
+	}
+
+	declaration, err := inter.GetCompositeDeclaration(enumType)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the raw values of the cases are the indices of the cases
+
+	caseCount := len(declaration.Members.EnumCases())
+
+	bigRawValue := interpreter.ConvertInt(inter, integerRawValue).BigInt
+	if bigRawValue.Sign() < 0 || bigRawValue.Cmp(big.NewInt(int64(caseCount))) >= 0 {
+		return nil, errors.NewDefaultUserError(
+			"cannot import enum `%s`: raw value `%s` is not the raw value of a case",
+			enumType.QualifiedString(),
+			bigRawValue,
+		)
+	}
+
+	return interpreter.NewCompositeValue(
+		inter,
+		getLocationRange,
+		enumType.Location,
+		enumType.QualifiedIdentifier(),
+		common.CompositeKindEnum,
+		[]interpreter.CompositeField{
+			interpreter.NewCompositeField(
+				inter,
+				sema.EnumRawValueFieldName,
+				rawValue,
+			),
+		},
+		common.Address{},
+	), nil
+}
+
 func importPublicKey(
 	inter *interpreter.Interpreter,
 	fields []interpreter.CompositeField,
@@ -1618,3 +3883,93 @@ func importSignatureAlgorithm(
 
 	return stdlib.NewSignatureAlgorithmCase(inter, uint8(rawValue)), nil
 }
+
+// EstimateImportMemory returns the memory the import of the given value would use,
+// i.e. the sum of the amounts of the memory usages that the import meters.
+//
+// The value is imported into a conversion interpreter, see NewConversionInterpreter,
+// so the estimate is the memory metered by the import itself.
+// The types of composite values are resolved using the given type resolvers.
+func EstimateImportMemory(
+	value cadence.Value,
+	expectedType sema.Type,
+	typeResolvers ...ConversionTypeResolver,
+) (uint64, error) {
+	var counter importMemoryCounter
+
+	inter, err := NewConversionInterpreter(&counter, typeResolvers...)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = importValue(inter, interpreter.ReturnEmptyLocationRange, value, expectedType)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(counter), nil
+}
+
+// importMemoryCounter is a memory gauge which sums the amounts of the metered memory usages.
+type importMemoryCounter uint64
+
+var _ common.MemoryGauge = new(importMemoryCounter)
+
+func (c *importMemoryCounter) MeterMemory(usage common.MemoryUsage) error {
+	*c += importMemoryCounter(usage.Amount)
+	return nil
+}
+
+// CountImportNodes returns the number of values the import of the given value would create,
+// without constructing any interpreter values, e.g. to reject oversized arguments before they are imported.
+//
+// Each value is a node, i.e. scalars, optionals, and containers, and the elements of arrays,
+// the keys and values of dictionaries, and the fields of composites are counted recursively.
+// Bytes are imported as an array of UInt8 values, so each byte is a node.
+func CountImportNodes(value cadence.Value) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+
+	case cadence.Optional:
+		return 1 + CountImportNodes(v.Value)
+
+	case cadence.Bytes:
+		return 1 + len(v)
+
+	case cadence.Array:
+		count := 1
+		for _, element := range v.Values {
+			count += CountImportNodes(element)
+		}
+		return count
+
+	case cadence.Dictionary:
+		count := 1
+		for _, pair := range v.Pairs {
+			count += CountImportNodes(pair.Key)
+			count += CountImportNodes(pair.Value)
+		}
+		return count
+
+	case cadence.Struct:
+		return countImportCompositeNodes(v.Fields)
+	case cadence.Resource:
+		return countImportCompositeNodes(v.Fields)
+	case cadence.Event:
+		return countImportCompositeNodes(v.Fields)
+	case cadence.Enum:
+		return countImportCompositeNodes(v.Fields)
+
+	default:
+		return 1
+	}
+}
+
+func countImportCompositeNodes(fields []cadence.Value) int {
+	count := 1
+	for _, field := range fields {
+		count += CountImportNodes(field)
+	}
+	return count
+}
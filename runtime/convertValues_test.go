@@ -21,7 +21,10 @@ package runtime
 import (
 	_ "embed"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
@@ -2961,7 +2964,7 @@ func TestRuntimeMalformedArgumentPassing(t *testing.T) {
 			exportedValue: cadence.NewArray([]cadence.Value{
 				cadence.NewInt(1),
 			}),
-			expectedInvalidEntryPointArgumentErrType: &MalformedValueError{},
+			expectedInvalidEntryPointArgumentErrType: errors.DefaultUserError{},
 		},
 		{
 			label:         "Constant-size array with too many elements",
@@ -2971,7 +2974,7 @@ func TestRuntimeMalformedArgumentPassing(t *testing.T) {
 				cadence.NewInt(2),
 				cadence.NewInt(3),
 			}),
-			expectedInvalidEntryPointArgumentErrType: &MalformedValueError{},
+			expectedInvalidEntryPointArgumentErrType: errors.DefaultUserError{},
 		},
 		{
 			label:         "Nested array with mismatching element",
@@ -3080,6 +3083,134 @@ func TestRuntimeMalformedArgumentPassing(t *testing.T) {
 	}
 }
 
+func TestImportValueWithExpectedType(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: Int
+
+          init(a: Int) {
+              self.a = a
+          }
+      }
+
+      pub enum E: UInt8 {
+          pub case a
+          pub case b
+      }
+
+      pub fun test(): S {
+          return S(a: 1)
+      }
+    `
+
+	newInterpreter := func(t *testing.T) *interpreter.Interpreter {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		return inter
+	}
+
+	compositeType := func(inter *interpreter.Interpreter, identifier string) *sema.CompositeType {
+		typeID := TestLocation.TypeID(nil, identifier)
+		return inter.Program.Elaboration.CompositeTypes[typeID]
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		exported, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		imported, err := ImportValue(inter, exported, compositeType(inter, "S"))
+		require.NoError(t, err)
+
+		AssertValuesEqual(t, inter, value, imported)
+	})
+
+	t.Run("enum raw value", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		imported, err := ImportValue(inter, cadence.NewUInt8(1), compositeType(inter, "E"))
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.CompositeValue{}, imported)
+		composite := imported.(*interpreter.CompositeValue)
+		assert.Equal(t, TestLocation.TypeID(nil, "E"), composite.TypeID())
+		assert.Equal(t,
+			interpreter.UInt8Value(1),
+			composite.GetField(inter, interpreter.ReturnEmptyLocationRange, sema.EnumRawValueFieldName),
+		)
+	})
+
+	t.Run("mismatched type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewInt(1),
+		}).WithType(&cadence.StructType{
+			Location:            TestLocation,
+			QualifiedIdentifier: "S",
+			Fields: []cadence.Field{
+				{
+					Identifier: "a",
+					Type:       cadence.IntType{},
+				},
+			},
+		})
+
+		_, err := ImportValue(inter, value, sema.StringType)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t,
+			err,
+			"cannot import value of type `S.test.S`: expected value of type `String`",
+		)
+	})
+
+	t.Run("unresolvable composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value := cadence.NewStruct(nil).WithType(&cadence.StructType{
+			Location:            TestLocation,
+			QualifiedIdentifier: "Unknown",
+		})
+
+		_, expectedErr := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.ErrorAs(t, expectedErr, &interpreter.TypeLoadingError{})
+
+		_, err := ImportValue(inter, value, sema.AnyStructType)
+		require.Error(t, err)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
 func TestRuntimeImportExportArrayValue(t *testing.T) {
 
 	t.Parallel()
@@ -3575,6 +3706,9 @@ func TestRuntimeImportExportDictionaryValue(t *testing.T) {
 		require.ErrorAs(t, err, &argErr)
 
 		assert.Contains(t, argErr.Error(), "cannot import dictionary: keys does not belong to the same type")
+
+		var importErr *HeterogeneousDictionaryImportError
+		require.ErrorAs(t, err, &importErr)
 	})
 
 	t.Run("nested dictionary with mismatching element", func(t *testing.T) {
@@ -5079,3 +5213,5247 @@ func TestNestedStructArgPassing(t *testing.T) {
 		require.ErrorAs(t, err, &argErr)
 	})
 }
+
+func TestExportMaxCollectionSize(t *testing.T) {
+
+	t.Parallel()
+
+	newArray := func(inter *interpreter.Interpreter, count int) *interpreter.ArrayValue {
+		values := make([]interpreter.Value, count)
+		for i := 0; i < count; i++ {
+			values[i] = interpreter.NewUnmeteredIntValueFromInt64(int64(i))
+		}
+
+		return interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			common.Address{},
+			values...,
+		)
+	}
+
+	newDictionary := func(inter *interpreter.Interpreter, count int) *interpreter.DictionaryValue {
+		keysAndValues := make([]interpreter.Value, 0, count*2)
+		for i := 0; i < count; i++ {
+			keysAndValues = append(
+				keysAndValues,
+				interpreter.NewUnmeteredIntValueFromInt64(int64(i)),
+				interpreter.BoolValue(true),
+			)
+		}
+
+		return interpreter.NewDictionaryValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.DictionaryStaticType{
+				KeyType:   interpreter.PrimitiveStaticTypeInt,
+				ValueType: interpreter.PrimitiveStaticTypeBool,
+			},
+			keysAndValues...,
+		)
+	}
+
+	const maxSize = 3
+
+	t.Run("array at limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		actual, err := NewExporter(WithMaxCollectionSize(maxSize)).
+			ExportValue(
+				newArray(inter, maxSize),
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Array{}, actual)
+		assert.Len(t, actual.(cadence.Array).Values, maxSize)
+	})
+
+	t.Run("array over limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := NewExporter(WithMaxCollectionSize(maxSize)).
+			ExportValue(
+				newArray(inter, maxSize+1),
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.Error(t, err)
+		assertUserError(t, err)
+	})
+
+	t.Run("dictionary at limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		actual, err := NewExporter(WithMaxCollectionSize(maxSize)).
+			ExportValue(
+				newDictionary(inter, maxSize),
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Dictionary{}, actual)
+		assert.Len(t, actual.(cadence.Dictionary).Pairs, maxSize)
+	})
+
+	t.Run("dictionary over limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := NewExporter(WithMaxCollectionSize(maxSize)).
+			ExportValue(
+				newDictionary(inter, maxSize+1),
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.Error(t, err)
+		assertUserError(t, err)
+	})
+
+	t.Run("nested over limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.VariableSizedStaticType{
+					Type: interpreter.PrimitiveStaticTypeInt,
+				},
+			},
+			common.Address{},
+			newArray(inter, maxSize+1),
+		)
+
+		_, err := NewExporter(WithMaxCollectionSize(maxSize)).
+			ExportValue(
+				value,
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.Error(t, err)
+		assertUserError(t, err)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := NewExporter().
+			ExportValue(
+				newArray(inter, maxSize+1),
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+		require.NoError(t, err)
+	})
+}
+
+func newTestInterpreterWithProgram(tb testing.TB, code string) *interpreter.Interpreter {
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(tb, err)
+
+	checker, err := sema.NewChecker(program, TestLocation, nil, false)
+	require.NoError(tb, err)
+
+	err = checker.Check()
+	require.NoError(tb, err)
+
+	inter := newTestInterpreter(tb)
+	inter.Program = interpreter.ProgramFromChecker(checker)
+
+	return inter
+}
+
+func TestExporterTypeCache(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let x: Int
+
+          init() {
+              self.x = 1
+          }
+      }
+    `
+
+	test := func(t *testing.T, exporter *Exporter) (
+		export func() *cadence.StructType,
+	) {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		return func() *cadence.StructType {
+			value := interpreter.NewCompositeValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				TestLocation,
+				"S",
+				common.CompositeKindStructure,
+				[]interpreter.CompositeField{
+					{
+						Name:  "x",
+						Value: interpreter.NewUnmeteredIntValueFromInt64(1),
+					},
+				},
+				common.Address{},
+			)
+
+			actual, err := exporter.ExportValue(
+				value,
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+			)
+			require.NoError(t, err)
+
+			return actual.(cadence.Struct).StructType
+		}
+	}
+
+	t.Run("caching disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		export := test(t, NewExporter())
+
+		assert.NotSame(t, export(), export())
+	})
+
+	t.Run("invalidate type", func(t *testing.T) {
+
+		t.Parallel()
+
+		exporter := NewExporter(WithTypeCaching(true))
+		export := test(t, exporter)
+
+		first := export()
+		assert.Same(t, first, export())
+
+		exporter.InvalidateType("S.test.S")
+
+		second := export()
+		assert.NotSame(t, first, second)
+		assert.Equal(t, first, second)
+
+		assert.Same(t, second, export())
+	})
+
+	t.Run("clear cache", func(t *testing.T) {
+
+		t.Parallel()
+
+		exporter := NewExporter(WithTypeCaching(true))
+		export := test(t, exporter)
+
+		first := export()
+
+		exporter.ClearCache()
+
+		second := export()
+		assert.NotSame(t, first, second)
+		assert.Equal(t, first, second)
+	})
+}
+
+func TestImportCompositeValueWithReorderedFields(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct Foo {
+          pub let a: Int
+          pub let b: String
+
+          init(a: Int, b: String) {
+              self.a = a
+              self.b = b
+          }
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	value := cadence.NewStruct([]cadence.Value{
+		cadence.String("foo"),
+		cadence.NewInt(42),
+	}).WithType(&cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []cadence.Field{
+			{
+				Identifier: "b",
+				Type:       cadence.StringType{},
+			},
+			{
+				Identifier: "a",
+				Type:       cadence.IntType{},
+			},
+		},
+	})
+
+	actual, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		nil,
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.CompositeValue{}, actual)
+	composite := actual.(*interpreter.CompositeValue)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUnmeteredIntValueFromInt64(42),
+		composite.GetField(inter, interpreter.ReturnEmptyLocationRange, "a"),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUnmeteredStringValue("foo"),
+		composite.GetField(inter, interpreter.ReturnEmptyLocationRange, "b"),
+	)
+
+	// Exporting uses the declaration order
+
+	exported, err := exportValueWithInterpreter(
+		composite,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]cadence.Value{
+			cadence.NewInt(42),
+			cadence.String("foo"),
+		},
+		exported.(cadence.Struct).Fields,
+	)
+}
+
+func TestExportValueWithTypes(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct interface I {}
+
+      pub struct S: I {}
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	value := interpreter.NewCompositeValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		TestLocation,
+		"S",
+		common.CompositeKindStructure,
+		nil,
+		common.Address{},
+	)
+
+	structType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+		Fields:              []cadence.Field{},
+	}
+
+	t.Run("composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		exported, dynamicType, staticType, err := ExportValueWithTypes(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewStruct([]cadence.Value{}).WithType(structType),
+			exported,
+		)
+		assert.Equal(t, structType, dynamicType)
+		assert.Equal(t, structType, staticType)
+	})
+
+	t.Run("reference with restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		interfaceType := inter.Program.Elaboration.InterfaceTypes["S.test.I"]
+		require.NotNil(t, interfaceType)
+
+		reference := interpreter.NewUnmeteredEphemeralReferenceValue(
+			false,
+			value,
+			&sema.RestrictedType{
+				Type:         sema.AnyStructType,
+				Restrictions: []*sema.InterfaceType{interfaceType},
+			},
+		)
+
+		exported, dynamicType, staticType, err := ExportValueWithTypes(
+			reference,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewStruct([]cadence.Value{}).WithType(structType),
+			exported,
+		)
+		assert.Equal(t, structType, dynamicType)
+		assert.Equal(t,
+			cadence.ReferenceType{
+				Type: (&cadence.RestrictedType{
+					Type: cadence.AnyStructType{},
+					Restrictions: []cadence.Type{
+						&cadence.StructInterfaceType{
+							Location:            TestLocation,
+							QualifiedIdentifier: "I",
+							Fields:              []cadence.Field{},
+						},
+					},
+				}).WithID("AnyStruct{S.test.I}"),
+			},
+			staticType,
+		)
+	})
+}
+
+func TestRuntimeImportExportVoidValue(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	imported, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		cadence.NewVoid(),
+		sema.VoidType,
+	)
+	require.NoError(t, err)
+
+	AssertValuesEqual(t, inter, interpreter.VoidValue{}, imported)
+
+	exported, err := exportValueWithInterpreter(
+		imported,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, cadence.NewVoid(), exported)
+}
+
+func TestExportCompositeCycleDetection(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub var ref: AnyStruct?
+
+          init() {
+              self.ref = nil
+          }
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	address := common.MustBytesToAddress([]byte{0x1})
+
+	value := interpreter.NewCompositeValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		TestLocation,
+		"S",
+		common.CompositeKindStructure,
+		[]interpreter.CompositeField{
+			{
+				Name:  "ref",
+				Value: interpreter.NilValue{},
+			},
+		},
+		address,
+	)
+
+	storageMap := inter.Storage.GetStorageMap(address, common.PathDomainStorage.Identifier(), true)
+	storageMap.WriteValue(inter, "s", value)
+
+	// Let the stored composite reference itself through a storage reference
+
+	stored := inter.ReadStored(address, common.PathDomainStorage.Identifier(), "s").(*interpreter.CompositeValue)
+
+	stored.SetMember(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		"ref",
+		interpreter.NewSomeValueNonCopying(
+			inter,
+			&interpreter.StorageReferenceValue{
+				TargetStorageAddress: address,
+				TargetPath: interpreter.PathValue{
+					Domain:     common.PathDomainStorage,
+					Identifier: "s",
+				},
+				BorrowedType: sema.AnyStructType,
+			},
+		),
+	)
+
+	require.IsType(t,
+		&interpreter.SomeValue{},
+		stored.GetField(inter, interpreter.ReturnEmptyLocationRange, "ref"),
+	)
+
+	actual, err := NewExporter(WithCompositeCycleDetection(true)).
+		ExportValue(
+			stored,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+		)
+	require.NoError(t, err)
+
+	structType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "ref",
+				Type: cadence.OptionalType{
+					Type: cadence.AnyStructType{},
+				},
+			},
+		},
+	}
+
+	// The revisited composite is exported as a placeholder
+
+	assert.Equal(t,
+		cadence.NewStruct([]cadence.Value{
+			cadence.NewOptional(nil),
+		}).WithType(structType),
+		actual,
+	)
+}
+
+func TestImportArrayFrom(t *testing.T) {
+
+	t.Parallel()
+
+	const count = 10_000
+
+	newGenerator := func() func() (cadence.Value, bool, error) {
+		var index int
+		return func() (cadence.Value, bool, error) {
+			if index >= count {
+				return nil, false, nil
+			}
+			value := cadence.NewInt(index)
+			index++
+			return value, true, nil
+		}
+	}
+
+	test := func(t *testing.T, expectedType sema.Type) {
+
+		inter := newTestInterpreter(t)
+
+		actual, err := ImportArrayFrom(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			expectedType,
+			newGenerator(),
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, count, actual.Count())
+		assert.Equal(t,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			actual.Type,
+		)
+
+		for i := 0; i < count; i++ {
+			AssertValuesEqual(
+				t,
+				inter,
+				interpreter.NewUnmeteredIntValueFromInt64(int64(i)),
+				actual.Get(inter, interpreter.ReturnEmptyLocationRange, i),
+			)
+		}
+	}
+
+	t.Run("expected type", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, &sema.VariableSizedType{
+			Type: sema.IntType,
+		})
+	})
+
+	t.Run("inferred type", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, nil)
+	})
+
+	t.Run("generator error", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		generatorErr := fmt.Errorf("generator failed")
+
+		_, err := ImportArrayFrom(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			nil,
+			func() (cadence.Value, bool, error) {
+				return nil, false, generatorErr
+			},
+		)
+		require.ErrorIs(t, err, generatorErr)
+	})
+}
+
+func newTestNestedArrayValue(inter *interpreter.Interpreter, count int) *interpreter.ArrayValue {
+	elementType := interpreter.VariableSizedStaticType{
+		Type: interpreter.PrimitiveStaticTypeInt,
+	}
+
+	elements := make([]interpreter.Value, count)
+	for i := range elements {
+		elements[i] = interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			elementType,
+			common.Address{},
+			interpreter.NewUnmeteredIntValueFromInt64(int64(i)),
+		)
+	}
+
+	return interpreter.NewArrayValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.VariableSizedStaticType{
+			Type: elementType,
+		},
+		common.Address{},
+		elements...,
+	)
+}
+
+func TestExporterSemaTypeCache(t *testing.T) {
+
+	t.Parallel()
+
+	const count = 10
+
+	test := func(t *testing.T, typeCaching bool, expectedConversions int) {
+
+		inter := newTestInterpreter(t)
+
+		value := newTestNestedArrayValue(inter, count)
+
+		exporter := NewExporter(WithTypeCaching(typeCaching))
+
+		for i := 0; i < 2; i++ {
+			_, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, expectedConversions, exporter.semaTypeConversions)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		// outer array and all inner arrays, for each export
+		test(t, false, 2*(count+1))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		// outer array type and inner array type, once
+		test(t, true, 2)
+	})
+}
+
+func BenchmarkExporterSemaTypeCache(b *testing.B) {
+
+	inter := newTestInterpreter(b)
+
+	value := newTestNestedArrayValue(inter, 100)
+
+	for _, typeCaching := range []bool{false, true} {
+
+		b.Run(fmt.Sprintf("type caching %v", typeCaching), func(b *testing.B) {
+
+			exporter := NewExporter(WithTypeCaching(typeCaching))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+				require.NoError(b, err)
+			}
+
+			b.ReportMetric(float64(exporter.semaTypeConversions)/float64(b.N), "conversions/op")
+		})
+	}
+}
+
+func TestImportStrictOptionalDepth(t *testing.T) {
+
+	t.Parallel()
+
+	intOptionalType := &sema.OptionalType{
+		Type: sema.IntType,
+	}
+
+	intDoubleOptionalType := &sema.OptionalType{
+		Type: intOptionalType,
+	}
+
+	type testCase struct {
+		label        string
+		value        cadence.Value
+		expectedType sema.Type
+		valid        bool
+	}
+
+	testCases := []testCase{
+		{
+			label:        "Int? as Int?",
+			value:        cadence.NewOptional(cadence.NewInt(1)),
+			expectedType: intOptionalType,
+			valid:        true,
+		},
+		{
+			label:        "Int?? as Int??",
+			value:        cadence.NewOptional(cadence.NewOptional(cadence.NewInt(1))),
+			expectedType: intDoubleOptionalType,
+			valid:        true,
+		},
+		{
+			label:        "nil as Int??",
+			value:        cadence.NewOptional(nil),
+			expectedType: intDoubleOptionalType,
+			valid:        true,
+		},
+		{
+			label:        "Int?? as AnyStruct",
+			value:        cadence.NewOptional(cadence.NewOptional(cadence.NewInt(1))),
+			expectedType: sema.AnyStructType,
+			valid:        true,
+		},
+		{
+			label: "[Int?] as [Int?]",
+			value: cadence.NewArray([]cadence.Value{
+				cadence.NewOptional(cadence.NewInt(1)),
+			}),
+			expectedType: &sema.VariableSizedType{
+				Type: intOptionalType,
+			},
+			valid: true,
+		},
+		{
+			label:        "Int? as Int??",
+			value:        cadence.NewOptional(cadence.NewInt(1)),
+			expectedType: intDoubleOptionalType,
+			valid:        false,
+		},
+		{
+			label:        "Int as Int?",
+			value:        cadence.NewInt(1),
+			expectedType: intOptionalType,
+			valid:        false,
+		},
+		{
+			label:        "Int?? as Int?",
+			value:        cadence.NewOptional(cadence.NewOptional(cadence.NewInt(1))),
+			expectedType: intOptionalType,
+			valid:        false,
+		},
+		{
+			label:        "nil as Int",
+			value:        cadence.NewOptional(nil),
+			expectedType: sema.IntType,
+			valid:        false,
+		},
+		{
+			label: "[Int] as [Int?]",
+			value: cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+			}),
+			expectedType: &sema.VariableSizedType{
+				Type: intOptionalType,
+			},
+			valid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := newTestInterpreter(t)
+
+			// Without the option, all values are imported
+
+			_, err := NewImporter().ImportValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				testCase.value,
+				testCase.expectedType,
+			)
+			require.NoError(t, err)
+
+			_, err = NewImporter(WithStrictOptionalDepth(true)).ImportValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				testCase.value,
+				testCase.expectedType,
+			)
+			if testCase.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assertUserError(t, err)
+			}
+		})
+	}
+}
+
+func TestImportDictionaryValueWithInvalidExpectedKeyType(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	value := cadence.NewDictionary([]cadence.KeyValuePair{
+		{
+			Key:   cadence.String("foo"),
+			Value: cadence.NewInt(1),
+		},
+	})
+
+	// Valid key type
+
+	_, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		&sema.DictionaryType{
+			KeyType:   sema.StringType,
+			ValueType: sema.IntType,
+		},
+	)
+	require.NoError(t, err)
+
+	// Invalid key type
+
+	_, err = importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		&sema.DictionaryType{
+			KeyType:   sema.AnyStructType,
+			ValueType: sema.IntType,
+		},
+	)
+	require.Error(t, err)
+	assertUserError(t, err)
+
+	assert.Contains(t, err.Error(), "cannot import dictionary: invalid key type `AnyStruct`")
+}
+
+func TestExportDictionaryValueWithAnyStructKeyType(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	value := interpreter.NewDictionaryValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.DictionaryStaticType{
+			KeyType:   interpreter.PrimitiveStaticTypeAnyStruct,
+			ValueType: interpreter.PrimitiveStaticTypeInt,
+		},
+		interpreter.NewUnmeteredIntValueFromInt64(1),
+		interpreter.NewUnmeteredIntValueFromInt64(2),
+		interpreter.NewUnmeteredStringValue("foo"),
+		interpreter.NewUnmeteredIntValueFromInt64(3),
+	)
+
+	actual, err := exportValueWithInterpreter(
+		value,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, cadence.Dictionary{}, actual)
+	dictionary := actual.(cadence.Dictionary)
+
+	assert.Equal(t,
+		cadence.DictionaryType{
+			KeyType:     cadence.AnyStructType{},
+			ElementType: cadence.IntType{},
+		},
+		dictionary.Type(),
+	)
+
+	pairs := map[string]cadence.KeyValuePair{}
+	for _, pair := range dictionary.Pairs {
+		pairs[pair.Key.Type().ID()] = pair
+	}
+
+	assert.Equal(t,
+		map[string]cadence.KeyValuePair{
+			"Int": {
+				Key:   cadence.NewInt(1),
+				Value: cadence.NewInt(2),
+			},
+			"String": {
+				Key:   cadence.String("foo"),
+				Value: cadence.NewInt(3),
+			},
+		},
+		pairs,
+	)
+}
+
+func TestExportCollectAllErrors(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	newIntArray := func(count int) interpreter.Value {
+		values := make([]interpreter.Value, count)
+		for i := range values {
+			values[i] = interpreter.NewUnmeteredIntValueFromInt64(int64(i))
+		}
+		return interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			common.Address{},
+			values...,
+		)
+	}
+
+	newAnyStructArray := func(values ...interpreter.Value) interpreter.Value {
+		return interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeAnyStruct,
+			},
+			common.Address{},
+			values...,
+		)
+	}
+
+	// The arrays with 4, 5, and 6 elements exceed the maximum collection size.
+	// The array with 5 elements is nested in the second branch
+
+	value := newAnyStructArray(
+		newIntArray(4),
+		newAnyStructArray(
+			newIntArray(1),
+			newIntArray(5),
+		),
+		interpreter.NewDictionaryValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.DictionaryStaticType{
+				KeyType:   interpreter.PrimitiveStaticTypeString,
+				ValueType: interpreter.PrimitiveStaticTypeAnyStruct,
+			},
+			interpreter.NewUnmeteredStringValue("a"),
+			newIntArray(6),
+		),
+	)
+
+	const maxCollectionSize = 3
+
+	t.Run("first error", func(t *testing.T) {
+
+		exporter := NewExporter(
+			WithMaxCollectionSize(maxCollectionSize),
+		)
+
+		_, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.Contains(t, err.Error(), "element count 4")
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+
+		exporter := NewExporter(
+			WithMaxCollectionSize(maxCollectionSize),
+			WithCollectAllErrors(true),
+		)
+
+		// The order of the errors must be stable across exports
+
+		for i := 0; i < 10; i++ {
+
+			_, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.Error(t, err)
+			assertUserError(t, err)
+
+			var exportErrors *ExportErrors
+			require.ErrorAs(t, err, &exportErrors)
+
+			require.Len(t, exportErrors.Errors, 3)
+			assert.Contains(t, exportErrors.Errors[0].Error(), "element count 4")
+			assert.Contains(t, exportErrors.Errors[1].Error(), "element count 5")
+			assert.Contains(t, exportErrors.Errors[2].Error(), "element count 6")
+		}
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+
+		exporter := NewExporter(
+			WithCollectAllErrors(true),
+		)
+
+		exported, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+		require.IsType(t, cadence.Array{}, exported)
+	})
+}
+
+func TestImportDictionaryValueWithAddressKeys(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	shortAddress := cadence.BytesToAddress([]byte{0x1})
+	paddedAddress := cadence.BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0x1})
+
+	value := cadence.NewDictionary([]cadence.KeyValuePair{
+		{
+			Key:   shortAddress,
+			Value: cadence.NewInt(1),
+		},
+		{
+			Key:   paddedAddress,
+			Value: cadence.NewInt(2),
+		},
+	})
+
+	actual, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		&sema.DictionaryType{
+			KeyType:   &sema.AddressType{},
+			ValueType: sema.IntType,
+		},
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.DictionaryValue{}, actual)
+	dictionary := actual.(*interpreter.DictionaryValue)
+
+	// Both forms of the address are the same key, the last value wins
+
+	require.Equal(t, 1, dictionary.Count())
+
+	existing, ok := dictionary.Get(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.NewUnmeteredAddressValueFromBytes([]byte{0x1}),
+	)
+	require.True(t, ok)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUnmeteredIntValueFromInt64(2),
+		existing,
+	)
+}
+
+func TestExportFieldNameMapper(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct Inner {
+          pub let someValue: String
+
+          init() {
+              self.someValue = "foo"
+          }
+      }
+
+      pub struct Outer {
+          pub let fooBar: Int
+          pub let inner: Inner
+          pub let innerItems: [Inner]
+
+          init() {
+              self.fooBar = 1
+              self.inner = Inner()
+              self.innerItems = [Inner()]
+          }
+      }
+    `
+
+	toSnakeCase := func(name string) string {
+		var sb strings.Builder
+		for _, r := range name {
+			if unicode.IsUpper(r) {
+				sb.WriteRune('_')
+				r = unicode.ToLower(r)
+			}
+			sb.WriteRune(r)
+		}
+		return sb.String()
+	}
+
+	fieldNames := func(t cadence.CompositeType) []string {
+		fields := t.CompositeFields()
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.Identifier
+		}
+		return names
+	}
+
+	test := func(t *testing.T, typeCaching bool) {
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		newInner := func() interpreter.Value {
+			return interpreter.NewCompositeValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				TestLocation,
+				"Inner",
+				common.CompositeKindStructure,
+				[]interpreter.CompositeField{
+					{
+						Name:  "someValue",
+						Value: interpreter.NewUnmeteredStringValue("foo"),
+					},
+				},
+				common.Address{},
+			)
+		}
+
+		value := interpreter.NewCompositeValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			TestLocation,
+			"Outer",
+			common.CompositeKindStructure,
+			[]interpreter.CompositeField{
+				{
+					Name:  "fooBar",
+					Value: interpreter.NewUnmeteredIntValueFromInt64(1),
+				},
+				{
+					Name:  "inner",
+					Value: newInner(),
+				},
+				{
+					Name: "innerItems",
+					Value: interpreter.NewArrayValue(
+						inter,
+						interpreter.ReturnEmptyLocationRange,
+						interpreter.VariableSizedStaticType{
+							Type: interpreter.CompositeStaticType{
+								Location:            TestLocation,
+								QualifiedIdentifier: "Inner",
+								TypeID:              TestLocation.TypeID(nil, "Inner"),
+							},
+						},
+						common.Address{},
+						newInner(),
+					),
+				},
+			},
+			common.Address{},
+		)
+
+		exporter := NewExporter(
+			WithFieldNameMapper(toSnakeCase),
+			WithTypeCaching(typeCaching),
+		)
+
+		// Export multiple times, to ensure cached types are not mapped multiple times
+
+		for i := 0; i < 2; i++ {
+
+			actual, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			require.IsType(t, cadence.Struct{}, actual)
+			outer := actual.(cadence.Struct)
+
+			assert.Equal(t,
+				[]string{"foo_bar", "inner", "inner_items"},
+				fieldNames(outer.StructType),
+			)
+
+			// Field values are in the same order as the type's fields
+
+			require.Len(t, outer.Fields, 3)
+			assert.Equal(t, cadence.NewInt(1), outer.Fields[0])
+
+			// Nested composite value
+
+			inner := outer.Fields[1].(cadence.Struct)
+			assert.Equal(t, []string{"some_value"}, fieldNames(inner.StructType))
+			assert.Equal(t, []cadence.Value{cadence.String("foo")}, inner.Fields)
+
+			// Field type of the outer type
+
+			assert.Equal(t,
+				[]string{"some_value"},
+				fieldNames(outer.StructType.Fields[1].Type.(*cadence.StructType)),
+			)
+
+			// Element type of the array, and array element value
+
+			items := outer.Fields[2].(cadence.Array)
+			assert.Equal(t,
+				[]string{"some_value"},
+				fieldNames(items.ArrayType.(cadence.VariableSizedArrayType).ElementType.(*cadence.StructType)),
+			)
+			assert.Equal(t,
+				[]string{"some_value"},
+				fieldNames(items.Values[0].(cadence.Struct).StructType),
+			)
+		}
+	}
+
+	t.Run("without type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, false)
+	})
+
+	t.Run("with type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, true)
+	})
+}
+
+func TestExportOnlyPublicFields(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct Inner {
+          pub let a: Int
+          priv let b: Int
+
+          init() {
+              self.a = 1
+              self.b = 2
+          }
+      }
+
+      pub struct Outer {
+          pub(set) var c: Int
+          access(contract) let d: Int
+          pub let inner: Inner
+          access(self) let e: Inner
+          pub let items: [Inner]
+
+          init() {
+              self.c = 3
+              self.d = 4
+              self.inner = Inner()
+              self.e = Inner()
+              self.items = [Inner()]
+          }
+      }
+
+      pub fun main(): Outer {
+          return Outer()
+      }
+    `
+
+	fieldNames := func(t cadence.CompositeType) []string {
+		fields := t.CompositeFields()
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.Identifier
+		}
+		return names
+	}
+
+	test := func(t *testing.T, typeCaching bool) {
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		value, err := inter.Invoke("main")
+		require.NoError(t, err)
+
+		// By default, all fields are exported
+
+		actual, err := NewExporter().ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]string{"c", "d", "inner", "e", "items"},
+			fieldNames(actual.(cadence.Struct).StructType),
+		)
+
+		exporter := NewExporter(
+			WithOnlyPublicFields(true),
+			WithTypeCaching(typeCaching),
+		)
+
+		// Export multiple times, to ensure cached types are not filtered multiple times
+
+		for i := 0; i < 2; i++ {
+
+			actual, err := exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			require.IsType(t, cadence.Struct{}, actual)
+			outer := actual.(cadence.Struct)
+
+			assert.Equal(t,
+				[]string{"c", "inner", "items"},
+				fieldNames(outer.StructType),
+			)
+
+			// Field values are in the same order as the type's fields
+
+			require.Len(t, outer.Fields, 3)
+			assert.Equal(t, cadence.NewInt(3), outer.Fields[0])
+
+			// Nested composite value
+
+			inner := outer.Fields[1].(cadence.Struct)
+			assert.Equal(t, []string{"a"}, fieldNames(inner.StructType))
+			assert.Equal(t, []cadence.Value{cadence.NewInt(1)}, inner.Fields)
+
+			// Field type of the outer type
+
+			assert.Equal(t,
+				[]string{"a"},
+				fieldNames(outer.StructType.Fields[1].Type.(*cadence.StructType)),
+			)
+
+			// Element type of the array, and array element value
+
+			items := outer.Fields[2].(cadence.Array)
+			assert.Equal(t,
+				[]string{"a"},
+				fieldNames(items.ArrayType.(cadence.VariableSizedArrayType).ElementType.(*cadence.StructType)),
+			)
+			assert.Equal(t,
+				[]string{"a"},
+				fieldNames(items.Values[0].(cadence.Struct).StructType),
+			)
+		}
+	}
+
+	t.Run("without type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, false)
+	})
+
+	t.Run("with type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, true)
+	})
+}
+
+func TestExportResourceOwner(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub resource R {}
+    `
+
+	owner := common.MustBytesToAddress([]byte{0x1})
+
+	test := func(t *testing.T, address common.Address, includeOwner bool) cadence.Resource {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		value := interpreter.NewCompositeValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			TestLocation,
+			"R",
+			common.CompositeKindResource,
+			[]interpreter.CompositeField{
+				{
+					Name:  sema.ResourceUUIDFieldName,
+					Value: interpreter.NewUnmeteredUInt64Value(0),
+				},
+			},
+			address,
+		)
+
+		actual, err := NewExporter(WithIncludeOwner(includeOwner)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Resource{}, actual)
+		return actual.(cadence.Resource)
+	}
+
+	t.Run("owned, included", func(t *testing.T) {
+
+		t.Parallel()
+
+		resource := test(t, owner, true)
+
+		require.NotNil(t, resource.Owner)
+		assert.Equal(t, cadence.Address(owner), *resource.Owner)
+	})
+
+	t.Run("owned, not included", func(t *testing.T) {
+
+		t.Parallel()
+
+		resource := test(t, owner, false)
+
+		assert.Nil(t, resource.Owner)
+	})
+
+	t.Run("not owned, included", func(t *testing.T) {
+
+		t.Parallel()
+
+		resource := test(t, common.Address{}, true)
+
+		assert.Nil(t, resource.Owner)
+	})
+}
+
+func TestEstimateImportMemory(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: [Int?]
+          pub let b: {String: UInt8}
+
+          init(a: [Int?], b: {String: UInt8}) {
+              self.a = a
+              self.b = b
+          }
+      }
+    `
+
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(t, err)
+
+	checker, err := sema.NewChecker(program, TestLocation, nil, false)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	require.NoError(t, err)
+
+	resolveType := func(location common.Location) *sema.Elaboration {
+		if location != TestLocation {
+			return nil
+		}
+		return checker.Elaboration
+	}
+
+	importMemory := func(t *testing.T, value cadence.Value, expectedType sema.Type) uint64 {
+		meter := newTestMemoryGauge()
+
+		inter, err := interpreter.NewInterpreter(
+			interpreter.ProgramFromChecker(checker),
+			TestLocation,
+			interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+			interpreter.WithMemoryGauge(meter),
+		)
+		require.NoError(t, err)
+
+		_, err = importValue(inter, interpreter.ReturnEmptyLocationRange, value, expectedType)
+		require.NoError(t, err)
+
+		var total uint64
+		for _, amount := range meter.meter {
+			total += amount
+		}
+		return total
+	}
+
+	nestedValue := cadence.NewArray([]cadence.Value{
+		cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key: cadence.String("a"),
+				Value: cadence.NewArray([]cadence.Value{
+					cadence.NewOptional(cadence.NewInt(1)),
+					cadence.NewOptional(nil),
+				}),
+			},
+			{
+				Key: cadence.String("b"),
+				Value: cadence.NewArray([]cadence.Value{
+					cadence.NewOptional(cadence.NewInt(2)),
+				}),
+			},
+		}),
+		cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key: cadence.String("c"),
+				Value: cadence.NewArray([]cadence.Value{
+					cadence.NewOptional(cadence.NewInt(3)),
+				}),
+			},
+		}),
+	})
+
+	compositeValue := cadence.NewStruct([]cadence.Value{
+		cadence.NewArray([]cadence.Value{
+			cadence.NewOptional(cadence.NewInt(1)),
+			cadence.NewOptional(nil),
+		}),
+		cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.String("x"),
+				Value: cadence.NewUInt8(2),
+			},
+		}),
+	}).WithType(&cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "a",
+				Type: cadence.VariableSizedArrayType{
+					ElementType: cadence.OptionalType{
+						Type: cadence.IntType{},
+					},
+				},
+			},
+			{
+				Identifier: "b",
+				Type: cadence.DictionaryType{
+					KeyType:     cadence.StringType{},
+					ElementType: cadence.UInt8Type{},
+				},
+			},
+		},
+	})
+
+	type testCase struct {
+		label        string
+		value        cadence.Value
+		expectedType sema.Type
+	}
+
+	for _, testCase := range []testCase{
+		{label: "Int", value: cadence.NewInt(1)},
+		{label: "String", value: cadence.String("hello")},
+		{label: "UInt8", value: cadence.NewUInt8(1)},
+		{label: "Optional", value: cadence.NewOptional(cadence.NewInt(1))},
+		{label: "Nil", value: cadence.NewOptional(nil)},
+		{label: "Bytes", value: cadence.NewBytes([]byte{1, 2, 3})},
+		{label: "nested, without expected type", value: nestedValue},
+		{
+			label: "nested, with expected type",
+			value: nestedValue,
+			expectedType: &sema.VariableSizedType{
+				Type: &sema.DictionaryType{
+					KeyType: sema.StringType,
+					ValueType: &sema.VariableSizedType{
+						Type: &sema.OptionalType{
+							Type: sema.IntType,
+						},
+					},
+				},
+			},
+		},
+		{label: "composite", value: compositeValue},
+	} {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			estimate, err := EstimateImportMemory(testCase.value, testCase.expectedType, resolveType)
+			require.NoError(t, err)
+
+			assert.Equal(t,
+				importMemory(t, testCase.value, testCase.expectedType),
+				estimate,
+			)
+		})
+	}
+
+	t.Run("unknown composite type", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := EstimateImportMemory(compositeValue, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestCountImportNodes(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("flat", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			value    cadence.Value
+			expected int
+		}
+
+		for _, testCase := range []testCase{
+			{cadence.NewInt(1), 1},
+			{cadence.String("hello"), 1},
+			{cadence.NewAddress([8]byte{0, 0, 0, 0, 0, 0, 0, 1}), 1},
+			{cadence.NewOptional(nil), 1},
+			{cadence.NewOptional(cadence.NewInt(1)), 2},
+			{cadence.NewBytes([]byte{1, 2, 3}), 4},
+		} {
+			assert.Equal(t,
+				testCase.expected,
+				CountImportNodes(testCase.value),
+				testCase.value.String(),
+			)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := cadence.NewArray([]cadence.Value{
+			cadence.NewInt(1),
+			cadence.NewInt(2),
+			cadence.NewInt(3),
+		})
+
+		assert.Equal(t, 4, CountImportNodes(value))
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := cadence.NewArray([]cadence.Value{
+			cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key: cadence.String("a"),
+					Value: cadence.NewArray([]cadence.Value{
+						cadence.NewOptional(cadence.NewInt(1)),
+						cadence.NewOptional(nil),
+					}),
+				},
+			}),
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(2),
+				cadence.NewArray(nil),
+			}),
+		})
+
+		// array: 1
+		// dictionary: 1, key: 1, array: 1, some: 2, nil: 1
+		// struct: 1, fields: 2
+		assert.Equal(t, 10, CountImportNodes(value))
+	})
+
+	t.Run("consistent with import", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := cadence.NewArray([]cadence.Value{
+			cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key: cadence.String("a"),
+					Value: cadence.NewArray([]cadence.Value{
+						cadence.NewInt(1),
+						cadence.NewInt(2),
+					}),
+				},
+			}),
+		})
+
+		inter := newTestInterpreter(t)
+
+		actual, err := importValue(inter, interpreter.ReturnEmptyLocationRange, value, nil)
+		require.NoError(t, err)
+
+		count := 0
+		var walk func(interpreter.Value)
+		walk = func(value interpreter.Value) {
+			count++
+			value.Walk(inter, walk)
+		}
+		walk(actual)
+
+		assert.Equal(t, count, CountImportNodes(value))
+	})
+}
+
+func TestImportCompositeValueFieldError(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct Inner {
+          pub let cap: Capability
+
+          init(cap: Capability) {
+              self.cap = cap
+          }
+      }
+
+      pub struct Outer {
+          pub let inner: Inner
+
+          init(inner: Inner) {
+              self.inner = inner
+          }
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	innerType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "Inner",
+		Fields: []cadence.Field{
+			{
+				Identifier: "cap",
+				Type:       cadence.CapabilityType{},
+			},
+		},
+	}
+
+	outerType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "Outer",
+		Fields: []cadence.Field{
+			{
+				Identifier: "inner",
+				Type:       innerType,
+			},
+		},
+	}
+
+	// The borrow type of the capability is not a reference type
+
+	value := cadence.NewStruct([]cadence.Value{
+		cadence.NewStruct([]cadence.Value{
+			cadence.Capability{
+				Path: cadence.Path{
+					Domain:     "storage",
+					Identifier: "foo",
+				},
+				Address:    cadence.BytesToAddress([]byte{0x1}),
+				BorrowType: cadence.IntType{},
+			},
+		}).WithType(innerType),
+	}).WithType(outerType)
+
+	_, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		nil,
+	)
+	require.Error(t, err)
+	assertUserError(t, err)
+
+	var fieldErr *ImportFieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "Outer", fieldErr.QualifiedIdentifier)
+	assert.Equal(t, "inner", fieldErr.FieldName)
+
+	require.ErrorAs(t, fieldErr.Err, &fieldErr)
+	assert.Equal(t, "Inner", fieldErr.QualifiedIdentifier)
+	assert.Equal(t, "cap", fieldErr.FieldName)
+
+	assert.Equal(t,
+		"cannot import field `Outer.inner`: "+
+			"cannot import field `Inner.cap`: "+
+			"cannot import capability: expected reference, got 'Int'",
+		err.Error(),
+	)
+}
+
+type testLimitMemoryGauge struct {
+	limit uint64
+	used  uint64
+}
+
+var errTestMemoryLimitExceeded = fmt.Errorf("memory limit exceeded")
+
+func (g *testLimitMemoryGauge) MeterMemory(usage common.MemoryUsage) error {
+	g.used += usage.Amount
+	if g.limit > 0 && g.used > g.limit {
+		return errTestMemoryLimitExceeded
+	}
+	return nil
+}
+
+func TestExportOnMemoryLimit(t *testing.T) {
+
+	t.Parallel()
+
+	newTest := func(t *testing.T, action MemoryLimitAction) (
+		exporter *Exporter,
+		export func() (cadence.Value, error),
+		gauge *testLimitMemoryGauge,
+		actions *int,
+	) {
+		gauge = &testLimitMemoryGauge{}
+
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			TestLocation,
+			interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+			interpreter.WithMemoryGauge(gauge),
+		)
+		require.NoError(t, err)
+
+		value := interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeString,
+			},
+			common.Address{},
+			interpreter.NewUnmeteredStringValue("aaaa"),
+			interpreter.NewUnmeteredStringValue("bbbb"),
+			interpreter.NewUnmeteredStringValue("cccc"),
+		)
+
+		actions = new(int)
+
+		// NOTE: type caching is enabled and the exporter is warmed up below,
+		// so the sema type of the array is not converted (and metered)
+		// after its elements were exported
+
+		exporter = NewExporter(
+			WithTypeCaching(true),
+			WithOnMemoryLimit(func() MemoryLimitAction {
+				*actions++
+				return action
+			}),
+		)
+
+		export = func() (cadence.Value, error) {
+			return exporter.ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		}
+
+		_, err = export()
+		require.NoError(t, err)
+
+		// The array base (1), its length (3), and the first two strings (5 each)
+		// fit into the limit, the third string exceeds it
+
+		gauge.used = 0
+		gauge.limit = 14
+
+		return
+	}
+
+	t.Run("abort", func(t *testing.T) {
+
+		t.Parallel()
+
+		exporter, export, _, actions := newTest(t, MemoryLimitActionAbort)
+
+		assert.PanicsWithError(t,
+			errors.MemoryError{Err: errTestMemoryLimitExceeded}.Error(),
+			func() {
+				_, _ = export()
+			},
+		)
+
+		assert.Equal(t, 1, *actions)
+		assert.False(t, exporter.Truncated())
+	})
+
+	t.Run("truncate here", func(t *testing.T) {
+
+		t.Parallel()
+
+		exporter, export, gauge, actions := newTest(t, MemoryLimitActionTruncateHere)
+
+		exported, err := export()
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.String("aaaa"),
+				cadence.String("bbbb"),
+				nil,
+			}).WithType(cadence.VariableSizedArrayType{
+				ElementType: cadence.StringType{},
+			}),
+			exported,
+		)
+		assert.Equal(t, 1, *actions)
+		assert.True(t, exporter.Truncated())
+
+		// The flag is reset by the next export
+
+		gauge.used = 0
+		gauge.limit = 0
+
+		_, err = export()
+		require.NoError(t, err)
+		assert.False(t, exporter.Truncated())
+	})
+}
+
+func TestExportValueChargedMemoryKinds(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: String
+          pub let b: String
+
+          init(a: String, b: String) {
+              self.a = a
+              self.b = b
+          }
+      }
+    `
+
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(t, err)
+
+	checker, err := sema.NewChecker(program, TestLocation, nil, false)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	require.NoError(t, err)
+
+	gauge := NewRecordingMemoryGauge()
+
+	inter, err := interpreter.NewInterpreter(
+		interpreter.ProgramFromChecker(checker),
+		TestLocation,
+		interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+		interpreter.WithMemoryGauge(gauge),
+	)
+	require.NoError(t, err)
+
+	err = inter.Interpret()
+	require.NoError(t, err)
+
+	value := interpreter.NewCompositeValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		TestLocation,
+		"S",
+		common.CompositeKindStructure,
+		[]interpreter.CompositeField{
+			{
+				Name:  "a",
+				Value: interpreter.NewUnmeteredStringValue("x"),
+			},
+			{
+				Name:  "b",
+				Value: interpreter.NewUnmeteredStringValue("y"),
+			},
+		},
+		common.Address{},
+	)
+
+	gauge.Reset()
+
+	_, err = ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	// The type of the composite is exported first,
+	// then the composite itself, then its fields
+
+	assert.Equal(t,
+		[]common.MemoryKind{
+			common.MemoryKindCompositeStaticType,
+			common.MemoryKindCadenceStructType,
+			common.MemoryKindCadenceSimpleType,
+			common.MemoryKindCadenceStructValueBase,
+			common.MemoryKindCadenceStructValueSize,
+			common.MemoryKindCadenceStringValue,
+			common.MemoryKindCadenceStringValue,
+		},
+		gauge.ChargedKinds(),
+	)
+}
+
+func TestImportDictionaryValueCoercedIntegerKeys(t *testing.T) {
+
+	t.Parallel()
+
+	dictionaryType := &sema.DictionaryType{
+		KeyType:   sema.IntType,
+		ValueType: sema.StringType,
+	}
+
+	t.Run("in range", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.NewUInt8(1),
+				Value: cadence.String("a"),
+			},
+			{
+				Key:   cadence.NewUInt8(2),
+				Value: cadence.String("b"),
+			},
+		})
+
+		imported, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			dictionaryType,
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.DictionaryValue{}, imported)
+		dictionary := imported.(*interpreter.DictionaryValue)
+
+		assert.Equal(t,
+			interpreter.DictionaryStaticType{
+				KeyType:   interpreter.PrimitiveStaticTypeInt,
+				ValueType: interpreter.PrimitiveStaticTypeString,
+			},
+			dictionary.Type,
+		)
+
+		// The keys are Int keys
+
+		for _, key := range []int64{1, 2} {
+			_, ok := dictionary.Get(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				interpreter.NewUnmeteredIntValueFromInt64(key),
+			)
+			assert.True(t, ok)
+		}
+
+		assert.True(t,
+			inter.IsSubTypeOfSemaType(
+				dictionary.StaticType(inter),
+				dictionaryType,
+			),
+		)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.NewInt(256),
+				Value: cadence.String("a"),
+			},
+		})
+
+		_, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			&sema.DictionaryType{
+				KeyType:   sema.UInt8Type,
+				ValueType: sema.StringType,
+			},
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.Contains(t,
+			err.Error(),
+			"cannot import dictionary: key `256` is out of the range of key type `UInt8`",
+		)
+	})
+}
+
+func TestExportCompositeTypeFieldOrder(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let c: Int
+          pub let a: String
+          pub let b: Bool
+
+          init() {
+              self.c = 1
+              self.a = "a"
+              self.b = true
+          }
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	// NOTE: the fields of the value are provided in a different order than declared
+
+	value := interpreter.NewCompositeValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		TestLocation,
+		"S",
+		common.CompositeKindStructure,
+		[]interpreter.CompositeField{
+			{
+				Name:  "a",
+				Value: interpreter.NewUnmeteredStringValue("a"),
+			},
+			{
+				Name:  "b",
+				Value: interpreter.BoolValue(true),
+			},
+			{
+				Name:  "c",
+				Value: interpreter.NewUnmeteredIntValueFromInt64(1),
+			},
+		},
+		common.Address{},
+	)
+
+	exported, err := exportValueWithInterpreter(
+		value,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, cadence.Struct{}, exported)
+	structValue := exported.(cadence.Struct)
+
+	fields := cadence.CompositeTypeFields(structValue.StructType)
+
+	fieldNames := make([]string, len(fields))
+	for i, field := range fields {
+		fieldNames[i] = field.Identifier
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, fieldNames)
+
+	assert.Equal(t,
+		[]cadence.Value{
+			cadence.NewInt(1),
+			cadence.String("a"),
+			cadence.NewBool(true),
+		},
+		structValue.Fields,
+	)
+
+	// The returned fields are a copy
+
+	fields[0].Identifier = "x"
+	assert.Equal(t, "c", structValue.StructType.Fields[0].Identifier)
+}
+
+func TestExportArrayValueElementError(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	// The function at index 2 cannot be exported
+
+	value := interpreter.NewArrayValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeAnyStruct,
+		},
+		common.Address{},
+		interpreter.NewUnmeteredIntValueFromInt64(1),
+		interpreter.NewUnmeteredIntValueFromInt64(2),
+		interpreter.NewUnmeteredHostFunctionValue(
+			func(invocation interpreter.Invocation) interpreter.Value {
+				return interpreter.VoidValue{}
+			},
+			&sema.FunctionType{
+				ReturnTypeAnnotation: sema.NewTypeAnnotation(sema.VoidType),
+			},
+		),
+	)
+
+	_, err := exportValueWithInterpreter(
+		value,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.Error(t, err)
+
+	var elementErr *ExportArrayElementError
+	require.ErrorAs(t, err, &elementErr)
+	assert.Equal(t, 2, elementErr.Index)
+
+	assert.Contains(t, err.Error(), "cannot export array element at index 2: ")
+
+	// The classification of the element error is preserved
+
+	assert.True(t, errors.IsInternalError(err))
+	assert.False(t, errors.IsUserError(err))
+}
+
+func TestExportSomeValueInnerError(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	// The function cannot be exported
+
+	value := interpreter.NewUnmeteredSomeValueNonCopying(
+		interpreter.NewUnmeteredHostFunctionValue(
+			func(invocation interpreter.Invocation) interpreter.Value {
+				return interpreter.VoidValue{}
+			},
+			&sema.FunctionType{
+				ReturnTypeAnnotation: sema.NewTypeAnnotation(sema.VoidType),
+			},
+		),
+	)
+
+	_, err := exportValueWithInterpreter(
+		value,
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		seenReferences{},
+	)
+	require.Error(t, err)
+
+	var optionalErr *ExportOptionalValueError
+	require.ErrorAs(t, err, &optionalErr)
+
+	assert.Contains(t, err.Error(), "cannot export optional value: ")
+
+	// The classification of the inner error is preserved
+
+	assert.True(t, errors.IsInternalError(err))
+	assert.False(t, errors.IsUserError(err))
+}
+
+func TestImportBytesAsUInt8Array(t *testing.T) {
+
+	t.Parallel()
+
+	bytes := cadence.Bytes{1, 2, 3}
+
+	expectedType := &sema.VariableSizedType{
+		Type: sema.UInt8Type,
+	}
+
+	t.Run("static type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		imported, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			bytes,
+			expectedType,
+		)
+		require.NoError(t, err)
+
+		// The static type is exactly [UInt8]
+
+		assert.Equal(t,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeUInt8,
+			},
+			imported.StaticType(inter),
+		)
+		assert.True(t, inter.IsSubTypeOfSemaType(imported.StaticType(inter), expectedType))
+	})
+
+	t.Run("argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		rt := newTestInterpreterRuntime()
+
+		runtimeInterface := &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			decodeArgument: func(_ []byte, _ cadence.Type) (cadence.Value, error) {
+				return bytes, nil
+			},
+		}
+
+		result, err := rt.ExecuteScript(
+			Script{
+				Source: []byte(`
+                  pub fun main(bytes: [UInt8]): [UInt8] {
+                      return bytes
+                  }
+                `),
+				Arguments: [][]byte{nil},
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  TestLocation,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewUInt8(1),
+				cadence.NewUInt8(2),
+				cadence.NewUInt8(3),
+			}).WithType(cadence.VariableSizedArrayType{
+				ElementType: cadence.UInt8Type{},
+			}),
+			result,
+		)
+	})
+}
+
+func TestImportStrictFieldTypes(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: Int64
+          pub let b: Int?
+
+          init(a: Int64, b: Int?) {
+              self.a = a
+              self.b = b
+          }
+      }
+    `
+
+	structType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "a",
+				Type:       cadence.Int64Type{},
+			},
+			{
+				Identifier: "b",
+				Type:       cadence.NewOptionalType(cadence.IntType{}),
+			},
+		},
+	}
+
+	t.Run("conforming", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewInt64(1),
+			cadence.NewInt(2),
+		}).WithType(structType)
+
+		_, err := NewImporter(WithStrictFieldTypes(true)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("mismatched", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewInt(1),
+			cadence.NewOptional(nil),
+		}).WithType(structType)
+
+		// By default, the field value is not checked
+
+		_, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.NoError(t, err)
+
+		_, err = NewImporter(WithStrictFieldTypes(true)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		var fieldErr *ImportFieldError
+		require.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "a", fieldErr.FieldName)
+
+		require.ErrorAs(t, err, &errors.DefaultUserError{})
+
+		assert.Equal(t,
+			"cannot import field `S.a`: value of type `Int` does not conform to field type `Int64`",
+			err.Error(),
+		)
+	})
+}
+
+func TestImportMaxDepth(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let next: S?
+
+          init(next: S?) {
+              self.next = next
+          }
+      }
+    `
+
+	structType := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+	}
+	structType.Fields = []cadence.Field{
+		{
+			Identifier: "next",
+			Type:       cadence.NewOptionalType(structType),
+		},
+	}
+
+	// newValue returns a composite which contains the given number of nested composites,
+	// i.e. the innermost composite is at the nesting depth 2 * count
+	newValue := func(count int) cadence.Value {
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewOptional(nil),
+		}).WithType(structType)
+
+		for i := 0; i < count; i++ {
+			value = cadence.NewStruct([]cadence.Value{
+				cadence.NewOptional(value),
+			}).WithType(structType)
+		}
+
+		return value
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		_, err := NewImporter(WithMaxImportDepth(11)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			newValue(5),
+			nil,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("beyond limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		value := newValue(10)
+
+		// By default, the depth is not limited
+
+		_, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.NoError(t, err)
+
+		_, err = NewImporter(WithMaxImportDepth(11)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		require.ErrorAs(t, err, &errors.DefaultUserError{})
+		assert.Contains(t, err.Error(), "cannot import value: maximum nesting depth of 11 exceeded")
+
+		var fieldErr *ImportFieldError
+		require.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "next", fieldErr.FieldName)
+	})
+}
+
+func TestExportResourceDoesNotConsume(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub resource Inner {
+          pub let y: String
+
+          init() {
+              self.y = "foo"
+          }
+      }
+
+      pub resource R {
+          pub let x: Int
+          pub let items: [Int]
+          pub let inner: @Inner
+
+          init() {
+              self.x = 1
+              self.items = [2, 3]
+              self.inner <- create Inner()
+          }
+
+          destroy() {
+              destroy self.inner
+          }
+      }
+
+      pub fun make(): @R {
+          return <- create R()
+      }
+
+      pub fun use(_ r: @R): Int {
+          let sum = r.x + r.items.length + r.inner.y.length
+          destroy r
+          return sum
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	var uuid uint64
+	inter.SetUUIDHandler(func() (uint64, error) {
+		uuid++
+		return uuid, nil
+	})
+
+	err := inter.Interpret()
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("make")
+	require.NoError(t, err)
+
+	first, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	second, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+
+	resource := first.(cadence.Resource)
+	require.Len(t, resource.Fields, 4)
+	assert.Equal(t, cadence.NewInt(1), resource.Fields[1])
+
+	// The resource is neither moved nor destroyed by the export, and can still be used
+
+	composite := value.(*interpreter.CompositeValue)
+	assert.False(t, composite.IsDestroyed())
+
+	result, err := inter.Invoke("use", value)
+	require.NoError(t, err)
+
+	AssertValuesEqual(t, inter, interpreter.NewUnmeteredIntValueFromInt64(6), result)
+}
+
+func TestExportEnumsAsRawValue(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub enum E: UInt8 {
+          pub case a
+          pub case b
+      }
+
+      pub struct S {
+          pub let e: E
+          pub let es: [E]
+
+          init() {
+              self.e = E.b
+              self.es = [E.a, E.b]
+          }
+      }
+
+      pub fun enum(): E {
+          return E.b
+      }
+
+      pub fun structure(): S {
+          return S()
+      }
+    `
+
+	test := func(t *testing.T, typeCaching bool) {
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		enum, err := inter.Invoke("enum")
+		require.NoError(t, err)
+
+		structure, err := inter.Invoke("structure")
+		require.NoError(t, err)
+
+		// By default, enums are exported as enums
+
+		actual, err := NewExporter().ExportValue(enum, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Enum{}, actual)
+		assert.Equal(t,
+			[]cadence.Value{cadence.NewUInt8(1)},
+			actual.(cadence.Enum).Fields,
+		)
+
+		exporter := NewExporter(
+			WithEnumsAsRawValue(true),
+			WithTypeCaching(typeCaching),
+		)
+
+		// Export multiple times, to ensure cached types are not mapped multiple times
+
+		for i := 0; i < 2; i++ {
+
+			actual, err = exporter.ExportValue(enum, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			assert.Equal(t, cadence.NewUInt8(1), actual)
+
+			actual, err = exporter.ExportValue(structure, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			require.IsType(t, cadence.Struct{}, actual)
+			s := actual.(cadence.Struct)
+
+			require.Len(t, s.Fields, 2)
+			assert.Equal(t, cadence.NewUInt8(1), s.Fields[0])
+
+			items := s.Fields[1].(cadence.Array)
+			assert.Equal(t,
+				[]cadence.Value{
+					cadence.NewUInt8(0),
+					cadence.NewUInt8(1),
+				},
+				items.Values,
+			)
+			assert.Equal(t,
+				cadence.VariableSizedArrayType{
+					ElementType: cadence.UInt8Type{},
+				},
+				items.ArrayType,
+			)
+
+			// Field types of the struct type
+
+			fields := s.StructType.Fields
+			require.Len(t, fields, 2)
+			assert.Equal(t, cadence.UInt8Type{}, fields[0].Type)
+			assert.Equal(t,
+				cadence.VariableSizedArrayType{
+					ElementType: cadence.UInt8Type{},
+				},
+				fields[1].Type,
+			)
+		}
+	}
+
+	t.Run("without type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, false)
+	})
+
+	t.Run("with type caching", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, true)
+	})
+}
+
+func TestImportEnumFromRawValue(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub enum E: UInt8 {
+          pub case a
+          pub case b
+      }
+    `
+
+	getEnumType := func(t *testing.T, inter *interpreter.Interpreter) *sema.CompositeType {
+		enumType, err := inter.GetCompositeType(
+			TestLocation,
+			"E",
+			TestLocation.TypeID(nil, "E"),
+		)
+		require.NoError(t, err)
+		return enumType
+	}
+
+	t.Run("known case", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		actual, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewUInt8(1),
+			getEnumType(t, inter),
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.CompositeValue{}, actual)
+		composite := actual.(*interpreter.CompositeValue)
+
+		assert.Equal(t, common.CompositeKindEnum, composite.Kind)
+		assert.Equal(t, TestLocation.TypeID(nil, "E"), composite.TypeID())
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewUnmeteredUInt8Value(1),
+			composite.GetField(inter, interpreter.ReturnEmptyLocationRange, sema.EnumRawValueFieldName),
+		)
+	})
+
+	t.Run("unknown case", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		_, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewUInt8(5),
+			getEnumType(t, inter),
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.Equal(t,
+			"cannot import enum `E`: raw value `5` is not the raw value of a case",
+			err.Error(),
+		)
+	})
+}
+
+func TestExportTypeSubstitutions(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: Int
+          pub let c: String
+
+          init() {
+              self.a = 1
+              self.c = "foo"
+          }
+      }
+
+      pub fun main(): S {
+          return S()
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	err := inter.Interpret()
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("main")
+	require.NoError(t, err)
+
+	// The upgraded type, in which field `a` was renamed to `b`
+
+	upgradedType := &sema.CompositeType{
+		Location:   TestLocation,
+		Identifier: "S",
+		Kind:       common.CompositeKindStructure,
+		Fields:     []string{"b", "c"},
+	}
+	upgradedType.Members = sema.GetMembersAsMap([]*sema.Member{
+		sema.NewPublicConstantFieldMember(nil, upgradedType, "b", sema.IntType, ""),
+		sema.NewPublicConstantFieldMember(nil, upgradedType, "c", sema.StringType, ""),
+	})
+
+	typeID := TestLocation.TypeID(nil, "S")
+
+	actual, err := NewExporter(
+		WithTypeSubstitutions(
+			map[sema.TypeID]*sema.CompositeType{
+				typeID: upgradedType,
+			},
+			map[sema.TypeID]map[string]string{
+				typeID: {
+					"b": "a",
+				},
+			},
+		),
+	).ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		cadence.NewStruct([]cadence.Value{
+			cadence.NewInt(1),
+			cadence.String("foo"),
+		}).WithType(&cadence.StructType{
+			Location:            TestLocation,
+			QualifiedIdentifier: "S",
+			Fields: []cadence.Field{
+				{
+					Identifier: "b",
+					Type:       cadence.IntType{},
+				},
+				{
+					Identifier: "c",
+					Type:       cadence.StringType{},
+				},
+			},
+		}),
+		actual,
+	)
+}
+
+func TestExportMaxNodes(t *testing.T) {
+
+	t.Parallel()
+
+	gauge := NewRecordingMemoryGauge()
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		TestLocation,
+		interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+		interpreter.WithMemoryGauge(gauge),
+	)
+	require.NoError(t, err)
+
+	// The array and its three elements are four nodes
+
+	value := interpreter.NewArrayValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeString,
+		},
+		common.Address{},
+		interpreter.NewUnmeteredStringValue("a"),
+		interpreter.NewUnmeteredStringValue("b"),
+		interpreter.NewUnmeteredStringValue("c"),
+	)
+
+	countNodes := func() int {
+		count := 0
+		for _, kind := range gauge.ChargedKinds() {
+			if kind == common.MemoryKindCadenceExportedNode {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		gauge.Reset()
+
+		actual, err := NewExporter(WithMaxNodes(4)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.String("a"),
+				cadence.String("b"),
+				cadence.String("c"),
+			}).WithType(cadence.VariableSizedArrayType{
+				ElementType: cadence.StringType{},
+			}),
+			actual,
+		)
+
+		assert.Equal(t, 4, countNodes())
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+
+		gauge.Reset()
+
+		_, err := NewExporter(WithMaxNodes(3)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		var nodeLimitErr *ExportNodeLimitExceededError
+		require.ErrorAs(t, err, &nodeLimitErr)
+		assert.Equal(t, 3, nodeLimitErr.MaxNodes)
+
+		// The limit is exceeded by the fourth node
+
+		assert.Equal(t, 4, countNodes())
+	})
+
+	t.Run("exceeding limit, collecting all errors", func(t *testing.T) {
+
+		gauge.Reset()
+
+		_, err := NewExporter(
+			WithMaxNodes(3),
+			WithCollectAllErrors(true),
+		).ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+
+		var nodeLimitErr *ExportNodeLimitExceededError
+		require.ErrorAs(t, err, &nodeLimitErr)
+
+		assert.Equal(t, 4, countNodes())
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+
+		gauge.Reset()
+
+		_, err := NewExporter().
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, countNodes())
+	})
+}
+
+func TestImportExportDictionaryOrder(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreter(t)
+
+	keys := []string{"c", "a", "e", "b", "d", "z", "y", "x"}
+
+	pairs := make([]cadence.KeyValuePair, len(keys))
+	for i, key := range keys {
+		pairs[i] = cadence.KeyValuePair{
+			Key:   cadence.String(key),
+			Value: cadence.NewInt(i),
+		}
+	}
+
+	value := cadence.NewDictionary(pairs)
+
+	imported, err := importValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		&sema.DictionaryType{
+			KeyType:   sema.StringType,
+			ValueType: sema.IntType,
+		},
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.DictionaryValue{}, imported)
+	dictionary := imported.(*interpreter.DictionaryValue)
+
+	exported, err := ExportValue(imported, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	require.IsType(t, cadence.Dictionary{}, exported)
+	exportedPairs := exported.(cadence.Dictionary).Pairs
+
+	// Dictionary values do not maintain the insertion order,
+	// so the round trip preserves the pairs, but not their order.
+
+	assert.ElementsMatch(t, pairs, exportedPairs)
+
+	// The pairs are exported in the iteration order of the dictionary value
+
+	var iteratedKeys []cadence.Value
+	dictionary.Iterate(inter, func(key, _ interpreter.Value) (resume bool) {
+		iteratedKeys = append(iteratedKeys, cadence.String(key.(*interpreter.StringValue).Str))
+		return true
+	})
+
+	exportedKeys := make([]cadence.Value, len(exportedPairs))
+	for i, pair := range exportedPairs {
+		exportedKeys[i] = pair.Key
+	}
+
+	assert.Equal(t, iteratedKeys, exportedKeys)
+
+	// Exporting again results in the same order
+
+	exportedAgain, err := ExportValue(imported, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	assert.Equal(t, exported, exportedAgain)
+}
+
+func TestExportStorageID(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {}
+    `
+
+	owner := common.MustBytesToAddress([]byte{0x1})
+
+	test := func(t *testing.T, address common.Address, includeStorageID bool) (
+		cadence.Struct,
+		*interpreter.CompositeValue,
+	) {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		value := interpreter.NewCompositeValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			TestLocation,
+			"S",
+			common.CompositeKindStructure,
+			nil,
+			address,
+		)
+
+		actual, err := NewExporter(WithIncludeStorageID(includeStorageID)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		return actual.(cadence.Struct), value
+	}
+
+	t.Run("stored, included", func(t *testing.T) {
+
+		t.Parallel()
+
+		structure, value := test(t, owner, true)
+
+		storageID := value.StorageID()
+
+		require.NotNil(t, structure.StorageID)
+		assert.Equal(t,
+			cadence.StorageID{
+				Address: cadence.Address(owner),
+				Index:   storageID.Index,
+			},
+			*structure.StorageID,
+		)
+		assert.Equal(t, storageID.String(), structure.StorageID.String())
+	})
+
+	t.Run("stored, not included", func(t *testing.T) {
+
+		t.Parallel()
+
+		structure, _ := test(t, owner, false)
+
+		assert.Nil(t, structure.StorageID)
+	})
+
+	t.Run("not stored, included", func(t *testing.T) {
+
+		t.Parallel()
+
+		structure, _ := test(t, common.Address{}, true)
+
+		assert.Nil(t, structure.StorageID)
+	})
+}
+
+func TestExportValueInterning(t *testing.T) {
+
+	t.Parallel()
+
+	// The array contains two references to the same struct
+
+	const code = `
+      pub struct S {
+          pub let x: Int
+
+          init(x: Int) {
+              self.x = x
+          }
+      }
+
+      pub fun test(): [&S] {
+          let s = S(x: 1)
+          return [&s as &S, &s as &S]
+      }
+    `
+
+	test := func(t *testing.T, interning bool) (first, second cadence.Struct) {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		actual, err := NewExporter(WithValueInterning(interning)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Array{}, actual)
+		elements := actual.(cadence.Array).Values
+		require.Len(t, elements, 2)
+
+		require.IsType(t, cadence.Struct{}, elements[0])
+		require.IsType(t, cadence.Struct{}, elements[1])
+
+		return elements[0].(cadence.Struct), elements[1].(cadence.Struct)
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		first, second := test(t, true)
+
+		assert.Equal(t, first, second)
+		assert.Same(t, &first.Fields[0], &second.Fields[0])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		first, second := test(t, false)
+
+		assert.Equal(t, first, second)
+		assert.NotSame(t, &first.Fields[0], &second.Fields[0])
+	})
+}
+
+const testImporterTypeCacheCode = `
+  pub struct S {
+      pub let a: UInt8
+
+      init(a: UInt8) {
+          self.a = a
+      }
+  }
+`
+
+func newTestImporterTypeCacheValue() cadence.Struct {
+	return cadence.NewStruct([]cadence.Value{
+		cadence.NewUInt8(1),
+	}).WithType(&cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "a",
+				Type:       cadence.UInt8Type{},
+			},
+		},
+	})
+}
+
+func TestImporterTypeCache(t *testing.T) {
+
+	t.Parallel()
+
+	const count = 10
+
+	test := func(t *testing.T, typeCaching bool, expectedLookups int) *Importer {
+
+		inter := newTestInterpreterWithProgram(t, testImporterTypeCacheCode)
+
+		importer := NewImporter(WithImportTypeCaching(typeCaching))
+
+		value := newTestImporterTypeCacheValue()
+
+		for i := 0; i < count; i++ {
+			_, err := importer.ImportValue(inter, interpreter.ReturnEmptyLocationRange, value, nil)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, expectedLookups, importer.compositeTypeLookups)
+
+		return importer
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, false, count)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, true, 1)
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+
+		t.Parallel()
+
+		importer := test(t, true, 1)
+
+		importer.InvalidateType(TestLocation.TypeID(nil, "S"))
+		assert.Empty(t, importer.compositeTypeCache)
+	})
+}
+
+func BenchmarkImporterTypeCache(b *testing.B) {
+
+	inter := newTestInterpreterWithProgram(b, testImporterTypeCacheCode)
+
+	// Validating the storage after each imported value makes the import quadratic
+
+	inter.SetAtreeValueValidationEnabled(false)
+	inter.SetAtreeStorageValidationEnabled(false)
+
+	value := newTestImporterTypeCacheValue()
+
+	const count = 10_000
+
+	for _, typeCaching := range []bool{false, true} {
+
+		b.Run(fmt.Sprintf("type caching %v", typeCaching), func(b *testing.B) {
+
+			importer := NewImporter(WithImportTypeCaching(typeCaching))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < count; j++ {
+					_, err := importer.ImportValue(inter, interpreter.ReturnEmptyLocationRange, value, nil)
+					require.NoError(b, err)
+				}
+			}
+
+			b.ReportMetric(float64(importer.compositeTypeLookups)/float64(b.N), "lookups/op")
+		})
+	}
+}
+
+func TestConversionInterpreter(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {
+          pub let a: UInt8
+          pub let b: String
+
+          init(a: UInt8, b: String) {
+              self.a = a
+              self.b = b
+          }
+      }
+    `
+
+	location := common.StringLocation("other")
+
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(t, err)
+
+	checker, err := sema.NewChecker(program, location, nil, false)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	require.NoError(t, err)
+
+	resolveType := func(resolvedLocation common.Location) *sema.Elaboration {
+		if resolvedLocation != location {
+			return nil
+		}
+		return checker.Elaboration
+	}
+
+	newValue := func(location common.Location) cadence.Struct {
+		return cadence.NewStruct([]cadence.Value{
+			cadence.NewUInt8(1),
+			cadence.String("foo"),
+		}).WithType(&cadence.StructType{
+			Location:            location,
+			QualifiedIdentifier: "S",
+			Fields: []cadence.Field{
+				{
+					Identifier: "a",
+					Type:       cadence.UInt8Type{},
+				},
+				{
+					Identifier: "b",
+					Type:       cadence.StringType{},
+				},
+			},
+		})
+	}
+
+	t.Run("round-trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter, err := NewConversionInterpreter(nil, resolveType)
+		require.NoError(t, err)
+
+		value := newValue(location)
+
+		imported, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.NoError(t, err)
+
+		exported, err := NewExporter().ExportValue(
+			imported,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, value, exported)
+	})
+
+	t.Run("unresolved type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter, err := NewConversionInterpreter(nil, resolveType)
+		require.NoError(t, err)
+
+		_, err = NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			newValue(common.StringLocation("unknown")),
+			nil,
+		)
+		require.Error(t, err)
+
+		require.ErrorAs(t, err, &interpreter.TypeLoadingError{})
+	})
+}
+
+func TestImportRejectZeroAddressCapabilities(t *testing.T) {
+
+	t.Parallel()
+
+	value := cadence.Capability{
+		BorrowType: cadence.ReferenceType{Type: cadence.IntType{}},
+		Path: cadence.Path{
+			Domain:     common.PathDomainPublic.Identifier(),
+			Identifier: "foo",
+		},
+	}
+
+	borrowType := &sema.ReferenceType{Type: sema.IntType}
+	expectedType := &sema.CapabilityType{BorrowType: borrowType}
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := NewImporter(WithRejectZeroAddressCapabilities(true)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			expectedType,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import capability: address must not be zero")
+	})
+
+	t.Run("enabled, non-zero address", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := value
+		value.Address = cadence.Address{0x1}
+
+		actual, err := NewImporter(WithRejectZeroAddressCapabilities(true)).ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			expectedType,
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.CapabilityValue{}, actual)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		actual, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			expectedType,
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.CapabilityValue{}, actual)
+		assert.Equal(t,
+			interpreter.AddressValue{},
+			actual.(*interpreter.CapabilityValue).Address,
+		)
+	})
+}
+
+func TestImportWithElaboration(t *testing.T) {
+
+	t.Parallel()
+
+	// The type of the struct is declared in a separate program,
+	// which is not the program of the interpreter
+
+	const code = `
+      pub struct S {
+          pub let a: UInt8
+
+          init(a: UInt8) {
+              self.a = a
+          }
+      }
+    `
+
+	location := common.StringLocation("other")
+
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(t, err)
+
+	checker, err := sema.NewChecker(program, location, nil, false)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	require.NoError(t, err)
+
+	value := cadence.NewStruct([]cadence.Value{
+		cadence.NewUInt8(1),
+	}).WithType(&cadence.StructType{
+		Location:            location,
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "a",
+				Type:       cadence.UInt8Type{},
+			},
+		},
+	})
+
+	// The interpreter cannot load the other program,
+	// so the type must be resolved using the elaboration
+
+	inter := newTestInterpreter(t)
+
+	actual, err := NewImporter(
+		WithElaboration(checker.Elaboration),
+		WithStrictFieldTypes(true),
+	).ImportValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		nil,
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.CompositeValue{}, actual)
+	composite := actual.(*interpreter.CompositeValue)
+
+	assert.Equal(t, location.TypeID(nil, "S"), composite.TypeID())
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUnmeteredUInt8Value(1),
+		composite.GetField(inter, interpreter.ReturnEmptyLocationRange, "a"),
+	)
+}
+
+func TestExportFix64Boundaries(t *testing.T) {
+
+	t.Parallel()
+
+	for _, test := range []struct {
+		value    int64
+		expected string
+	}{
+		{value: math.MaxInt64, expected: "92233720368.54775807"},
+		{value: math.MinInt64, expected: "-92233720368.54775808"},
+		{value: -50000000, expected: "-0.50000000"},
+		{value: -1, expected: "-0.00000001"},
+		{value: -150000000, expected: "-1.50000000"},
+		{value: 0, expected: "0.00000000"},
+	} {
+		test := test
+
+		t.Run(test.expected, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := newTestInterpreter(t)
+
+			value := interpreter.NewUnmeteredFix64Value(test.value)
+
+			actual, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			assert.Equal(t, cadence.Fix64(test.value), actual)
+			assert.Equal(t, test.expected, actual.String())
+			assert.Equal(t, value.String(), actual.String())
+
+			// The exported value must round-trip
+
+			imported, err := importValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				actual,
+				sema.Fix64Type,
+			)
+			require.NoError(t, err)
+
+			AssertValuesEqual(t, inter, value, imported)
+		})
+	}
+}
+
+// NOTE: not parallel, as the big integer memory usage function is global
+func TestBigIntMemoryUsage(t *testing.T) {
+
+	var byteLengths []int
+
+	SetBigIntMemoryUsage(func(byteLen int) common.MemoryUsage {
+		byteLengths = append(byteLengths, byteLen)
+		return common.MemoryUsage{
+			Kind:   common.MemoryKindBigInt,
+			Amount: uint64(byteLen) * 100,
+		}
+	})
+	defer SetBigIntMemoryUsage(nil)
+
+	gauge := newTestMemoryGauge()
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		TestLocation,
+		interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+		interpreter.WithMemoryGauge(gauge),
+	)
+	require.NoError(t, err)
+
+	t.Run("export", func(t *testing.T) {
+
+		byteLengths = nil
+		gauge.meter = map[common.MemoryKind]uint64{}
+
+		actual, err := exportValueWithInterpreter(
+			interpreter.NewUnmeteredIntValueFromInt64(1000),
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			seenReferences{},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, cadence.NewInt(1000), actual)
+
+		assert.Equal(t, []int{8}, byteLengths)
+		assert.Equal(t, uint64(800), gauge.getMemory(common.MemoryKindBigInt))
+	})
+
+	t.Run("import", func(t *testing.T) {
+
+		byteLengths = nil
+		gauge.meter = map[common.MemoryKind]uint64{}
+
+		actual, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewUInt(1000),
+			sema.UIntType,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, interpreter.NewUnmeteredUIntValueFromUint64(1000), actual)
+
+		assert.Equal(t, []int{8}, byteLengths)
+		assert.Equal(t, uint64(800), gauge.getMemory(common.MemoryKindBigInt))
+	})
+
+	t.Run("default", func(t *testing.T) {
+
+		SetBigIntMemoryUsage(nil)
+
+		byteLengths = nil
+		gauge.meter = map[common.MemoryKind]uint64{}
+
+		_, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewInt(1000),
+			sema.IntType,
+		)
+		require.NoError(t, err)
+
+		assert.Empty(t, byteLengths)
+		assert.Equal(t, uint64(8), gauge.getMemory(common.MemoryKindBigInt))
+	})
+}
+
+func TestExportValueProjection(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct T {
+          pub let x: Int
+
+          init(x: Int) {
+              self.x = x
+          }
+      }
+
+      pub struct S {
+          pub let a: Int
+          pub let b: String
+          pub let c: [Int]
+          pub let d: T
+
+          init() {
+              self.a = 1
+              self.b = "2"
+              self.c = [3]
+              self.d = T(x: 4)
+          }
+      }
+
+      pub fun test(): S {
+          return S()
+      }
+
+      pub fun testArray(): [S] {
+          return [S()]
+      }
+    `
+
+	newInterpreter := func(t *testing.T) *interpreter.Interpreter {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		return inter
+	}
+
+	fieldIdentifiers := func(fields []cadence.Field) []string {
+		identifiers := make([]string, len(fields))
+		for i, field := range fields {
+			identifiers[i] = field.Identifier
+		}
+		return identifiers
+	}
+
+	t.Run("composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		actual, err := ExportValueProjection(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			[]string{"d", "a"},
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		structure := actual.(cadence.Struct)
+
+		assert.Equal(t,
+			[]string{"a", "d"},
+			fieldIdentifiers(structure.StructType.Fields),
+		)
+
+		require.Len(t, structure.Fields, 2)
+		assert.Equal(t, cadence.NewInt(1), structure.Fields[0])
+
+		// Nested composites are exported whole
+
+		require.IsType(t, cadence.Struct{}, structure.Fields[1])
+		nested := structure.Fields[1].(cadence.Struct)
+		assert.Equal(t, []cadence.Value{cadence.NewInt(4)}, nested.Fields)
+		assert.Equal(t,
+			[]string{"x"},
+			fieldIdentifiers(nested.StructType.Fields),
+		)
+
+		// The exported type of the composite is not modified
+
+		actual, err = ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		assert.Equal(t,
+			[]string{"a", "b", "c", "d"},
+			fieldIdentifiers(actual.(cadence.Struct).StructType.Fields),
+		)
+	})
+
+	t.Run("non-composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("testArray")
+		require.NoError(t, err)
+
+		actual, err := ExportValueProjection(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			[]string{"a"},
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Array{}, actual)
+		elements := actual.(cadence.Array).Values
+		require.Len(t, elements, 1)
+
+		require.IsType(t, cadence.Struct{}, elements[0])
+		assert.Len(t, elements[0].(cadence.Struct).Fields, 4)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		_, err = ExportValueProjection(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			[]string{"a", "e"},
+		)
+		assertUserError(t, err)
+		require.ErrorContains(t, err, "cannot export field `e` of `S.test.S`: no such field")
+	})
+}
+
+func TestImportStringInterning(t *testing.T) {
+
+	t.Parallel()
+
+	value := cadence.NewArray([]cadence.Value{
+		cadence.String("foo"),
+		cadence.String("bar"),
+		cadence.String("foo"),
+		cadence.String("foo"),
+	})
+
+	expectedType := &sema.VariableSizedType{Type: sema.StringType}
+
+	test := func(t *testing.T, interning bool) {
+
+		gauge := newTestMemoryGauge()
+
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			TestLocation,
+			interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+			interpreter.WithMemoryGauge(gauge),
+		)
+		require.NoError(t, err)
+
+		importer := NewImporter(WithStringInterning(interning))
+
+		// Strings are only interned within an import
+
+		for i := 0; i < 2; i++ {
+			gauge.meter = map[common.MemoryKind]uint64{}
+
+			actual, err := importer.ImportValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				value,
+				expectedType,
+			)
+			require.NoError(t, err)
+
+			AssertValuesEqual(
+				t,
+				inter,
+				interpreter.NewArrayValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					interpreter.VariableSizedStaticType{
+						Type: interpreter.PrimitiveStaticTypeString,
+					},
+					common.Address{},
+					interpreter.NewUnmeteredStringValue("foo"),
+					interpreter.NewUnmeteredStringValue("bar"),
+					interpreter.NewUnmeteredStringValue("foo"),
+					interpreter.NewUnmeteredStringValue("foo"),
+				),
+				actual,
+			)
+
+			stringMemoryUsage := common.NewStringMemoryUsage(3).Amount
+
+			expectedStrings := uint64(4)
+			if interning {
+				expectedStrings = 2
+			}
+
+			assert.Equal(t,
+				expectedStrings*stringMemoryUsage,
+				gauge.getMemory(common.MemoryKindStringValue),
+			)
+		}
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, true)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, false)
+	})
+}
+
+func BenchmarkImportStringInterning(b *testing.B) {
+
+	inter := newTestInterpreter(b)
+
+	// Validating the storage after each imported value makes the import quadratic
+
+	inter.SetAtreeValueValidationEnabled(false)
+	inter.SetAtreeStorageValidationEnabled(false)
+
+	const count = 10_000
+
+	values := make([]cadence.Value, count)
+	for i := range values {
+		values[i] = cadence.String(fmt.Sprintf("value %d", i%10))
+	}
+	value := cadence.NewArray(values)
+
+	expectedType := &sema.VariableSizedType{Type: sema.StringType}
+
+	for _, interning := range []bool{false, true} {
+
+		b.Run(fmt.Sprintf("string interning %v", interning), func(b *testing.B) {
+
+			importer := NewImporter(WithStringInterning(interning))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := importer.ImportValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					value,
+					expectedType,
+				)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func TestExportCyclicResource(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub resource R {
+          pub var inner: @R?
+
+          init() {
+              self.inner <- nil
+          }
+
+          destroy() {
+              destroy self.inner
+          }
+      }
+    `
+
+	inter := newTestInterpreterWithProgram(t, code)
+
+	// Validating the values would compare the cyclic resource with itself infinitely
+
+	inter.SetAtreeValueValidationEnabled(false)
+	inter.SetAtreeStorageValidationEnabled(false)
+
+	address := common.MustBytesToAddress([]byte{0x1})
+
+	value := interpreter.NewCompositeValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		TestLocation,
+		"R",
+		common.CompositeKindResource,
+		[]interpreter.CompositeField{
+			{
+				Name:  sema.ResourceUUIDFieldName,
+				Value: interpreter.NewUnmeteredUInt64Value(0),
+			},
+			{
+				Name:  "inner",
+				Value: interpreter.NilValue{},
+			},
+		},
+		address,
+	)
+
+	// Craft an invalid state, in which the resource contains itself
+
+	value.SetMember(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		"inner",
+		interpreter.NewSomeValueNonCopying(inter, value),
+	)
+
+	inner := value.GetField(inter, interpreter.ReturnEmptyLocationRange, "inner")
+	require.IsType(t, &interpreter.SomeValue{}, inner)
+
+	innerValue := inner.(*interpreter.SomeValue).InnerValue(inter, interpreter.ReturnEmptyLocationRange)
+	require.IsType(t, &interpreter.CompositeValue{}, innerValue)
+	require.Equal(t, value.StorageID(), innerValue.(*interpreter.CompositeValue).StorageID())
+
+	_, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.Error(t, err)
+	assertUserError(t, err)
+
+	assert.ErrorContains(t, err, "cannot export resource of type `S.test.R`: cyclic resource")
+}
+
+func TestImportConstantSizedArrayLength(t *testing.T) {
+
+	t.Parallel()
+
+	expectedType := &sema.ConstantSizedType{
+		Type: sema.IntType,
+		Size: 3,
+	}
+
+	t.Run("matching", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		actual, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewInt(2),
+				cadence.NewInt(3),
+			}),
+			expectedType,
+		)
+		require.NoError(t, err)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewArrayValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				interpreter.ConstantSizedStaticType{
+					Type: interpreter.PrimitiveStaticTypeInt,
+					Size: 3,
+				},
+				common.Address{},
+				interpreter.NewUnmeteredIntValueFromInt64(1),
+				interpreter.NewUnmeteredIntValueFromInt64(2),
+				interpreter.NewUnmeteredIntValueFromInt64(3),
+			),
+			actual,
+		)
+	})
+
+	t.Run("mismatching", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewInt(2),
+			}),
+			expectedType,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import array: expected 3 elements for type `[Int; 3]`, got 2")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		actual, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray(nil),
+			&sema.ConstantSizedType{
+				Type: sema.IntType,
+				Size: 0,
+			},
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.ArrayValue{}, actual)
+		assert.Equal(t,
+			interpreter.ConstantSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+				Size: 0,
+			},
+			actual.(*interpreter.ArrayValue).Type,
+		)
+	})
+
+	t.Run("empty, mismatching", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := importValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray(nil),
+			expectedType,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import array: expected 3 elements for type `[Int; 3]`, got 0")
+	})
+
+	t.Run("mismatching, generated", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		elements := []cadence.Value{
+			cadence.NewInt(1),
+			cadence.NewInt(2),
+			cadence.NewInt(3),
+			cadence.NewInt(4),
+		}
+
+		_, err := ImportArrayFrom(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			expectedType,
+			func() (cadence.Value, bool, error) {
+				if len(elements) == 0 {
+					return nil, false, nil
+				}
+				element := elements[0]
+				elements = elements[1:]
+				return element, true, nil
+			},
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import array: expected 3 elements for type `[Int; 3]`, got 4")
+	})
+}
+
+func TestExportRedactedType(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct SocialSecurity {
+          pub let number: String
+
+          init(number: String) {
+              self.number = number
+          }
+      }
+
+      pub struct Home {
+          pub let city: String
+
+          init(city: String) {
+              self.city = city
+          }
+      }
+
+      pub struct Person {
+          pub let name: String
+          pub let ssn: SocialSecurity
+          pub let home: Home
+          pub let previous: [SocialSecurity]
+
+          init() {
+              self.name = "Alice"
+              self.ssn = SocialSecurity(number: "123-45-6789")
+              self.home = Home(city: "Vancouver")
+              self.previous = [SocialSecurity(number: "987-65-4321")]
+          }
+      }
+
+      pub fun test(): Person {
+          return Person()
+      }
+
+      pub fun testSocialSecurity(): SocialSecurity {
+          return SocialSecurity(number: "123-45-6789")
+      }
+    `
+
+	const redactedTypeID = "S.test.SocialSecurity"
+
+	newInterpreter := func(t *testing.T) *interpreter.Interpreter {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		return inter
+	}
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		actual, err := NewExporter(WithRedactType(redactedTypeID)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		person := actual.(cadence.Struct)
+
+		require.Len(t, person.Fields, 4)
+
+		assert.Equal(t, cadence.String("Alice"), person.Fields[0])
+		assert.Equal(t, cadence.NewOptional(nil), person.Fields[1])
+
+		require.IsType(t, cadence.Struct{}, person.Fields[2])
+		home := person.Fields[2].(cadence.Struct)
+		assert.Equal(t, "S.test.Home", home.StructType.ID())
+		assert.Equal(t, []cadence.Value{cadence.String("Vancouver")}, home.Fields)
+
+		assert.Equal(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewOptional(nil),
+			}).WithType(cadence.VariableSizedArrayType{
+				ElementType: person.StructType.Fields[1].Type,
+			}),
+			person.Fields[3],
+		)
+	})
+
+	t.Run("top-level", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("testSocialSecurity")
+		require.NoError(t, err)
+
+		actual, err := NewExporter(WithRedactType(redactedTypeID)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.NewOptional(nil), actual)
+	})
+
+	t.Run("other type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("testSocialSecurity")
+		require.NoError(t, err)
+
+		actual, err := NewExporter(WithRedactType("S.test.Home")).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		assert.Equal(t,
+			[]cadence.Value{cadence.String("123-45-6789")},
+			actual.(cadence.Struct).Fields,
+		)
+	})
+
+	t.Run("non-composite type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newInterpreter(t)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		actual, err := NewExporter(WithRedactType("String")).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		person := actual.(cadence.Struct)
+
+		require.Len(t, person.Fields, 4)
+		assert.Equal(t, cadence.NewOptional(nil), person.Fields[0])
+		assert.Equal(t,
+			[]cadence.Value{cadence.NewOptional(nil)},
+			person.Fields[2].(cadence.Struct).Fields,
+		)
+	})
+}
+
+func TestExportArrayPage(t *testing.T) {
+
+	t.Parallel()
+
+	const count = 1000
+	const pageSize = 100
+
+	inter := newTestInterpreter(t)
+	inter.SetAtreeValueValidationEnabled(false)
+	inter.SetAtreeStorageValidationEnabled(false)
+
+	elements := make([]interpreter.Value, count)
+	for i := range elements {
+		elements[i] = interpreter.NewUnmeteredIntValueFromInt64(int64(i))
+	}
+
+	value := interpreter.NewArrayValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		common.Address{},
+		elements...,
+	)
+
+	expectedType := cadence.VariableSizedArrayType{
+		ElementType: cadence.IntType{},
+	}
+
+	t.Run("pages", func(t *testing.T) {
+
+		var exported []cadence.Value
+
+		offset := 0
+		pages := 0
+		for more := true; more; offset += pageSize {
+			page, hasMore, err := ExportArrayPage(
+				value,
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				offset,
+				pageSize,
+			)
+			require.NoError(t, err)
+
+			require.Len(t, page.Values, pageSize)
+			assert.Equal(t, expectedType, page.ArrayType)
+
+			exported = append(exported, page.Values...)
+			pages++
+			more = hasMore
+		}
+
+		assert.Equal(t, count/pageSize, pages)
+
+		require.Len(t, exported, count)
+		for i, element := range exported {
+			assert.Equal(t, cadence.NewInt(i), element)
+		}
+	})
+
+	t.Run("elements before the page are not visited", func(t *testing.T) {
+
+		// The array and the elements of the page are all nodes the export may visit
+
+		page, more, err := NewExporter(WithMaxNodes(pageSize+1)).
+			ExportArrayPage(
+				value,
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				count-pageSize,
+				pageSize,
+			)
+		require.NoError(t, err)
+
+		assert.False(t, more)
+		require.Len(t, page.Values, pageSize)
+		assert.Equal(t, cadence.NewInt(count-pageSize), page.Values[0])
+	})
+
+	t.Run("last partial page", func(t *testing.T) {
+
+		page, more, err := ExportArrayPage(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			count-10,
+			pageSize,
+		)
+		require.NoError(t, err)
+
+		assert.False(t, more)
+		require.Len(t, page.Values, 10)
+		assert.Equal(t, cadence.NewInt(count-1), page.Values[9])
+	})
+
+	t.Run("offset beyond end", func(t *testing.T) {
+
+		page, more, err := ExportArrayPage(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			count,
+			pageSize,
+		)
+		require.NoError(t, err)
+
+		assert.False(t, more)
+		assert.Empty(t, page.Values)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+
+		_, _, err := ExportArrayPage(
+			value,
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			0,
+			0,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+	})
+}
+
+func TestExportMaxDepth(t *testing.T) {
+
+	t.Parallel()
+
+	// newNestedArray returns an array which contains the given number of nested arrays,
+	// i.e. the innermost array is at the nesting depth count
+	newNestedArray := func(inter *interpreter.Interpreter, count int) interpreter.Value {
+		arrayType := interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeAnyStruct,
+		}
+
+		value := interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			arrayType,
+			common.Address{},
+		)
+
+		for i := 0; i < count; i++ {
+			value = interpreter.NewArrayValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				arrayType,
+				common.Address{},
+				value,
+			)
+		}
+
+		return value
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := newNestedArray(inter, 9)
+
+		_, err := NewExporter(WithMaxExportDepth(10)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+	})
+
+	t.Run("beyond limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := newNestedArray(inter, 10)
+
+		_, err := NewExporter(WithMaxExportDepth(10)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		require.ErrorAs(t, err, &errors.DefaultUserError{})
+		assert.Contains(t, err.Error(), "cannot export value: maximum depth of 10 exceeded")
+	})
+
+	t.Run("beyond default limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+		inter.SetAtreeValueValidationEnabled(false)
+		inter.SetAtreeStorageValidationEnabled(false)
+
+		value := newNestedArray(inter, DefaultMaxExportDepth)
+
+		_, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		require.ErrorAs(t, err, &errors.DefaultUserError{})
+		assert.Contains(t,
+			err.Error(),
+			fmt.Sprintf("cannot export value: maximum depth of %d exceeded", DefaultMaxExportDepth),
+		)
+	})
+
+	t.Run("nested optionals and composites", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, `
+          pub struct S {
+              pub let next: S?
+
+              init(next: S?) {
+                  self.next = next
+              }
+          }
+
+          pub fun test(): S {
+              var s = S(next: nil)
+              var i = 0
+              while i < 4 {
+                  s = S(next: s)
+                  i = i + 1
+              }
+              return s
+          }
+        `)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		// The five composites and the four optionals between them are nine levels
+
+		_, err = NewExporter(WithMaxExportDepth(9)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		_, err = NewExporter(WithMaxExportDepth(8)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &errors.DefaultUserError{})
+	})
+}
+
+func TestExportDocComments(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      /// A token vault.
+      pub struct Vault {
+
+          /// The balance of the vault.
+          pub let balance: UFix64
+
+          pub let id: UInt64
+
+          /// The previous vaults.
+          /// Empty for new vaults.
+          access(self) let history: [Vault]
+
+          init() {
+              self.balance = 1.0
+              self.id = 2
+              self.history = []
+          }
+      }
+
+      pub fun test(): Vault {
+          return Vault()
+      }
+    `
+
+	newVault := func(t *testing.T) (interpreter.Value, *interpreter.Interpreter) {
+		inter := newTestInterpreterWithProgram(t, code)
+
+		err := inter.Interpret()
+		require.NoError(t, err)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		return value, inter
+	}
+
+	docStrings := func(fields []cadence.Field) map[string]string {
+		result := map[string]string{}
+		for _, field := range fields {
+			result[field.Identifier] = field.DocString
+		}
+		return result
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		value, inter := newVault(t)
+
+		actual, err := NewExporter(WithDocComments(true)).
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+		vaultType := actual.(cadence.Struct).StructType
+
+		expected := map[string]string{
+			"balance": " The balance of the vault.",
+			"id":      "",
+			"history": " The previous vaults.\n Empty for new vaults.",
+		}
+
+		assert.Equal(t, expected, docStrings(vaultType.Fields))
+
+		// The doc comments are also added to contained composite types
+
+		require.IsType(t, cadence.VariableSizedArrayType{}, vaultType.Fields[2].Type)
+		elementType := vaultType.Fields[2].Type.(cadence.VariableSizedArrayType).ElementType
+
+		require.IsType(t, &cadence.StructType{}, elementType)
+		assert.Equal(t, expected, docStrings(elementType.(*cadence.StructType).Fields))
+	})
+
+	t.Run("with only public fields", func(t *testing.T) {
+
+		t.Parallel()
+
+		value, inter := newVault(t)
+
+		actual, err := NewExporter(
+			WithDocComments(true),
+			WithOnlyPublicFields(true),
+		).ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+
+		assert.Equal(t,
+			map[string]string{
+				"balance": " The balance of the vault.",
+				"id":      "",
+			},
+			docStrings(actual.(cadence.Struct).StructType.Fields),
+		)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		value, inter := newVault(t)
+
+		actual, err := NewExporter().
+			ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		require.IsType(t, cadence.Struct{}, actual)
+
+		for _, field := range actual.(cadence.Struct).StructType.Fields {
+			assert.Empty(t, field.DocString)
+		}
+	})
+}
+
+func TestExportImportPublicKey(t *testing.T) {
+
+	t.Parallel()
+
+	validatePublicKey := func(
+		_ *interpreter.Interpreter,
+		_ func() interpreter.LocationRange,
+		_ *interpreter.CompositeValue,
+	) error {
+		return nil
+	}
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		TestLocation,
+		interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+		interpreter.WithPublicKeyValidationHandler(validatePublicKey),
+	)
+	require.NoError(t, err)
+
+	publicKey := NewPublicKeyValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		&PublicKey{
+			PublicKey: []byte{1, 2, 3},
+			SignAlgo:  sema.SignatureAlgorithmECDSA_secp256k1,
+		},
+		validatePublicKey,
+	)
+
+	exported, err := ExportValue(publicKey, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	require.IsType(t, cadence.Struct{}, exported)
+	exportedStruct := exported.(cadence.Struct)
+
+	fields := exportedStruct.StructType.Fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, sema.PublicKeyPublicKeyField, fields[0].Identifier)
+	assert.Equal(t, sema.PublicKeySignAlgoField, fields[1].Identifier)
+
+	imported, err := NewImporter().ImportValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		exported,
+		sema.PublicKeyType,
+	)
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.CompositeValue{}, imported)
+	importedPublicKey := imported.(*interpreter.CompositeValue)
+
+	assert.Equal(t, sema.PublicKeyType.ID(), importedPublicKey.TypeID())
+
+	keyBytes, err := interpreter.ByteArrayValueToByteSlice(
+		inter,
+		importedPublicKey.ComputedFields[sema.PublicKeyPublicKeyField](
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, keyBytes)
+
+	signAlgo := importedPublicKey.GetField(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		sema.PublicKeySignAlgoField,
+	)
+	require.IsType(t, &interpreter.CompositeValue{}, signAlgo)
+	assert.Equal(t,
+		interpreter.UInt8Value(sema.SignatureAlgorithmECDSA_secp256k1),
+		signAlgo.(*interpreter.CompositeValue).GetField(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			sema.EnumRawValueFieldName,
+		),
+	)
+
+	// The re-exported public key is equal to the exported public key
+
+	reexported, err := ExportValue(imported, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+	assert.Equal(t, exported, reexported)
+}
+
+func TestImportEmptyArrayType(t *testing.T) {
+
+	t.Parallel()
+
+	importArray := func(t *testing.T, value cadence.Value) interpreter.Value {
+		inter := newTestInterpreter(t)
+
+		actual, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			value,
+			nil,
+		)
+		require.NoError(t, err)
+
+		return actual
+	}
+
+	t.Run("variable-sized", func(t *testing.T) {
+
+		t.Parallel()
+
+		actual := importArray(t,
+			cadence.NewArray(nil).
+				WithType(cadence.NewVariableSizedArrayType(cadence.IntType{})),
+		)
+
+		require.IsType(t, &interpreter.ArrayValue{}, actual)
+		assert.Equal(t,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			actual.(*interpreter.ArrayValue).Type,
+		)
+	})
+
+	t.Run("constant-sized", func(t *testing.T) {
+
+		t.Parallel()
+
+		actual := importArray(t,
+			cadence.NewArray(nil).
+				WithType(cadence.NewConstantSizedArrayType(0, cadence.StringType{})),
+		)
+
+		require.IsType(t, &interpreter.ArrayValue{}, actual)
+		assert.Equal(t,
+			interpreter.ConstantSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeString,
+				Size: 0,
+			},
+			actual.(*interpreter.ArrayValue).Type,
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		innerType := cadence.NewVariableSizedArrayType(cadence.IntType{})
+
+		actual := importArray(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewArray(nil).WithType(innerType),
+			}).WithType(cadence.NewVariableSizedArrayType(innerType)),
+		)
+
+		require.IsType(t, &interpreter.ArrayValue{}, actual)
+		assert.Equal(t,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.VariableSizedStaticType{
+					Type: interpreter.PrimitiveStaticTypeInt,
+				},
+			},
+			actual.(*interpreter.ArrayValue).Type,
+		)
+	})
+
+	t.Run("expected type", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		// The expected type takes precedence over the type of the array
+
+		actual, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray(nil).
+				WithType(cadence.NewVariableSizedArrayType(cadence.IntType{})),
+			&sema.VariableSizedType{
+				Type: sema.AnyStructType,
+			},
+		)
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.ArrayValue{}, actual)
+		assert.Equal(t,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeAnyStruct,
+			},
+			actual.(*interpreter.ArrayValue).Type,
+		)
+	})
+}
+
+func TestImportHeterogeneousCollections(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub resource R {}
+    `
+
+	resourceType := &cadence.ResourceType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "R",
+		Fields: []cadence.Field{
+			{
+				Identifier: sema.ResourceUUIDFieldName,
+				Type:       cadence.UInt64Type{},
+			},
+		},
+	}
+
+	newResource := func() cadence.Resource {
+		return cadence.NewResource([]cadence.Value{
+			cadence.NewUInt64(1),
+		}).WithType(resourceType)
+	}
+
+	t.Run("array", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		_, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+				newResource(),
+			}),
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		var importErr *HeterogeneousArrayImportError
+		require.ErrorAs(t, err, &importErr)
+
+		require.Len(t, importErr.ElementTypes, 2)
+		assert.Equal(t, sema.IntType, importErr.ElementTypes[0])
+		assert.Equal(t, "R", importErr.ElementTypes[1].QualifiedString())
+
+		assert.Equal(t,
+			"cannot import array: elements do not belong to the same type: `Int`, `R`",
+			importErr.Error(),
+		)
+	})
+
+	t.Run("dictionary, keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		_, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key:   cadence.String("a"),
+					Value: cadence.NewInt(1),
+				},
+				{
+					Key:   cadence.NewInt(2),
+					Value: cadence.NewInt(2),
+				},
+			}),
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		var importErr *HeterogeneousDictionaryImportError
+		require.ErrorAs(t, err, &importErr)
+
+		assert.Equal(t,
+			[]sema.Type{sema.StringType, sema.IntType},
+			importErr.KeyTypes,
+		)
+		assert.Equal(t,
+			[]sema.Type{sema.IntType, sema.IntType},
+			importErr.ValueTypes,
+		)
+
+		assert.Equal(t,
+			"cannot import dictionary: keys does not belong to the same type: `String`, `Int`",
+			importErr.Error(),
+		)
+	})
+
+	t.Run("dictionary, values", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreterWithProgram(t, code)
+
+		_, err := NewImporter().ImportValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key:   cadence.String("a"),
+					Value: cadence.NewInt(1),
+				},
+				{
+					Key:   cadence.String("b"),
+					Value: newResource(),
+				},
+			}),
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		var importErr *HeterogeneousDictionaryImportError
+		require.ErrorAs(t, err, &importErr)
+
+		assert.Equal(t,
+			[]sema.Type{sema.StringType, sema.StringType},
+			importErr.KeyTypes,
+		)
+		require.Len(t, importErr.ValueTypes, 2)
+		assert.Equal(t, sema.IntType, importErr.ValueTypes[0])
+		assert.Equal(t, "R", importErr.ValueTypes[1].QualifiedString())
+
+		assert.Equal(t,
+			"cannot import dictionary: values does not belong to the same type: `Int`, `R`",
+			importErr.Error(),
+		)
+	})
+}
+
+func TestExportArrayValueIter(t *testing.T) {
+
+	t.Parallel()
+
+	const count = 1000
+
+	inter := newTestInterpreter(t)
+	inter.SetAtreeValueValidationEnabled(false)
+	inter.SetAtreeStorageValidationEnabled(false)
+
+	elements := make([]interpreter.Value, count)
+	for i := range elements {
+		elements[i] = interpreter.NewUnmeteredIntValueFromInt64(int64(i))
+	}
+
+	value := interpreter.NewArrayValue(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		common.Address{},
+		elements...,
+	)
+
+	t.Run("all elements", func(t *testing.T) {
+
+		next, err := ExportArrayValueIter(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		var exported []cadence.Value
+		for {
+			element, err := next()
+			require.NoError(t, err)
+			if element == nil {
+				break
+			}
+			exported = append(exported, element)
+		}
+
+		expected, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected.(cadence.Array).Values, exported)
+
+		// The iterator stays exhausted
+
+		element, err := next()
+		require.NoError(t, err)
+		assert.Nil(t, element)
+	})
+
+	t.Run("element error", func(t *testing.T) {
+
+		// The array and two elements are within the limit
+
+		next, err := NewExporter(WithMaxNodes(3)).
+			ExportArrayValueIter(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			element, err := next()
+			require.NoError(t, err)
+			assert.Equal(t, cadence.NewInt(i), element)
+		}
+
+		element, err := next()
+		require.Error(t, err)
+		assert.Nil(t, element)
+
+		var elementErr *ExportArrayElementError
+		require.ErrorAs(t, err, &elementErr)
+		assert.Equal(t, 2, elementErr.Index)
+
+		var nodeLimitErr *ExportNodeLimitExceededError
+		require.ErrorAs(t, err, &nodeLimitErr)
+
+		// Further calls return the same error
+
+		_, nextErr := next()
+		assert.Equal(t, err, nextErr)
+	})
+
+	t.Run("collection size", func(t *testing.T) {
+
+		_, err := NewExporter(WithMaxCollectionSize(count-1)).
+			ExportArrayValueIter(value, inter, interpreter.ReturnEmptyLocationRange)
+		require.Error(t, err)
+	})
+
+	t.Run("memory limit", func(t *testing.T) {
+
+		newTest := func(t *testing.T, action MemoryLimitAction) (
+			exporter *Exporter,
+			next func() (cadence.Value, error),
+			gauge *testLimitMemoryGauge,
+			actions *int,
+		) {
+			gauge = &testLimitMemoryGauge{}
+
+			inter, err := interpreter.NewInterpreter(
+				nil,
+				TestLocation,
+				interpreter.WithStorage(newUnmeteredInMemoryStorage()),
+				interpreter.WithMemoryGauge(gauge),
+			)
+			require.NoError(t, err)
+
+			value := interpreter.NewArrayValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				interpreter.VariableSizedStaticType{
+					Type: interpreter.PrimitiveStaticTypeString,
+				},
+				common.Address{},
+				interpreter.NewUnmeteredStringValue("aaaa"),
+				interpreter.NewUnmeteredStringValue("bbbb"),
+				interpreter.NewUnmeteredStringValue("cccc"),
+			)
+
+			actions = new(int)
+
+			exporter = NewExporter(
+				WithOnMemoryLimit(func() MemoryLimitAction {
+					*actions++
+					return action
+				}),
+			)
+
+			next, err = exporter.ExportArrayValueIter(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			// The first string (5) fits into the limit, the second string exceeds it
+
+			gauge.used = 0
+			gauge.limit = 5
+
+			return
+		}
+
+		t.Run("abort", func(t *testing.T) {
+
+			exporter, next, _, actions := newTest(t, MemoryLimitActionAbort)
+
+			element, err := next()
+			require.NoError(t, err)
+			assert.Equal(t, cadence.String("aaaa"), element)
+
+			assert.PanicsWithError(t,
+				errors.MemoryError{Err: errTestMemoryLimitExceeded}.Error(),
+				func() {
+					_, _ = next()
+				},
+			)
+
+			assert.Equal(t, 1, *actions)
+			assert.False(t, exporter.Truncated())
+		})
+
+		t.Run("truncate here", func(t *testing.T) {
+
+			exporter, next, gauge, actions := newTest(t, MemoryLimitActionTruncateHere)
+
+			element, err := next()
+			require.NoError(t, err)
+			assert.Equal(t, cadence.String("aaaa"), element)
+
+			// The truncated element is exported as a placeholder (nil)
+
+			element, err = next()
+			require.NoError(t, err)
+			assert.Nil(t, element)
+
+			assert.Equal(t, 1, *actions)
+			assert.True(t, exporter.Truncated())
+
+			// The iteration continues
+
+			gauge.limit = 0
+
+			element, err = next()
+			require.NoError(t, err)
+			assert.Equal(t, cadence.String("cccc"), element)
+
+			element, err = next()
+			require.NoError(t, err)
+			assert.Nil(t, element)
+
+			assert.Equal(t, 1, *actions)
+			assert.True(t, exporter.Truncated())
+		})
+	})
+}
@@ -567,3 +567,214 @@ func (e *MissingDeclarationError) Error() string {
 		e.Name,
 	)
 }
+
+// ImportFieldError
+//
+// ImportFieldError is returned when a field of an imported composite cannot be imported.
+// Errors of nested composites are wrapped again, so the error message contains the path of the field.
+type ImportFieldError struct {
+	QualifiedIdentifier string
+	FieldName           string
+	Err                 error
+}
+
+var _ errors.UserError = &ImportFieldError{}
+
+func (*ImportFieldError) IsUserError() {}
+
+func (e *ImportFieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ImportFieldError) Error() string {
+	return fmt.Sprintf(
+		"cannot import field `%s.%s`: %s",
+		e.QualifiedIdentifier,
+		e.FieldName,
+		e.Err.Error(),
+	)
+}
+
+// HeterogeneousArrayImportError
+//
+// HeterogeneousArrayImportError is returned when an array without an expected type is imported,
+// and the types of the elements have no common super type.
+type HeterogeneousArrayImportError struct {
+	ElementTypes []sema.Type
+}
+
+var _ errors.UserError = &HeterogeneousArrayImportError{}
+
+func (*HeterogeneousArrayImportError) IsUserError() {}
+
+func (e *HeterogeneousArrayImportError) Error() string {
+	return fmt.Sprintf(
+		"cannot import array: elements do not belong to the same type: %s",
+		importTypesString(e.ElementTypes),
+	)
+}
+
+// HeterogeneousDictionaryImportError
+//
+// HeterogeneousDictionaryImportError is returned when a dictionary without an expected type is imported,
+// and the types of the keys have no common super type which is a valid dictionary key type,
+// or the types of the values have no common super type.
+type HeterogeneousDictionaryImportError struct {
+	KeyTypes   []sema.Type
+	ValueTypes []sema.Type
+}
+
+var _ errors.UserError = &HeterogeneousDictionaryImportError{}
+
+func (*HeterogeneousDictionaryImportError) IsUserError() {}
+
+func (e *HeterogeneousDictionaryImportError) Error() string {
+	keySuperType := sema.LeastCommonSuperType(e.KeyTypes...)
+	if !sema.IsValidDictionaryKeyType(keySuperType) {
+		return fmt.Sprintf(
+			"cannot import dictionary: keys does not belong to the same type: %s",
+			importTypesString(e.KeyTypes),
+		)
+	}
+
+	return fmt.Sprintf(
+		"cannot import dictionary: values does not belong to the same type: %s",
+		importTypesString(e.ValueTypes),
+	)
+}
+
+func importTypesString(types []sema.Type) string {
+	var builder strings.Builder
+	for i, ty := range types {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteByte('`')
+		builder.WriteString(ty.QualifiedString())
+		builder.WriteByte('`')
+	}
+	return builder.String()
+}
+
+// JSONCDCDecodingError
+//
+// JSONCDCDecodingError is returned when a JSON-CDC encoded value cannot be decoded, see ImportJSONCDC.
+// The offset is the byte offset in the encoded data where the offending value begins.
+//
+// Errors of the import of a successfully decoded value are not wrapped, so they have no offset.
+type JSONCDCDecodingError struct {
+	Offset int64
+	Err    error
+}
+
+var _ errors.UserError = &JSONCDCDecodingError{}
+
+func (*JSONCDCDecodingError) IsUserError() {}
+
+func (e *JSONCDCDecodingError) Unwrap() error {
+	return e.Err
+}
+
+func (e *JSONCDCDecodingError) Error() string {
+	return fmt.Sprintf(
+		"cannot decode JSON-CDC value at offset %d: %s",
+		e.Offset,
+		e.Err.Error(),
+	)
+}
+
+// ExportArrayElementError
+//
+// ExportArrayElementError is returned when an element of an exported array cannot be exported.
+//
+// NOTE: the error is not a user error itself,
+// so the classification of the wrapped error (e.g. internal error) is preserved.
+type ExportArrayElementError struct {
+	Index int
+	Err   error
+}
+
+func (e *ExportArrayElementError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExportArrayElementError) Error() string {
+	return fmt.Sprintf(
+		"cannot export array element at index %d: %s",
+		e.Index,
+		e.Err.Error(),
+	)
+}
+
+// ExportOptionalValueError
+//
+// ExportOptionalValueError is returned when the inner value of an exported optional cannot be exported.
+//
+// NOTE: the error is not a user error itself,
+// so the classification of the wrapped error (e.g. internal error) is preserved.
+type ExportOptionalValueError struct {
+	Err error
+}
+
+func (e *ExportOptionalValueError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExportOptionalValueError) Error() string {
+	return fmt.Sprintf(
+		"cannot export optional value: %s",
+		e.Err.Error(),
+	)
+}
+
+// ExportNodeLimitExceededError
+//
+// ExportNodeLimitExceededError is returned when an export visits more values
+// than the maximum number of nodes, see WithMaxNodes.
+type ExportNodeLimitExceededError struct {
+	MaxNodes int
+}
+
+var _ errors.UserError = &ExportNodeLimitExceededError{}
+
+func (*ExportNodeLimitExceededError) IsUserError() {}
+
+func (e *ExportNodeLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"cannot export value: the number of exported values exceeds the limit of %d",
+		e.MaxNodes,
+	)
+}
+
+// ExportErrors
+//
+// ExportErrors is returned by an exporter that collects all errors,
+// see WithCollectAllErrors.
+//
+// The errors are in traversal order.
+type ExportErrors struct {
+	Errors []error
+}
+
+var _ errors.ParentError = &ExportErrors{}
+
+func (e *ExportErrors) Error() string {
+	var sb strings.Builder
+	sb.WriteString("cannot export value: ")
+	for i, err := range e.Errors {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+func (e *ExportErrors) ChildErrors() []error {
+	return e.Errors
+}
+
+// Unwrap returns the first error
+func (e *ExportErrors) Unwrap() error {
+	return e.Errors[0]
+}
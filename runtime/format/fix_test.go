@@ -19,6 +19,7 @@
 package format
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -30,3 +31,14 @@ func TestUFix64(t *testing.T) {
 
 	require.Equal(t, "99999999999.70000000", UFix64(9999999999970000000))
 }
+
+func TestFix64(t *testing.T) {
+
+	t.Parallel()
+
+	require.Equal(t, "92233720368.54775807", Fix64(math.MaxInt64))
+	require.Equal(t, "-92233720368.54775808", Fix64(math.MinInt64))
+	require.Equal(t, "-0.50000000", Fix64(-50000000))
+	require.Equal(t, "-1.00000001", Fix64(-100000001))
+	require.Equal(t, "0.00000000", Fix64(0))
+}
@@ -933,6 +933,11 @@ func (interpreter *Interpreter) findVariable(name string) *Variable {
 	return interpreter.activations.Find(name)
 }
 
+// FindVariable returns the variable with the given name in the current scope, if any.
+func (interpreter *Interpreter) FindVariable(name string) *Variable {
+	return interpreter.findVariable(name)
+}
+
 func (interpreter *Interpreter) findOrDeclareVariable(name string) *Variable {
 	variable := interpreter.findVariable(name)
 	if variable == nil {
@@ -4243,6 +4248,35 @@ func (interpreter *Interpreter) GetCompositeType(
 	return interpreter.getUserCompositeType(location, typeID)
 }
 
+// GetCompositeDeclaration returns the declaration of the given user-defined composite type.
+func (interpreter *Interpreter) GetCompositeDeclaration(
+	compositeType *sema.CompositeType,
+) (*ast.CompositeDeclaration, error) {
+	typeID := compositeType.ID()
+
+	if compositeType.Location == nil {
+		return nil, TypeLoadingError{
+			TypeID: typeID,
+		}
+	}
+
+	elaboration := interpreter.getElaboration(compositeType.Location)
+	if elaboration == nil {
+		return nil, TypeLoadingError{
+			TypeID: typeID,
+		}
+	}
+
+	declaration := elaboration.CompositeTypeDeclarations[compositeType]
+	if declaration == nil {
+		return nil, TypeLoadingError{
+			TypeID: typeID,
+		}
+	}
+
+	return declaration, nil
+}
+
 func (interpreter *Interpreter) getUserCompositeType(location common.Location, typeID common.TypeID) (*sema.CompositeType, error) {
 	elaboration := interpreter.getElaboration(location)
 	if elaboration == nil {
@@ -341,6 +341,14 @@ func (interpreter *Interpreter) VisitBinaryExpression(expression *ast.BinaryExpr
 		return left.BitwiseRightShift(interpreter, right)
 
 	case ast.OperationLess:
+		if leftTypeValue, ok := leftValue.(TypeValue); ok {
+			right, rightOk := rightValue().(TypeValue)
+			if !rightOk {
+				error(right)
+			}
+			return NewBoolValue(interpreter, leftTypeValue.Compare(interpreter, right) < 0)
+		}
+
 		left, leftOk := leftValue.(NumberValue)
 		right, rightOk := rightValue().(NumberValue)
 		if !leftOk || !rightOk {
@@ -349,6 +357,14 @@ func (interpreter *Interpreter) VisitBinaryExpression(expression *ast.BinaryExpr
 		return left.Less(interpreter, right)
 
 	case ast.OperationLessEqual:
+		if leftTypeValue, ok := leftValue.(TypeValue); ok {
+			right, rightOk := rightValue().(TypeValue)
+			if !rightOk {
+				error(right)
+			}
+			return NewBoolValue(interpreter, leftTypeValue.Compare(interpreter, right) <= 0)
+		}
+
 		left, leftOk := leftValue.(NumberValue)
 		right, rightOk := rightValue().(NumberValue)
 		if !leftOk || !rightOk {
@@ -357,6 +373,14 @@ func (interpreter *Interpreter) VisitBinaryExpression(expression *ast.BinaryExpr
 		return left.LessEqual(interpreter, right)
 
 	case ast.OperationGreater:
+		if leftTypeValue, ok := leftValue.(TypeValue); ok {
+			right, rightOk := rightValue().(TypeValue)
+			if !rightOk {
+				error(right)
+			}
+			return NewBoolValue(interpreter, leftTypeValue.Compare(interpreter, right) > 0)
+		}
+
 		left, leftOk := leftValue.(NumberValue)
 		right, rightOk := rightValue().(NumberValue)
 		if !leftOk || !rightOk {
@@ -365,6 +389,14 @@ func (interpreter *Interpreter) VisitBinaryExpression(expression *ast.BinaryExpr
 		return left.Greater(interpreter, right)
 
 	case ast.OperationGreaterEqual:
+		if leftTypeValue, ok := leftValue.(TypeValue); ok {
+			right, rightOk := rightValue().(TypeValue)
+			if !rightOk {
+				error(right)
+			}
+			return NewBoolValue(interpreter, leftTypeValue.Compare(interpreter, right) >= 0)
+		}
+
 		left, leftOk := leftValue.(NumberValue)
 		right, rightOk := rightValue().(NumberValue)
 		if !leftOk || !rightOk {
@@ -320,14 +320,29 @@ func (v TypeValue) Equal(_ *Interpreter, _ func() LocationRange, other Value) bo
 	return staticType.Equal(otherStaticType)
 }
 
+// typeID returns the identifier of the type, or the empty string for an unknown type.
+func (v TypeValue) typeID(interpreter *Interpreter) string {
+	staticType := v.Type
+	if staticType == nil {
+		return ""
+	}
+	return string(interpreter.MustConvertStaticToSemaType(staticType).ID())
+}
+
+// Compare compares the type with the given other type by identifier,
+// and returns -1 if the type is ordered before the other type,
+// 1 if the type is ordered after the other type, and 0 if both have the same identifier.
+//
+// The order is total and stable, so types can be sorted, e.g. with the comparison operators.
+// Unknown types are ordered before all other types.
+func (v TypeValue) Compare(interpreter *Interpreter, other TypeValue) int {
+	return strings.Compare(v.typeID(interpreter), other.typeID(interpreter))
+}
+
 func (v TypeValue) GetMember(interpreter *Interpreter, _ func() LocationRange, name string) Value {
 	switch name {
 	case "identifier":
-		var typeID string
-		staticType := v.Type
-		if staticType != nil {
-			typeID = string(interpreter.MustConvertStaticToSemaType(staticType).ID())
-		}
+		typeID := v.typeID(interpreter)
 		memoryUsage := common.MemoryUsage{
 			Kind:   common.MemoryKindStringValue,
 			Amount: uint64(len(typeID)),
@@ -0,0 +1,446 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/hex"
+	goJSON "encoding/json"
+	goErrors "errors"
+	"math/big"
+	"strconv"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/errors"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// InterpreterValueJSONCDCType returns the top-level JSON-CDC type of the given runtime value,
+// e.g. "Struct" or "Array", i.e. the type the value would have when exported and encoded as JSON-CDC.
+//
+// The type is determined from the value and its static type,
+// without exporting the value.
+func InterpreterValueJSONCDCType(value interpreter.Value, inter *interpreter.Interpreter) (string, error) {
+	switch v := value.(type) {
+	case interpreter.VoidValue:
+		return "Void", nil
+	case interpreter.NilValue, *interpreter.SomeValue:
+		return "Optional", nil
+	case interpreter.BoolValue:
+		return "Bool", nil
+	case *interpreter.StringValue:
+		return "String", nil
+	case interpreter.CharacterValue:
+		return "Character", nil
+	case interpreter.AddressValue:
+		return "Address", nil
+	case interpreter.NumberValue:
+		staticType, ok := v.StaticType(inter).(interpreter.PrimitiveStaticType)
+		if !ok {
+			return "", errors.NewUnexpectedError("invalid static type for number value: %s", v.StaticType(inter))
+		}
+		return staticType.String(), nil
+	case *interpreter.ArrayValue:
+		return "Array", nil
+	case *interpreter.DictionaryValue:
+		return "Dictionary", nil
+	case *interpreter.CompositeValue:
+		return compositeKindJSONCDCType(v.Kind)
+	case *interpreter.SimpleCompositeValue:
+		staticType, err := inter.ConvertStaticToSemaType(v.StaticType(inter))
+		if err != nil {
+			return "", err
+		}
+
+		compositeType, ok := staticType.(*sema.CompositeType)
+		if !ok {
+			return "", errors.NewUnexpectedError(
+				"unexportable composite value: %s",
+				staticType,
+			)
+		}
+		return compositeKindJSONCDCType(compositeType.Kind)
+	case interpreter.LinkValue:
+		return "Link", nil
+	case interpreter.PathValue:
+		return "Path", nil
+	case interpreter.TypeValue:
+		return "Type", nil
+	case *interpreter.CapabilityValue:
+		return "Capability", nil
+	case *interpreter.EphemeralReferenceValue:
+		// References are exported as the referenced value
+		return InterpreterValueJSONCDCType(v.Value, inter)
+	case *interpreter.StorageReferenceValue:
+		// References are exported as the referenced value
+		referencedValue := v.ReferencedValue(inter)
+		if referencedValue == nil {
+			return "", errors.NewDefaultUserError("cannot determine JSON-CDC type of dangling reference")
+		}
+		return InterpreterValueJSONCDCType(*referencedValue, inter)
+	default:
+		return "", errors.NewUnexpectedError("cannot export value of type %T", value)
+	}
+}
+
+func compositeKindJSONCDCType(kind common.CompositeKind) (string, error) {
+	switch kind {
+	case common.CompositeKindStructure:
+		return "Struct", nil
+	case common.CompositeKindResource:
+		return "Resource", nil
+	case common.CompositeKindEvent:
+		return "Event", nil
+	case common.CompositeKindContract:
+		return "Contract", nil
+	case common.CompositeKindEnum:
+		return "Enum", nil
+	default:
+		return "", errors.NewDefaultUserError("invalid composite kind `%s`", kind)
+	}
+}
+
+// ImportJSONCDC decodes the given JSON-CDC encoded value and imports it as a runtime value
+// of the given expected type, i.e. it is equivalent to json.Decode followed by an import.
+//
+// Simple top-level values, e.g. integers, strings, and addresses, which are encoded
+// with the expected type, are imported directly, without decoding them to an intermediate
+// cadence.Value first. All other values, and all invalid encodings, are decoded and imported
+// in two steps, so errors are the same.
+//
+// Decoding errors are wrapped in a JSONCDCDecodingError,
+// which contains the byte offset in the given data where the offending value begins.
+//
+// NOTE: errors of the import of a successfully decoded value, e.g. a nested value
+// which does not have the expected type, are returned as they are, without an offset,
+// as the decoded value does not keep track of the offsets of its nested values.
+func ImportJSONCDC(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	data []byte,
+	expectedType sema.Type,
+) (interpreter.Value, error) {
+	if value, ok := importSimpleJSONCDC(inter, data, expectedType); ok {
+		return value, nil
+	}
+
+	value, err := json.Decode(inter, data)
+	if err != nil {
+		return nil, &JSONCDCDecodingError{
+			Offset: jsonCDCErrorOffset(data, err),
+			Err:    err,
+		}
+	}
+
+	return importValue(inter, getLocationRange, value, expectedType)
+}
+
+// jsonCDCErrorOffset returns the byte offset in the given data
+// where the value begins which caused the given decoding error.
+//
+// The JSON-CDC decoder does not keep track of offsets,
+// so the offending value is located after the fact:
+// it is the innermost encoded value which cannot be decoded on its own.
+func jsonCDCErrorOffset(data []byte, err error) int64 {
+	var syntaxErr *goJSON.SyntaxError
+	if goErrors.As(err, &syntaxErr) {
+		// NOTE: the offset of a syntax error is the offset after the offending byte
+		if syntaxErr.Offset > 0 {
+			return syntaxErr.Offset - 1
+		}
+		return 0
+	}
+
+	start := int64(len(data) - len(bytes.TrimLeft(data, " \t\r\n")))
+
+	var raw goJSON.RawMessage
+	if goJSON.NewDecoder(bytes.NewReader(data[start:])).Decode(&raw) != nil {
+		return start
+	}
+
+	offset, ok := locateInvalidJSONCDCValue(raw, start)
+	if !ok {
+		return start
+	}
+	return offset
+}
+
+// locateInvalidJSONCDCValue returns the offset of the innermost encoded value
+// in the given JSON value, which begins at the given offset, that cannot be decoded on its own.
+//
+// Only JSON objects which have a type key, and which are not static types (i.e. have no kind key),
+// are considered encoded values. Other JSON objects and arrays, e.g. dictionary entries,
+// are only searched for encoded values.
+func locateInvalidJSONCDCValue(data goJSON.RawMessage, offset int64) (int64, bool) {
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return 0, false
+	}
+
+	type member struct {
+		key    string
+		data   goJSON.RawMessage
+		offset int64
+	}
+
+	var members []member
+
+	decoder := goJSON.NewDecoder(bytes.NewReader(data))
+
+	// Opening delimiter
+	if _, err := decoder.Token(); err != nil {
+		return 0, false
+	}
+
+	for decoder.More() {
+		var key string
+		if data[0] == '{' {
+			token, err := decoder.Token()
+			if err != nil {
+				return 0, false
+			}
+			key, _ = token.(string)
+		}
+
+		var value goJSON.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return 0, false
+		}
+
+		// The decoder is positioned at the end of the value
+		valueOffset := decoder.InputOffset() - int64(len(value))
+
+		members = append(members, member{
+			key:    key,
+			data:   value,
+			offset: offset + valueOffset,
+		})
+	}
+
+	isValue := false
+	if data[0] == '{' {
+		hasType, hasKind := false, false
+		for _, member := range members {
+			switch member.key {
+			case "type":
+				hasType = true
+			case "kind":
+				hasKind = true
+			}
+		}
+		isValue = hasType && !hasKind
+	}
+
+	if isValue {
+		if _, err := json.Decode(nil, data); err == nil {
+			return 0, false
+		}
+	}
+
+	for _, member := range members {
+		if memberOffset, ok := locateInvalidJSONCDCValue(member.data, member.offset); ok {
+			return memberOffset, true
+		}
+	}
+
+	if isValue {
+		return offset, true
+	}
+
+	return 0, false
+}
+
+// importSimpleJSONCDC imports the given JSON-CDC encoded value directly,
+// if it is a simple value which is encoded with the given expected type.
+//
+// The result is false if the value cannot be imported directly,
+// e.g. because it has a different type, or because it is invalid.
+func importSimpleJSONCDC(
+	inter *interpreter.Interpreter,
+	data []byte,
+	expectedType sema.Type,
+) (interpreter.Value, bool) {
+	if expectedType == nil {
+		return nil, false
+	}
+
+	// NOTE: decode the same way as the JSON-CDC decoder,
+	// i.e. only the first JSON value, which must be an object
+	// with exactly the two keys "type" and "value"
+
+	var object map[string]goJSON.RawMessage
+	err := goJSON.NewDecoder(bytes.NewReader(data)).Decode(&object)
+	if err != nil || len(object) != 2 {
+		return nil, false
+	}
+
+	typeID, ok := jsonCDCString(object["type"])
+	if !ok || typeID != string(expectedType.ID()) {
+		return nil, false
+	}
+
+	valueJSON, ok := object["value"]
+	if !ok {
+		return nil, false
+	}
+
+	if expectedType == sema.BoolType {
+		switch string(valueJSON) {
+		case "true":
+			return interpreter.NewBoolValue(inter, true), true
+		case "false":
+			return interpreter.NewBoolValue(inter, false), true
+		default:
+			return nil, false
+		}
+	}
+
+	// All other simple values are encoded as strings
+
+	valueString, ok := jsonCDCString(valueJSON)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := expectedType.(*sema.AddressType); ok {
+		// must include 0x prefix
+		if len(valueString) < 2 || valueString[:2] != "0x" {
+			return nil, false
+		}
+		b, err := hex.DecodeString(valueString[2:])
+		if err != nil || len(b) > cadence.AddressLength {
+			return nil, false
+		}
+		return importAddress(inter, cadence.BytesToAddress(b)), true
+	}
+
+	switch expectedType {
+	case sema.StringType:
+		str, err := cadence.NewString(valueString)
+		if err != nil {
+			return nil, false
+		}
+		return importString(inter, str), true
+
+	case sema.IntType, sema.UIntType:
+		i, ok := new(big.Int).SetString(valueString, 10)
+		if !ok {
+			return nil, false
+		}
+		if expectedType == sema.IntType {
+			return importInt(inter, cadence.Int{Value: i}), true
+		}
+		if i.Sign() < 0 {
+			return nil, false
+		}
+		return importUInt(inter, cadence.UInt{Value: i}), true
+
+	case sema.Int8Type, sema.Int16Type, sema.Int32Type, sema.Int64Type:
+		i, err := strconv.ParseInt(valueString, 10, jsonCDCIntegerBitSize(expectedType))
+		if err != nil {
+			return nil, false
+		}
+		switch expectedType {
+		case sema.Int8Type:
+			return importInt8(inter, cadence.Int8(i)), true
+		case sema.Int16Type:
+			return importInt16(inter, cadence.Int16(i)), true
+		case sema.Int32Type:
+			return importInt32(inter, cadence.Int32(i)), true
+		default:
+			return importInt64(inter, cadence.Int64(i)), true
+		}
+
+	case sema.UInt8Type, sema.UInt16Type, sema.UInt32Type, sema.UInt64Type,
+		sema.Word8Type, sema.Word16Type, sema.Word32Type, sema.Word64Type:
+
+		i, err := strconv.ParseUint(valueString, 10, jsonCDCIntegerBitSize(expectedType))
+		if err != nil {
+			return nil, false
+		}
+		switch expectedType {
+		case sema.UInt8Type:
+			return importUInt8(inter, cadence.UInt8(i)), true
+		case sema.UInt16Type:
+			return importUInt16(inter, cadence.UInt16(i)), true
+		case sema.UInt32Type:
+			return importUInt32(inter, cadence.UInt32(i)), true
+		case sema.UInt64Type:
+			return importUInt64(inter, cadence.UInt64(i)), true
+		case sema.Word8Type:
+			return importWord8(inter, cadence.Word8(i)), true
+		case sema.Word16Type:
+			return importWord16(inter, cadence.Word16(i)), true
+		case sema.Word32Type:
+			return importWord32(inter, cadence.Word32(i)), true
+		default:
+			return importWord64(inter, cadence.Word64(i)), true
+		}
+
+	case sema.Fix64Type:
+		v, err := cadence.NewFix64(valueString)
+		if err != nil {
+			return nil, false
+		}
+		return importFix64(inter, v), true
+
+	case sema.UFix64Type:
+		v, err := cadence.NewUFix64(valueString)
+		if err != nil {
+			return nil, false
+		}
+		return importUFix64(inter, v), true
+
+	default:
+		return nil, false
+	}
+}
+
+// jsonCDCString returns the string encoded in the given JSON value, if any.
+func jsonCDCString(data goJSON.RawMessage) (string, bool) {
+	// NOTE: null can be unmarshaled into a string, so explicitly require a string
+	if len(data) == 0 || data[0] != '"' {
+		return "", false
+	}
+
+	var result string
+	err := goJSON.Unmarshal(data, &result)
+	if err != nil {
+		return "", false
+	}
+	return result, true
+}
+
+// jsonCDCIntegerBitSize returns the bit size of the given fixed-size integer type.
+func jsonCDCIntegerBitSize(t sema.Type) int {
+	switch t {
+	case sema.Int8Type, sema.UInt8Type, sema.Word8Type:
+		return 8
+	case sema.Int16Type, sema.UInt16Type, sema.Word16Type:
+		return 16
+	case sema.Int32Type, sema.UInt32Type, sema.Word32Type:
+		return 32
+	case sema.Int64Type, sema.UInt64Type, sema.Word64Type:
+		return 64
+	default:
+		panic(errors.NewUnreachableError())
+	}
+}
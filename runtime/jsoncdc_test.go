@@ -0,0 +1,453 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	goJSON "encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+	. "github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestInterpreterValueJSONCDCType(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct S {}
+
+      pub enum E: UInt8 {
+          pub case a
+      }
+    `
+
+	type testCase struct {
+		label        string
+		expected     string
+		valueFactory func(*interpreter.Interpreter) interpreter.Value
+	}
+
+	testCases := []testCase{
+		{
+			label:    "Void",
+			expected: "Void",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.VoidValue{}
+			},
+		},
+		{
+			label:    "nil",
+			expected: "Optional",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NilValue{}
+			},
+		},
+		{
+			label:    "Some",
+			expected: "Optional",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredSomeValueNonCopying(
+					interpreter.NewUnmeteredIntValueFromInt64(1),
+				)
+			},
+		},
+		{
+			label:    "Bool",
+			expected: "Bool",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.BoolValue(true)
+			},
+		},
+		{
+			label:    "String",
+			expected: "String",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredStringValue("foo")
+			},
+		},
+		{
+			label:    "Int8",
+			expected: "Int8",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredInt8Value(1)
+			},
+		},
+		{
+			label:    "UInt256",
+			expected: "UInt256",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredUInt256ValueFromUint64(1)
+			},
+		},
+		{
+			label:    "UFix64",
+			expected: "UFix64",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredUFix64Value(1)
+			},
+		},
+		{
+			label:    "Array",
+			expected: "Array",
+			valueFactory: func(inter *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewArrayValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					interpreter.VariableSizedStaticType{
+						Type: interpreter.PrimitiveStaticTypeInt,
+					},
+					common.Address{},
+				)
+			},
+		},
+		{
+			label:    "Dictionary",
+			expected: "Dictionary",
+			valueFactory: func(inter *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewDictionaryValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					interpreter.DictionaryStaticType{
+						KeyType:   interpreter.PrimitiveStaticTypeString,
+						ValueType: interpreter.PrimitiveStaticTypeInt,
+					},
+				)
+			},
+		},
+		{
+			label:    "Struct",
+			expected: "Struct",
+			valueFactory: func(inter *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewCompositeValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					TestLocation,
+					"S",
+					common.CompositeKindStructure,
+					nil,
+					common.Address{},
+				)
+			},
+		},
+		{
+			label:    "Enum",
+			expected: "Enum",
+			valueFactory: func(inter *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewCompositeValue(
+					inter,
+					interpreter.ReturnEmptyLocationRange,
+					TestLocation,
+					"E",
+					common.CompositeKindEnum,
+					[]interpreter.CompositeField{
+						{
+							Name:  sema.EnumRawValueFieldName,
+							Value: interpreter.NewUnmeteredUInt8Value(0),
+						},
+					},
+					common.Address{},
+				)
+			},
+		},
+		{
+			label:    "Path",
+			expected: "Path",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.PathValue{
+					Domain:     common.PathDomainStorage,
+					Identifier: "foo",
+				}
+			},
+		},
+		{
+			label:    "Type",
+			expected: "Type",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.TypeValue{
+					Type: interpreter.PrimitiveStaticTypeInt,
+				}
+			},
+		},
+		{
+			label:    "reference",
+			expected: "Int8",
+			valueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+				return interpreter.NewUnmeteredEphemeralReferenceValue(
+					false,
+					interpreter.NewUnmeteredInt8Value(1),
+					sema.Int8Type,
+				)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := newTestInterpreterWithProgram(t, code)
+
+			value := testCase.valueFactory(inter)
+
+			actual, err := InterpreterValueJSONCDCType(value, inter)
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.expected, actual)
+
+			// The type must match the type in the encoding of the exported value
+
+			exported, err := ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+			require.NoError(t, err)
+
+			encoded, err := json.Encode(exported)
+			require.NoError(t, err)
+
+			var decoded struct {
+				Type string `json:"type"`
+			}
+			err = goJSON.Unmarshal(encoded, &decoded)
+			require.NoError(t, err)
+
+			assert.Equal(t, decoded.Type, actual)
+		})
+	}
+
+	t.Run("function", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := newTestInterpreter(t)
+
+		value := interpreter.NewUnmeteredHostFunctionValue(
+			func(invocation interpreter.Invocation) interpreter.Value {
+				return interpreter.VoidValue{}
+			},
+			&sema.FunctionType{
+				ReturnTypeAnnotation: sema.NewTypeAnnotation(sema.VoidType),
+			},
+		)
+
+		_, err := InterpreterValueJSONCDCType(value, inter)
+		require.Error(t, err)
+	})
+}
+
+func TestImportJSONCDC(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		label        string
+		data         string
+		expectedType sema.Type
+	}
+
+	testCases := []testCase{
+		{"Bool", `{"type":"Bool","value":true}`, sema.BoolType},
+		{"String", `{"type":"String","value":"foo"}`, sema.StringType},
+		{"Address", `{"type":"Address","value":"0x1"}`, &sema.AddressType{}},
+		{"Int", `{"type":"Int","value":"-123456789012345678901234567890"}`, sema.IntType},
+		{"UInt", `{"type":"UInt","value":"123456789012345678901234567890"}`, sema.UIntType},
+		{"Int8", `{"type":"Int8","value":"-128"}`, sema.Int8Type},
+		{"Int16", `{"type":"Int16","value":"-2"}`, sema.Int16Type},
+		{"Int32", `{"type":"Int32","value":"3"}`, sema.Int32Type},
+		{"Int64", `{"type":"Int64","value":"-4"}`, sema.Int64Type},
+		{"UInt8", `{"type":"UInt8","value":"255"}`, sema.UInt8Type},
+		{"UInt16", `{"type":"UInt16","value":"6"}`, sema.UInt16Type},
+		{"UInt32", `{"type":"UInt32","value":"7"}`, sema.UInt32Type},
+		{"UInt64", `{"type":"UInt64","value":"18446744073709551615"}`, sema.UInt64Type},
+		{"Word8", `{"type":"Word8","value":"9"}`, sema.Word8Type},
+		{"Word16", `{"type":"Word16","value":"10"}`, sema.Word16Type},
+		{"Word32", `{"type":"Word32","value":"11"}`, sema.Word32Type},
+		{"Word64", `{"type":"Word64","value":"12"}`, sema.Word64Type},
+		{"Fix64", `{"type":"Fix64","value":"-1.50000000"}`, sema.Fix64Type},
+		{"UFix64", `{"type":"UFix64","value":"1.50000000"}`, sema.UFix64Type},
+		{"Int128", `{"type":"Int128","value":"-13"}`, sema.Int128Type},
+		{
+			"Optional",
+			`{"type":"Optional","value":{"type":"Int","value":"1"}}`,
+			&sema.OptionalType{Type: sema.IntType},
+		},
+		{
+			"Array",
+			`{"type":"Array","value":[{"type":"UInt8","value":"1"},{"type":"UInt8","value":"2"}]}`,
+			&sema.VariableSizedType{Type: sema.UInt8Type},
+		},
+		{"Int as Int64", `{"type":"Int","value":"1"}`, sema.Int64Type},
+		{"no expected type", `{"type":"Int","value":"1"}`, nil},
+		{"invalid JSON", `{"type":"Int",`, sema.IntType},
+		{"invalid Int8", `{"type":"Int8","value":"128"}`, sema.Int8Type},
+		{"invalid UInt", `{"type":"UInt","value":"-1"}`, sema.UIntType},
+		{"invalid Address", `{"type":"Address","value":"1"}`, &sema.AddressType{}},
+		{"invalid Bool", `{"type":"Bool","value":"true"}`, sema.BoolType},
+		{"null value", `{"type":"String","value":null}`, sema.StringType},
+		{"extra key", `{"type":"Int","value":"1","foo":"bar"}`, sema.IntType},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := newTestInterpreter(t)
+
+			data := []byte(testCase.data)
+
+			// The result must be the same as when decoding and importing in two steps
+
+			actual, err := ImportJSONCDC(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				data,
+				testCase.expectedType,
+			)
+
+			decoded, decodeErr := json.Decode(inter, data)
+			if decodeErr != nil {
+				require.Error(t, err)
+
+				var decodingErr *JSONCDCDecodingError
+				require.ErrorAs(t, err, &decodingErr)
+				assert.Equal(t, decodeErr.Error(), decodingErr.Err.Error())
+				return
+			}
+
+			expected, importErr := importValue(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				decoded,
+				testCase.expectedType,
+			)
+			if importErr != nil {
+				require.Error(t, err)
+				assert.Equal(t, importErr.Error(), err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			AssertValuesEqual(t, inter, expected, actual)
+		})
+	}
+}
+
+func TestImportJSONCDCErrorOffset(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		label          string
+		data           string
+		expectedOffset int64
+	}
+
+	const array = `{"type":"Array","value":[{"type":"Int","value":"1"},`
+
+	const dictionary = `{"type":"Dictionary","value":[{"key":{"type":"String","value":"a"},"value":`
+
+	testCases := []testCase{
+		{
+			label:          "invalid top-level value",
+			data:           ` {"type":"Int8","value":"128"}`,
+			expectedOffset: 1,
+		},
+		{
+			label:          "invalid array element",
+			data:           array + `{"type":"Int8","value":"128"}]}`,
+			expectedOffset: int64(len(array)),
+		},
+		{
+			label:          "invalid dictionary value",
+			data:           dictionary + `{"type":"Foo","value":"1"}}]}`,
+			expectedOffset: int64(len(dictionary)),
+		},
+		{
+			label:          "malformed JSON",
+			data:           array + `{"type":"Int","value":}]}`,
+			expectedOffset: int64(len(array) + len(`{"type":"Int","value":`)),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := newTestInterpreter(t)
+
+			_, err := ImportJSONCDC(
+				inter,
+				interpreter.ReturnEmptyLocationRange,
+				[]byte(testCase.data),
+				nil,
+			)
+			require.Error(t, err)
+			assertUserError(t, err)
+
+			var decodingErr *JSONCDCDecodingError
+			require.ErrorAs(t, err, &decodingErr)
+			assert.Equal(t, testCase.expectedOffset, decodingErr.Offset)
+		})
+	}
+}
+
+func BenchmarkImportJSONCDC(b *testing.B) {
+
+	inter := newTestInterpreter(b)
+
+	data := []byte(`{"type":"UInt64","value":"18446744073709551615"}`)
+
+	b.Run("two steps", func(b *testing.B) {
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			value, err := json.Decode(inter, data)
+			require.NoError(b, err)
+
+			_, err = importValue(inter, interpreter.ReturnEmptyLocationRange, value, sema.UInt64Type)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("one step", func(b *testing.B) {
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, err := ImportJSONCDC(inter, interpreter.ReturnEmptyLocationRange, data, sema.UInt64Type)
+			require.NoError(b, err)
+		}
+	})
+}
@@ -19,7 +19,11 @@
 package runtime
 
 import (
+	"fmt"
 	"sort"
+	"strings"
+
+	"github.com/onflow/cadence"
 
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/cmd"
@@ -31,28 +35,63 @@ import (
 	"github.com/onflow/cadence/runtime/stdlib"
 )
 
+// REPLLastResultName is the name of the constant
+// which refers to the result of the last evaluated expression statement
+const REPLLastResultName = "_"
+
 type REPL struct {
 	checker  *sema.Checker
 	inter    *interpreter.Interpreter
 	onError  func(err error, location common.Location, codes map[common.Location]string)
 	onResult func(interpreter.Value)
 	codes    map[common.Location]string
+	logs     []string
+	// computationLimit is the maximum computation of one call of Accept,
+	// see SetComputationLimit
+	computationLimit uint
+	computationUsed  uint
+}
+
+// REPLOption is an option for a REPL, see NewREPL.
+type REPLOption func(*REPL)
+
+// WithREPLComputationLimit returns a REPL option that sets the maximum computation
+// of the code given in one call of Accept, see REPL.SetComputationLimit.
+func WithREPLComputationLimit(limit uint) REPLOption {
+	return func(repl *REPL) {
+		repl.SetComputationLimit(limit)
+	}
 }
 
 func NewREPL(
 	onError func(err error, location common.Location, codes map[common.Location]string),
 	onResult func(interpreter.Value),
 	checkerOptions []sema.Option,
+	options ...REPLOption,
 ) (*REPL, error) {
 
 	checkers := map[common.Location]*sema.Checker{}
 	codes := map[common.Location]string{}
 
+	var repl *REPL
+
+	// Buffer the output of log calls, see Logs
+
+	impls := stdlib.DefaultFlowBuiltinImpls()
+	impls.Log = func(invocation interpreter.Invocation) interpreter.Value {
+		message := invocation.Arguments[0].MeteredString(
+			invocation.Interpreter,
+			interpreter.SeenReferences{},
+		)
+		repl.logs = append(repl.logs, message)
+		return interpreter.NewVoidValue(invocation.Interpreter)
+	}
+
 	defaultCheckerOptions, defaultInterpreterOptions :=
 		cmd.DefaultCheckerInterpreterOptions(
 			checkers,
 			codes,
-			stdlib.DefaultFlowBuiltinImpls(),
+			impls,
 		)
 
 	defaultCheckerOptions = append(
@@ -89,6 +128,16 @@ func NewREPL(
 			defer func() { uuid++ }()
 			return uuid, nil
 		}),
+		interpreter.WithOnMeterComputationFuncHandler(
+			func(_ common.ComputationKind, intensity uint) {
+				// The predeclared values may already be computed
+				// while the interpreter is created
+				if repl == nil {
+					return
+				}
+				repl.meterComputation(intensity)
+			},
+		),
 	}
 
 	interpreterOptions = append(
@@ -105,40 +154,460 @@ func NewREPL(
 		return nil, err
 	}
 
-	repl := &REPL{
+	repl = &REPL{
 		checker:  checker,
 		inter:    inter,
 		onError:  onError,
 		onResult: onResult,
 		codes:    codes,
 	}
+
+	// Declare the last result, see REPLLastResultName.
+	// Its type and value are updated after each evaluated expression statement.
+
+	err = repl.declare(stdlib.StandardLibraryValue{
+		Name:      REPLLastResultName,
+		Type:      sema.VoidType,
+		DocString: "The result of the last evaluated expression",
+		ValueFactory: func(inter *interpreter.Interpreter) interpreter.Value {
+			return interpreter.NewVoidValue(inter)
+		},
+		Kind: common.DeclarationKindConstant,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the options are applied after the REPL is initialized,
+	// so the computation of the initialization is not limited
+
+	for _, option := range options {
+		option(repl)
+	}
+
 	return repl, nil
 }
 
+// SetComputationLimit sets the maximum computation of the code given in one call of Accept.
+// The evaluation is aborted with a REPLComputationLimitExceededError,
+// which is reported through the error handler, when the limit is exceeded.
+// A limit of zero disables the limit, which is the default.
+func (r *REPL) SetComputationLimit(limit uint) {
+	r.computationLimit = limit
+}
+
+func (r *REPL) meterComputation(intensity uint) {
+	if r.computationLimit == 0 {
+		return
+	}
+
+	r.computationUsed += intensity
+	if r.computationUsed > r.computationLimit {
+		panic(REPLComputationLimitExceededError{
+			Limit: r.computationLimit,
+		})
+	}
+}
+
+// REPLComputationLimitExceededError is reported by the REPL
+// when the evaluation of the code exceeds the computation limit, see REPL.SetComputationLimit.
+type REPLComputationLimitExceededError struct {
+	Limit uint
+}
+
+var _ errors.UserError = REPLComputationLimitExceededError{}
+
+func (REPLComputationLimitExceededError) IsUserError() {}
+
+func (e REPLComputationLimitExceededError) Error() string {
+	return fmt.Sprintf("computation limit exceeded: %d", e.Limit)
+}
+
 func (r *REPL) handleCheckerError() bool {
 	err := r.checker.CheckerError()
 	if err == nil {
 		return true
 	}
 	if r.onError != nil {
-		r.onError(err, r.checker.Location, r.codes)
+		r.onError(withMemberSuggestions(err), r.checker.Location, r.codes)
 	}
 	return false
 }
 
-func (r *REPL) execute(element ast.Element) {
+// REPLLastResultInFunctionError is reported by the REPL
+// when a function refers to the last result, see REPLLastResultName.
+//
+// The type of the last result changes after each evaluated expression statement,
+// so functions, which may be called later, cannot refer to it.
+type REPLLastResultInFunctionError struct {
+	ast.Range
+}
+
+var _ errors.UserError = &REPLLastResultInFunctionError{}
+
+func (*REPLLastResultInFunctionError) IsUserError() {}
+
+func (*REPLLastResultInFunctionError) Error() string {
+	return fmt.Sprintf(
+		"cannot refer to the last result `%s` in a function",
+		REPLLastResultName,
+	)
+}
+
+// checkLastResultReferences reports a REPLLastResultInFunctionError
+// if a function in the given element refers to the last result.
+func (r *REPL) checkLastResultReferences(element ast.Element, code string) bool {
+	finder := lastResultReferenceFinder{
+		references: new([]*ast.IdentifierExpression),
+	}
+
+	ast.Walk(finder, element)
+
+	if len(*finder.references) == 0 {
+		return true
+	}
+
+	if r.onError != nil {
+		r.codes[r.checker.Location] = code
+		reference := (*finder.references)[0]
+		r.onError(
+			&REPLLastResultInFunctionError{
+				Range: ast.NewRangeFromPositioned(nil, reference),
+			},
+			r.checker.Location,
+			r.codes,
+		)
+	}
+	return false
+}
+
+// lastResultReferenceFinder is an ast.Walker which finds references to the last result
+// in functions, see checkLastResultReferences.
+//
+// The identifiers are matched by name, so a local variable of a function
+// which has the same name as the last result is also found.
+type lastResultReferenceFinder struct {
+	inFunction bool
+	references *[]*ast.IdentifierExpression
+}
+
+func (f lastResultReferenceFinder) Walk(element ast.Element) ast.Walker {
+	switch element := element.(type) {
+	case *ast.FunctionDeclaration,
+		*ast.SpecialFunctionDeclaration,
+		*ast.FunctionExpression:
+
+		f.inFunction = true
+
+	case *ast.TransactionDeclaration:
+		f.inFunction = true
+
+		// The conditions are not walked, see TransactionDeclaration.Walk
+		f.walkConditions(element.PreConditions)
+		f.walkConditions(element.PostConditions)
+
+	case *ast.FunctionBlock:
+		// The conditions are not walked, see FunctionBlock.Walk
+		f.walkConditions(element.PreConditions)
+		f.walkConditions(element.PostConditions)
+
+	case *ast.IdentifierExpression:
+		if f.inFunction && element.Identifier.Identifier == REPLLastResultName {
+			*f.references = append(*f.references, element)
+		}
+	}
+
+	return f
+}
+
+func (f lastResultReferenceFinder) walkConditions(conditions *ast.Conditions) {
+	if conditions == nil {
+		return
+	}
+
+	for _, condition := range *conditions {
+		ast.Walk(f, condition.Test)
+		if condition.Message != nil {
+			ast.Walk(f, condition.Message)
+		}
+	}
+}
+
+// REPLMaxMemberSuggestions is the maximum number of suggested member names
+// of a REPLNotDeclaredMemberError
+const REPLMaxMemberSuggestions = 3
+
+// REPLNotDeclaredMemberError is reported by the REPL instead of a sema.NotDeclaredMemberError.
+// It additionally suggests the member names of the receiver's type
+// which are nearest to the unknown member name, by edit distance.
+type REPLNotDeclaredMemberError struct {
+	*sema.NotDeclaredMemberError
+	Suggestions []string
+}
+
+func (e *REPLNotDeclaredMemberError) SecondaryError() string {
+	secondaryError := e.NotDeclaredMemberError.SecondaryError()
+	if len(e.Suggestions) == 0 {
+		return secondaryError
+	}
+
+	quoted := make([]string, len(e.Suggestions))
+	for i, suggestion := range e.Suggestions {
+		quoted[i] = fmt.Sprintf("`%s`", suggestion)
+	}
+
+	return fmt.Sprintf(
+		"%s, did you mean %s?",
+		secondaryError,
+		strings.Join(quoted, ", "),
+	)
+}
+
+// withMemberSuggestions returns a copy of the given checker error,
+// in which all unknown member errors are replaced by errors with suggestions.
+func withMemberSuggestions(err *sema.CheckerError) *sema.CheckerError {
+	errs := make([]error, len(err.Errors))
+	for i, childErr := range err.Errors {
+		if notDeclaredMemberErr, ok := childErr.(*sema.NotDeclaredMemberError); ok {
+			childErr = &REPLNotDeclaredMemberError{
+				NotDeclaredMemberError: notDeclaredMemberErr,
+				Suggestions:            memberSuggestions(notDeclaredMemberErr.Type, notDeclaredMemberErr.Name),
+			}
+		}
+		errs[i] = childErr
+	}
+
+	result := *err
+	result.Errors = errs
+	return &result
+}
+
+// memberSuggestions returns the names of the members of the given type
+// which are nearest to the given name by edit distance,
+// at most REPLMaxMemberSuggestions, nearest first.
+func memberSuggestions(ty sema.Type, name string) []string {
+	if ty == nil {
+		return nil
+	}
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	var candidates []candidate
+
+	// Iterating over the dictionary of members is safe,
+	// as the candidates are sorted afterwards
+
+	for memberName := range ty.GetMembers() { //nolint:maprangecheck
+		candidates = append(candidates, candidate{
+			name:     memberName,
+			distance: editDistance(name, memberName),
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a := candidates[i]
+		b := candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		return a.name < b.name
+	})
+
+	if len(candidates) > REPLMaxMemberSuggestions {
+		candidates = candidates[:REPLMaxMemberSuggestions]
+	}
+
+	result := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		result[i] = candidate.name
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between the given strings,
+// i.e. the minimum number of single-character insertions, deletions, and substitutions
+// required to change one string into the other.
+func editDistance(a, b string) int {
+	as := []rune(a)
+	bs := []rune(b)
+
+	previous := make([]int, len(bs)+1)
+	current := make([]int, len(bs)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(as); i++ {
+		current[0] = i
+		for j := 1; j <= len(bs); j++ {
+			substitutionCost := 1
+			if as[i-1] == bs[j-1] {
+				substitutionCost = 0
+			}
+			distance := previous[j-1] + substitutionCost
+			if deletion := previous[j] + 1; deletion < distance {
+				distance = deletion
+			}
+			if insertion := current[j-1] + 1; insertion < distance {
+				distance = insertion
+			}
+			current[j] = distance
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(bs)]
+}
+
+func (r *REPL) execute(element ast.Element) (ok bool) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		err, isLimitErr := recovered.(REPLComputationLimitExceededError)
+		if !isLimitErr {
+			panic(recovered)
+		}
+
+		if r.onError != nil {
+			r.onError(err, r.checker.Location, r.codes)
+		}
+		ok = false
+	}()
+
 	result := element.Accept(r.inter)
-	expStatementRes, ok := result.(interpreter.ExpressionStatementResult)
+	expStatementRes, isExpressionStatementResult := result.(interpreter.ExpressionStatementResult)
+	if !isExpressionStatementResult {
+		return true
+	}
+	r.setLastResult(expStatementRes.Value)
+	if r.onResult == nil {
+		return true
+	}
+	r.onResult(expStatementRes.Value)
+	return true
+}
+
+// Declare imports the given value and declares it as a constant with the given name,
+// so the code given in subsequent calls of Accept can refer to it,
+// e.g. to provide a value which was computed elsewhere.
+//
+// The type of the constant is the type of the imported value.
+// Composites must have a type which is declared in the REPL.
+// Resources cannot be declared, and the name must not already be declared.
+func (r *REPL) Declare(name string, value cadence.Value) error {
+	if _, ok := r.checker.Elaboration.GlobalValues.Get(name); ok {
+		return errors.NewDefaultUserError(
+			"cannot declare `%s`: already declared",
+			name,
+		)
+	}
+
+	importedValue, err := importValue(
+		r.inter,
+		interpreter.ReturnEmptyLocationRange,
+		value,
+		nil,
+	)
+	if err != nil {
+		return errors.NewDefaultUserError(
+			"cannot declare `%s`: %s",
+			name,
+			err,
+		)
+	}
+
+	semaType, err := r.inter.ConvertStaticToSemaType(importedValue.StaticType(r.inter))
+	if err != nil {
+		return err
+	}
+
+	if semaType.IsResourceType() {
+		return errors.NewDefaultUserError(
+			"cannot declare `%s`: resources cannot be declared",
+			name,
+		)
+	}
+
+	return r.declare(stdlib.StandardLibraryValue{
+		Name: name,
+		Type: semaType,
+		ValueFactory: func(_ *interpreter.Interpreter) interpreter.Value {
+			return importedValue
+		},
+		Kind: common.DeclarationKindConstant,
+	})
+}
+
+// declare declares the given value in the checker and the interpreter.
+//
+// NOTE: the declared value is not a builtin, see Builtins and ExportGlobals,
+// so the predeclared values of the checker and the interpreter are restored
+func (r *REPL) declare(declaration stdlib.StandardLibraryValue) error {
+	predeclaredCheckerValues := r.checker.PredeclaredValues
+	err := sema.WithPredeclaredValues(
+		[]sema.ValueDeclaration{declaration},
+	)(r.checker)
+	r.checker.PredeclaredValues = predeclaredCheckerValues
+	if err != nil {
+		return err
+	}
+
+	predeclaredInterpreterValues := r.inter.PredeclaredValues
+	err = interpreter.WithPredeclaredValues(
+		[]interpreter.ValueDeclaration{declaration},
+	)(r.inter)
+	r.inter.PredeclaredValues = predeclaredInterpreterValues
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setLastResult updates the type and the value of the last result, see REPLLastResultName.
+//
+// Like in other REPLs, void results do not replace the last result.
+// Resources are not bound, as they would be used twice.
+//
+// NOTE: updating the type is only sound because functions cannot refer to the last result,
+// i.e. only the code of the top-level statements, which is checked right before it is executed,
+// see checkLastResultReferences
+func (r *REPL) setLastResult(value interpreter.Value) {
+	if _, ok := value.(interpreter.VoidValue); ok {
+		return
+	}
+
+	semaType, err := r.inter.ConvertStaticToSemaType(value.StaticType(r.inter))
+	if err != nil || semaType.IsResourceType() {
+		return
+	}
+
+	checkerVariable, ok := r.checker.Elaboration.GlobalValues.Get(REPLLastResultName)
 	if !ok {
 		return
 	}
-	if r.onResult == nil {
+
+	interpreterVariable, ok := r.inter.Globals.Get(REPLLastResultName)
+	if !ok {
 		return
 	}
-	r.onResult(expStatementRes.Value)
+
+	checkerVariable.Type = semaType
+	interpreterVariable.SetValue(value)
 }
 
 func (r *REPL) check(element ast.Element, code string) bool {
+	if !r.checkLastResultReferences(element, code) {
+		return false
+	}
+
 	element.Accept(r.checker)
 	r.codes[r.checker.Location] = code
 	return r.handleCheckerError()
@@ -149,6 +618,9 @@ func (r *REPL) Accept(code string) (inputIsComplete bool) {
 	// TODO: detect if the input is complete
 	inputIsComplete = true
 
+	r.logs = nil
+	r.computationUsed = 0
+
 	var err error
 	result, errs := parser.ParseStatements(code, nil)
 	if len(errs) > 0 {
@@ -179,7 +651,9 @@ func (r *REPL) Accept(code string) (inputIsComplete bool) {
 				return
 			}
 
-			r.execute(typedElement)
+			if !r.execute(typedElement) {
+				return
+			}
 
 		case ast.Statement:
 			r.checker.Program = nil
@@ -188,7 +662,9 @@ func (r *REPL) Accept(code string) (inputIsComplete bool) {
 				return
 			}
 
-			r.execute(typedElement)
+			if !r.execute(typedElement) {
+				return
+			}
 
 		default:
 			panic(errors.NewUnreachableError())
@@ -198,6 +674,11 @@ func (r *REPL) Accept(code string) (inputIsComplete bool) {
 	return
 }
 
+// Logs returns the output of the log calls of the code given in the last call of Accept.
+func (r *REPL) Logs() []string {
+	return r.logs
+}
+
 type REPLSuggestion struct {
 	Name, Description string
 }
@@ -230,3 +711,94 @@ func (r *REPL) Suggestions() (result []REPLSuggestion) {
 
 	return
 }
+
+// Builtins returns the predeclared standard library functions and values
+// which are available in the REPL, sorted by name.
+func (r *REPL) Builtins() (result []REPLSuggestion) {
+	names := map[string]string{}
+
+	for _, declaration := range r.checker.PredeclaredValues {
+		name := declaration.ValueDeclarationName()
+		if name == REPLLastResultName || names[name] != "" {
+			continue
+		}
+		names[name] = declaration.ValueDeclarationType().String()
+	}
+
+	// Iterating over the dictionary of names is safe,
+	// as the builtin entries are sorted afterwards
+
+	for name, description := range names { //nolint:maprangecheck
+		result = append(result, REPLSuggestion{
+			Name:        name,
+			Description: description,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a := result[i]
+		b := result[j]
+		return a.Name < b.Name
+	})
+
+	return
+}
+
+// ExportGlobals exports the values of all global variables declared in the REPL session, keyed by name.
+//
+// Global variables with values that are not exportable, i.e. functions, including composite constructors,
+// have no entry in the result, and their names are returned as skipped, in declaration order.
+// The predeclared standard library values and the last result are not declared in the session,
+// so they are neither exported nor skipped.
+func (r *REPL) ExportGlobals() (globals map[string]cadence.Value, skipped []string, err error) {
+	builtins := map[string]struct{}{
+		REPLLastResultName: {},
+	}
+	for _, declaration := range r.checker.PredeclaredValues {
+		builtins[declaration.ValueDeclarationName()] = struct{}{}
+	}
+
+	globals = map[string]cadence.Value{}
+
+	r.checker.Elaboration.GlobalValues.Foreach(func(name string, _ *sema.Variable) {
+		if err != nil {
+			return
+		}
+
+		if _, ok := builtins[name]; ok {
+			return
+		}
+
+		variable := r.inter.FindVariable(name)
+		if variable == nil {
+			return
+		}
+
+		value := variable.GetValue()
+
+		// Functions are not exportable
+		if _, ok := value.(interpreter.FunctionValue); ok {
+			skipped = append(skipped, name)
+			return
+		}
+
+		var exported cadence.Value
+		exported, err = ExportValue(value, r.inter, interpreter.ReturnEmptyLocationRange)
+		if err != nil {
+			err = errors.NewDefaultUserError(
+				"cannot export global `%s`: %s",
+				name,
+				err,
+			)
+			return
+		}
+
+		globals[name] = exported
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return globals, skipped, nil
+}
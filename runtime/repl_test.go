@@ -0,0 +1,422 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+func TestREPLBuiltins(t *testing.T) {
+
+	t.Parallel()
+
+	repl, err := NewREPL(nil, nil, nil)
+	require.NoError(t, err)
+
+	builtins := repl.Builtins()
+
+	descriptions := map[string]string{}
+	for _, builtin := range builtins {
+		descriptions[builtin.Name] = builtin.Description
+	}
+
+	for _, name := range []string{"assert", "panic", "log", "getAccount", "unsafeRandom"} {
+		assert.Contains(t, descriptions, name)
+	}
+
+	assert.Equal(t, "((_ condition: Bool, message: String): Void)", descriptions["assert"])
+
+	assert.IsIncreasing(t,
+		func() []string {
+			names := make([]string, len(builtins))
+			for i, builtin := range builtins {
+				names[i] = builtin.Name
+			}
+			return names
+		}(),
+	)
+}
+
+func TestREPLExportGlobals(t *testing.T) {
+
+	t.Parallel()
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			t.Fatal(err)
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`let x = 1`)
+	repl.Accept(`var y: [String] = ["a", "b"]`)
+	repl.Accept(`fun f(): Int { return x }`)
+	repl.Accept(`pub struct S { pub let z: Bool; init() { self.z = true } }`)
+	repl.Accept(`let s = S()`)
+	repl.Accept(`2`)
+
+	globals, skipped, err := repl.ExportGlobals()
+	require.NoError(t, err)
+
+	assert.Equal(t, cadence.NewInt(1), globals["x"])
+
+	assert.Equal(t,
+		cadence.NewArray([]cadence.Value{
+			cadence.String("a"),
+			cadence.String("b"),
+		}).WithType(cadence.VariableSizedArrayType{
+			ElementType: cadence.StringType{},
+		}),
+		globals["y"],
+	)
+
+	require.IsType(t, cadence.Struct{}, globals["s"])
+	assert.Equal(t,
+		[]cadence.Value{cadence.NewBool(true)},
+		globals["s"].(cadence.Struct).Fields,
+	)
+
+	// Functions, including the constructor of the struct, are skipped
+
+	assert.NotContains(t, globals, "f")
+	assert.NotContains(t, globals, "S")
+	assert.Equal(t, []string{"f", "S"}, skipped)
+
+	// Builtins are not session globals
+
+	assert.NotContains(t, globals, "assert")
+	assert.NotContains(t, skipped, "assert")
+
+	// The last result is skipped
+
+	assert.NotContains(t, globals, REPLLastResultName)
+}
+
+func TestREPLMemberSuggestions(t *testing.T) {
+
+	t.Parallel()
+
+	var errs []error
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			errs = append(errs, err)
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`
+      pub struct S {
+          pub let foo: Int
+          pub let bar: Int
+          pub fun fooBar() {}
+
+          init() {
+              self.foo = 1
+              self.bar = 2
+          }
+      }
+    `)
+	require.Empty(t, errs)
+
+	repl.Accept(`S().fooo`)
+	require.Len(t, errs, 1)
+
+	var checkerErr *sema.CheckerError
+	require.ErrorAs(t, errs[0], &checkerErr)
+	require.Len(t, checkerErr.Errors, 1)
+
+	var notDeclaredMemberErr *REPLNotDeclaredMemberError
+	require.ErrorAs(t, checkerErr.Errors[0], &notDeclaredMemberErr)
+
+	assert.Equal(t,
+		[]string{"foo", "fooBar", "bar"},
+		notDeclaredMemberErr.Suggestions,
+	)
+	assert.Equal(t,
+		"unknown member, did you mean `foo`, `fooBar`, `bar`?",
+		notDeclaredMemberErr.SecondaryError(),
+	)
+}
+
+func TestEditDistance(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t, 0, editDistance("foo", "foo"))
+	assert.Equal(t, 3, editDistance("", "foo"))
+	assert.Equal(t, 1, editDistance("fooo", "foo"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
+
+func TestREPLLogs(t *testing.T) {
+
+	t.Parallel()
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			t.Fatal(err)
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`log("hi")`)
+	assert.Equal(t, []string{`"hi"`}, repl.Logs())
+
+	repl.Accept(`fun f() { log(1); log([true]) }`)
+	assert.Empty(t, repl.Logs())
+
+	repl.Accept(`f()`)
+	assert.Equal(t, []string{"1", "[true]"}, repl.Logs())
+}
+
+func TestREPLLastResult(t *testing.T) {
+
+	t.Parallel()
+
+	var results []interpreter.Value
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			t.Fatal(err)
+		},
+		func(value interpreter.Value) {
+			results = append(results, value)
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`1 + 1`)
+	repl.Accept(`_ * 2`)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, interpreter.NewUnmeteredIntValueFromInt64(4), results[1])
+
+	// The type of the last result is updated
+
+	repl.Accept(`"foo"`)
+	repl.Accept(`_.concat("bar")`)
+
+	require.Len(t, results, 4)
+	assert.Equal(t, interpreter.NewUnmeteredStringValue("foobar"), results[3])
+
+	// Void results do not replace the last result
+
+	repl.Accept(`fun f() {}`)
+	repl.Accept(`f()`)
+	repl.Accept(`_.length`)
+
+	require.Len(t, results, 6)
+	assert.Equal(t, interpreter.NewUnmeteredIntValueFromInt64(6), results[5])
+
+	// The last result is not a builtin
+
+	for _, builtin := range repl.Builtins() {
+		assert.NotEqual(t, REPLLastResultName, builtin.Name)
+	}
+}
+
+func TestREPLLastResultInFunction(t *testing.T) {
+
+	t.Parallel()
+
+	var errs []error
+	var results []interpreter.Value
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			errs = append(errs, err)
+		},
+		func(value interpreter.Value) {
+			results = append(results, value)
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`1`)
+	require.Empty(t, errs)
+
+	for _, code := range []string{
+		`fun f(): Int { return _ }`,
+		`let g = fun (): Int { return _ }`,
+		`fun h(): Int { post { result == _ } return 1 }`,
+		`struct S { let x: Int; init() { self.x = _ } }`,
+	} {
+		errs = nil
+
+		repl.Accept(code)
+
+		require.Len(t, errs, 1, code)
+		require.IsType(t, &REPLLastResultInFunctionError{}, errs[0], code)
+	}
+
+	// The rejected function is not declared,
+	// so it cannot be called after the type of the last result changed
+
+	errs = nil
+
+	repl.Accept(`"hello"`)
+	require.Empty(t, errs)
+
+	repl.Accept(`f()`)
+
+	require.Len(t, errs, 1)
+	require.IsType(t, &sema.CheckerError{}, errs[0])
+
+	// Top-level statements may still refer to the last result
+
+	errs = nil
+
+	repl.Accept(`_.length`)
+
+	require.Empty(t, errs)
+	require.Len(t, results, 3)
+	assert.Equal(t, interpreter.NewUnmeteredIntValueFromInt64(5), results[2])
+}
+
+func TestREPLComputationLimit(t *testing.T) {
+
+	t.Parallel()
+
+	var errs []error
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			errs = append(errs, err)
+		},
+		nil,
+		nil,
+		WithREPLComputationLimit(1000),
+	)
+	require.NoError(t, err)
+
+	// The limit applies to the first call of Accept
+
+	repl.Accept("while true {}")
+
+	require.Len(t, errs, 1)
+	require.ErrorAs(t, errs[0], &REPLComputationLimitExceededError{})
+	assert.EqualError(t, errs[0], "computation limit exceeded: 1000")
+
+	// The limit applies to each call of Accept
+
+	errs = nil
+
+	var results []interpreter.Value
+	repl.onResult = func(value interpreter.Value) {
+		results = append(results, value)
+	}
+
+	repl.Accept("1 + 2")
+
+	require.Empty(t, errs)
+	require.Len(t, results, 1)
+	assert.Equal(t, interpreter.NewUnmeteredIntValueFromInt64(3), results[0])
+}
+
+func TestREPLDeclare(t *testing.T) {
+
+	t.Parallel()
+
+	var errs []error
+	var results []interpreter.Value
+
+	repl, err := NewREPL(
+		func(err error, _ common.Location, _ map[common.Location]string) {
+			errs = append(errs, err)
+		},
+		func(value interpreter.Value) {
+			results = append(results, value)
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	repl.Accept(`
+      struct S {
+          let x: Int
+
+          init(x: Int) {
+              self.x = x
+          }
+      }
+    `)
+	require.Empty(t, errs)
+
+	structType := &cadence.StructType{
+		Location:            common.REPLLocation{},
+		QualifiedIdentifier: "S",
+		Fields: []cadence.Field{
+			{
+				Identifier: "x",
+				Type:       cadence.IntType{},
+			},
+		},
+	}
+
+	err = repl.Declare(
+		"s",
+		cadence.NewStruct([]cadence.Value{
+			cadence.NewInt(42),
+		}).WithType(structType),
+	)
+	require.NoError(t, err)
+
+	repl.Accept("s.x")
+
+	require.Empty(t, errs)
+	require.Len(t, results, 1)
+	assert.Equal(t, interpreter.NewUnmeteredIntValueFromInt64(42), results[0])
+
+	// The declared value is a constant
+
+	repl.Accept("s = S(x: 1)")
+
+	require.Len(t, errs, 1)
+	require.IsType(t, &sema.CheckerError{}, errs[0])
+
+	// Declared values are not builtins, but globals
+
+	for _, builtin := range repl.Builtins() {
+		assert.NotEqual(t, "s", builtin.Name)
+	}
+
+	globals, _, err := repl.ExportGlobals()
+	require.NoError(t, err)
+	assert.Contains(t, globals, "s")
+
+	err = repl.Declare("s", cadence.NewInt(1))
+	require.EqualError(t, err, "cannot declare `s`: already declared")
+}
@@ -21,6 +21,8 @@ package runtime
 import (
 	"fmt"
 	"math/big"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,6 +33,9 @@ import (
 	jsoncdc "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/errors"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/onflow/cadence/runtime/sema"
 	"github.com/onflow/cadence/runtime/tests/utils"
 )
 
@@ -773,6 +778,38 @@ func TestCadenceValueAndTypeMetering(t *testing.T) {
 
 		assert.Equal(t, uint64(32), meter.getMemory(common.MemoryKindCadenceNumberValue))
 	})
+
+	t.Run("return value dictionary", func(t *testing.T) {
+		t.Parallel()
+
+		script := `
+            pub fun main(): {String: Int} {
+                return {"a": 1, "b": 2, "c": 3}
+            }
+        `
+		meter := newTestMemoryGauge()
+		runtimeInterface := &testRuntimeInterface{
+			meterMemory: func(usage common.MemoryUsage) error {
+				return meter.MeterMemory(usage)
+			},
+		}
+
+		runtime := newTestInterpreterRuntime()
+
+		_, err := runtime.ExecuteScript(
+			Script{
+				Source: []byte(script),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  utils.TestLocation,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(1), meter.getMemory(common.MemoryKindCadenceDictionaryValue))
+		assert.Equal(t, uint64(3), meter.getMemory(common.MemoryKindCadenceKeyValuePair))
+	})
 }
 
 func TestLogFunctionStringConversionMetering(t *testing.T) {
@@ -1072,3 +1109,182 @@ func TestMemoryMeteringErrors(t *testing.T) {
 		assert.ErrorIs(t, err, testMemoryError{})
 	})
 }
+
+// memoryKindCounts returns the number of times each of the given memory kinds occurs,
+// one memory kind per line, sorted by memory kind name, see TestExportValueMeteringGolden.
+func memoryKindCounts(kinds []common.MemoryKind) string {
+	counts := map[common.MemoryKind]int{}
+	for _, kind := range kinds {
+		counts[kind]++
+	}
+
+	lines := make([]string, 0, len(counts))
+	for kind, count := range counts { //nolint:maprangecheck
+		lines = append(lines, fmt.Sprintf("%s: %d", kind, count))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// exportValueMeteringGolden is the expected multiset of memory kinds charged
+// when the fixture of TestExportValueMeteringGolden is exported,
+// i.e. the number of times each memory kind is charged.
+//
+// To regenerate it after an intentional change of the metering of exported values,
+// run the test and replace the value with the actual value reported by the failing assertion.
+const exportValueMeteringGolden = `ArrayValueBase: 3
+AtreeArrayDataSlab: 3
+AtreeArrayElementOverhead: 3
+AtreeArrayMetaDataSlab: 3
+AtreeMapDataSlab: 4
+AtreeMapElementOverhead: 4
+AtreeMapMetaDataSlab: 4
+BigInt: 8
+CadenceAddressValue: 1
+CadenceArrayValueBase: 3
+CadenceArrayValueLength: 3
+CadenceBoolValue: 1
+CadenceCharacterValue: 1
+CadenceConstantSizedArrayType: 1
+CadenceDictionaryType: 1
+CadenceDictionaryValue: 1
+CadenceEnumType: 2
+CadenceEnumValueBase: 1
+CadenceEnumValueSize: 1
+CadenceIntValue: 8
+CadenceKeyValuePair: 1
+CadenceNumberValue: 6
+CadenceOptionalType: 1
+CadenceOptionalValue: 2
+CadencePathValue: 1
+CadenceResourceType: 1
+CadenceSimpleType: 19
+CadenceStringValue: 2
+CadenceStructType: 4
+CadenceStructValueBase: 3
+CadenceStructValueSize: 3
+CadenceTypeValue: 1
+CadenceVariableSizedArrayType: 1
+CompositeStaticType: 3
+CompositeValueBase: 3
+ConstantSizedSemaType: 1
+DictionarySemaType: 1
+DictionaryValueBase: 1
+RawString: 1
+VariableSizedSemaType: 2`
+
+// TestExportValueMeteringGolden exports a value which contains all kinds of exportable values
+// and asserts that the memory charged for the export does not change,
+// so changes to the exporter do not silently drop (or add) metering.
+func TestExportValueMeteringGolden(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub struct Inner {
+          pub let x: Int
+
+          init(x: Int) {
+              self.x = x
+          }
+      }
+
+      pub enum E: UInt8 {
+          pub case a
+      }
+
+      pub resource R {
+          pub let id: UInt64
+
+          init(id: UInt64) {
+              self.id = id
+          }
+      }
+
+      pub struct Fixture {
+          pub let int: Int
+          pub let uint: UInt
+          pub let int8: Int8
+          pub let uint64: UInt64
+          pub let word8: Word8
+          pub let fix64: Fix64
+          pub let ufix64: UFix64
+          pub let bool: Bool
+          pub let string: String
+          pub let character: Character
+          pub let address: Address
+          pub let path: StoragePath
+          pub let type: Type
+          pub let optional: Int?
+          pub let none: Int?
+          pub let array: [UInt8]
+          pub let constantArray: [Int; 2]
+          pub let dictionary: {String: Int}
+          pub let inner: Inner
+          pub let enum: E
+          pub let anyStruct: AnyStruct
+
+          init() {
+              self.int = 1
+              self.uint = 2
+              self.int8 = 3
+              self.uint64 = 4
+              self.word8 = 5
+              self.fix64 = -6.5
+              self.ufix64 = 7.5
+              self.bool = true
+              self.string = "eight"
+              self.character = "9"
+              self.address = 0x10
+              self.path = /storage/eleven
+              self.type = Type<@R>()
+              self.optional = 12
+              self.none = nil
+              self.array = [13, 14]
+              self.constantArray = [15, 16]
+              self.dictionary = {"seventeen": 17}
+              self.inner = Inner(x: 18)
+              self.enum = E.a
+              self.anyStruct = [Inner(x: 19)]
+          }
+      }
+
+      pub fun fixture(): Fixture {
+          return Fixture()
+      }
+    `
+
+	program, err := parser.ParseProgram(code, nil)
+	require.NoError(t, err)
+
+	checker, err := sema.NewChecker(program, utils.TestLocation, nil, false)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	require.NoError(t, err)
+
+	gauge := utils.NewRecordingMemoryGauge()
+
+	inter, err := interpreter.NewInterpreter(
+		interpreter.ProgramFromChecker(checker),
+		utils.TestLocation,
+		interpreter.WithStorage(interpreter.NewInMemoryStorage(nil)),
+		interpreter.WithMemoryGauge(gauge),
+	)
+	require.NoError(t, err)
+
+	err = inter.Interpret()
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("fixture")
+	require.NoError(t, err)
+
+	// Only record the charges of the export
+
+	gauge.Reset()
+
+	_, err = ExportValue(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	assert.Equal(t, exportValueMeteringGolden, memoryKindCounts(gauge.ChargedKinds()))
+}
@@ -202,6 +202,15 @@ func (checker *Checker) checkBinaryExpressionArithmeticOrNonEqualityComparisonOr
 	leftType, rightType Type,
 	leftIsInvalid, rightIsInvalid, anyInvalid bool,
 ) Type {
+	// Types are ordered by identifier, so they can be compared
+
+	if operationKind == BinaryOperationKindNonEqualityComparison &&
+		leftType.Equal(MetaType) &&
+		rightType.Equal(MetaType) {
+
+		return BoolType
+	}
+
 	// check both types are number/integer subtypes
 
 	var expectedSuperType Type
@@ -166,7 +166,9 @@ func (checker *Checker) visitMember(expression *ast.MemberExpression) (accessedT
 		targetRange := ast.NewRangeFromPositioned(checker.memoryGauge, expression.Expression)
 		member = resolver.Resolve(checker.memoryGauge, identifier, targetRange, checker.report)
 		if resolver.Mutating {
-			if targetExpression, ok := accessedExpression.(*ast.MemberExpression); ok {
+			// Force-unwrapping an optional field, e.g. `foo.x!.append(1)`,
+			// still mutates the field
+			if targetExpression, ok := withoutForceExpressions(accessedExpression).(*ast.MemberExpression); ok {
 				// visitMember caches its result, so visiting the target expression again,
 				// after it had been previously visited to get the resolver,
 				// performs no computation
@@ -361,3 +363,15 @@ func containingContractKindedType(t Type) CompositeKindedType {
 		return nil
 	}
 }
+
+// withoutForceExpressions returns the given expression,
+// without any enclosing force expressions, e.g. `x` for `x!!`.
+func withoutForceExpressions(expression ast.Expression) ast.Expression {
+	for {
+		forceExpression, ok := expression.(*ast.ForceExpression)
+		if !ok {
+			return expression
+		}
+		expression = forceExpression.Expression
+	}
+}
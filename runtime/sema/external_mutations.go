@@ -0,0 +1,144 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"github.com/onflow/cadence/runtime/ast"
+)
+
+// FindExternalMutations returns the ranges of the member expressions in the given program
+// which are likely to be reported as an ExternalMutationError when the program is checked,
+// i.e. index assignments on collection-typed fields outside the declaring composite,
+// e.g. `foo.x[0] = 1`.
+//
+// The program is only scanned syntactically, it is not type-checked.
+// The fields are matched by name, so there may be false positives,
+// e.g. for an index assignment on a field of a different composite which has the same name
+// as a collection-typed field, and false negatives, e.g. for fields that have an inferred type.
+// Mutations through function calls, e.g. `foo.x.append(1)`, are not detected.
+func FindExternalMutations(program *ast.Program) []ast.Range {
+	finder := externalMutationFinder{
+		collectionFields: map[string]struct{}{},
+		ranges:           new([]ast.Range),
+	}
+
+	ast.Inspect(program, func(element ast.Element) bool {
+		if declaration, ok := element.(*ast.CompositeDeclaration); ok {
+			for name := range collectionFields(declaration) {
+				finder.collectionFields[name] = struct{}{}
+			}
+		}
+		return true
+	})
+
+	ast.Walk(finder, program)
+
+	return *finder.ranges
+}
+
+// externalMutationFinder is an ast.Walker which finds index assignments
+// on collection-typed fields, see FindExternalMutations.
+type externalMutationFinder struct {
+	// collectionFields contains the names of the collection-typed fields
+	// of all composites of the program, which are not publicly settable
+	collectionFields map[string]struct{}
+	// enclosingFields contains the names of the collection-typed fields
+	// of the innermost enclosing composite, which may be mutated
+	enclosingFields map[string]struct{}
+	ranges          *[]ast.Range
+}
+
+func (f externalMutationFinder) Walk(element ast.Element) ast.Walker {
+	switch element := element.(type) {
+	case *ast.CompositeDeclaration:
+		f.enclosingFields = collectionFields(element)
+
+	case *ast.AssignmentStatement:
+		f.checkIndexAssignment(element.Target)
+	}
+
+	return f
+}
+
+func (f externalMutationFinder) checkIndexAssignment(target ast.Expression) {
+	indexExpression, ok := target.(*ast.IndexExpression)
+	if !ok {
+		return
+	}
+
+	memberExpression, ok := indexExpression.TargetExpression.(*ast.MemberExpression)
+	if !ok {
+		return
+	}
+
+	name := memberExpression.Identifier.Identifier
+
+	if _, ok := f.collectionFields[name]; !ok {
+		return
+	}
+
+	// Fields of the enclosing composite may be mutated,
+	// both through `self` and through other instances
+
+	if _, ok := f.enclosingFields[name]; ok {
+		return
+	}
+
+	if identifierExpression, ok := memberExpression.Expression.(*ast.IdentifierExpression); ok &&
+		identifierExpression.Identifier.Identifier == SelfIdentifier {
+
+		return
+	}
+
+	*f.ranges = append(
+		*f.ranges,
+		ast.NewRangeFromPositioned(nil, memberExpression),
+	)
+}
+
+// collectionFields returns the names of the collection-typed fields of the given composite
+// which are not publicly settable.
+func collectionFields(declaration *ast.CompositeDeclaration) map[string]struct{} {
+	fields := map[string]struct{}{}
+
+	for _, field := range declaration.Members.Fields() {
+		if field.Access == ast.AccessPublicSettable ||
+			field.TypeAnnotation == nil ||
+			!isCollectionType(field.TypeAnnotation.Type) {
+
+			continue
+		}
+
+		fields[field.Identifier.Identifier] = struct{}{}
+	}
+
+	return fields
+}
+
+func isCollectionType(t ast.Type) bool {
+	switch t := t.(type) {
+	case *ast.VariableSizedType, *ast.ConstantSizedType, *ast.DictionaryType:
+		return true
+
+	case *ast.ReferenceType:
+		return isCollectionType(t.Type)
+	}
+
+	return false
+}
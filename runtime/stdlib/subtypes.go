@@ -0,0 +1,107 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stdlib
+
+import (
+	"sort"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/errors"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+const subtypesOfFunctionDocString = `
+Returns the composite types declared in the current program which are subtypes of the given type,
+e.g. the composite types which conform to the interfaces of a restricted type like ` + "`AnyStruct{I}`" + `.
+`
+
+var subtypesOfFunctionType = &sema.FunctionType{
+	Parameters: []*sema.Parameter{
+		{
+			Label:          sema.ArgumentLabelNotRequired,
+			Identifier:     "type",
+			TypeAnnotation: sema.NewTypeAnnotation(sema.MetaType),
+		},
+	},
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(
+		&sema.VariableSizedType{
+			Type: sema.MetaType,
+		},
+	),
+}
+
+// SubtypesOfFunction returns the composite types declared in the current program
+// which are subtypes of the given type, sorted by type ID.
+//
+// The function is not part of the builtin functions,
+// it must be explicitly provided by the host.
+var SubtypesOfFunction = NewStandardLibraryFunction(
+	"subtypesOf",
+	subtypesOfFunctionType,
+	subtypesOfFunctionDocString,
+	func(invocation interpreter.Invocation) interpreter.Value {
+		typeValue, ok := invocation.Arguments[0].(interpreter.TypeValue)
+		if !ok {
+			panic(errors.NewUnreachableError())
+		}
+
+		inter := invocation.Interpreter
+
+		resultStaticType := interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeMetaType,
+		}
+
+		var compositeTypes []*sema.CompositeType
+
+		if typeValue.Type != nil && inter.Program != nil {
+			superType := inter.MustConvertStaticToSemaType(typeValue.Type)
+
+			// Iterating over the composite types is safe,
+			// as the resulting types are sorted afterwards
+
+			for _, compositeType := range inter.Program.Elaboration.CompositeTypes { //nolint:maprangecheck
+				if !sema.IsSubType(compositeType, superType) {
+					continue
+				}
+				compositeTypes = append(compositeTypes, compositeType)
+			}
+
+			sort.Slice(compositeTypes, func(i, j int) bool {
+				return compositeTypes[i].ID() < compositeTypes[j].ID()
+			})
+		}
+
+		values := make([]interpreter.Value, len(compositeTypes))
+		for i, compositeType := range compositeTypes {
+			values[i] = interpreter.NewTypeValue(
+				inter,
+				interpreter.ConvertSemaToStaticType(inter, compositeType),
+			)
+		}
+
+		return interpreter.NewArrayValue(
+			inter,
+			invocation.GetLocationRange,
+			resultStaticType,
+			common.Address{},
+			values...,
+		)
+	},
+)
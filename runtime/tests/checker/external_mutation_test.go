@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/cadence/runtime/ast"
@@ -862,3 +863,361 @@ func TestCheckMutationThroughAccess(t *testing.T) {
 		require.ErrorAs(t, errs[0], &externalMutationError)
 	})
 }
+
+func TestCheckMutationThroughOptional(t *testing.T) {
+
+	t.Parallel()
+
+	// NOTE: index assignments on optional collections, e.g. `foo.optColl![0] = 3`,
+	// are not valid assignment targets, and optional chaining has no index syntax,
+	// so only mutating functions can mutate optional collection fields
+
+	test := func(t *testing.T, fieldType string, initialValue string, mutation string) {
+		_, err := ParseAndCheck(t,
+			fmt.Sprintf(
+				`
+                  pub struct Foo {
+                      pub let optColl: %[1]s
+
+                      init() {
+                          self.optColl = %[2]s
+                      }
+                  }
+
+                  pub fun test() {
+                      let foo = Foo()
+                      %[3]s
+                  }
+                `,
+				fieldType,
+				initialValue,
+				mutation,
+			),
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+		var externalMutationError *sema.ExternalMutationError
+		require.ErrorAs(t, errs[0], &externalMutationError)
+		assert.Equal(t, "optColl", externalMutationError.Name)
+	}
+
+	t.Run("array, optional chaining", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, "[Int]?", "[3]", "foo.optColl?.append(3)")
+	})
+
+	t.Run("array, force unwrap", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, "[Int]?", "[3]", "foo.optColl!.append(3)")
+	})
+
+	t.Run("array, nested force unwrap", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, "[Int]??", "[3]", "foo.optColl!!.append(3)")
+	})
+
+	t.Run("dictionary, optional chaining", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, "{Int: Int}?", "{}", "foo.optColl?.insert(key: 0, 3)")
+	})
+
+	t.Run("dictionary, force unwrap", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, "{Int: Int}?", "{}", "foo.optColl!.remove(key: 0)")
+	})
+
+	t.Run("optional composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          pub struct Bar {
+              pub let x: [Int]
+
+              init() {
+                  self.x = [3]
+              }
+          }
+
+          pub struct Foo {
+              pub let bar: Bar?
+
+              init() {
+                  self.bar = Bar()
+              }
+          }
+
+          pub fun test() {
+              let foo = Foo()
+              foo.bar?.x?.append(3)
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+		var externalMutationError *sema.ExternalMutationError
+		require.ErrorAs(t, errs[0], &externalMutationError)
+		assert.Equal(t, "x", externalMutationError.Name)
+	})
+
+	t.Run("force unwrap inside composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          pub struct Foo {
+              pub let optColl: [Int]?
+
+              init() {
+                  self.optColl = [3]
+              }
+
+              pub fun add() {
+                  self.optColl!.append(3)
+              }
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+}
+
+func TestFindExternalMutations(t *testing.T) {
+
+	t.Parallel()
+
+	// The fixtures of the index access tests above,
+	// and their expected number of external mutations
+
+	tests := map[string]struct {
+		code     string
+		expected int
+	}{
+		"array": {
+			code: `
+              pub contract C {
+                  pub struct Foo {
+                      pub let x: [Int]
+
+                      init() {
+                          self.x = [3]
+                      }
+                  }
+
+                  pub fun bar() {
+                      let foo = Foo()
+                      foo.x[0] = 3
+                  }
+              }
+            `,
+			expected: 1,
+		},
+		"dictionary": {
+			code: `
+              pub contract C {
+                  pub resource Foo {
+                      access(account) var x: {Int: Int}
+
+                      init() {
+                          self.x = {0: 3}
+                      }
+                  }
+
+                  pub fun bar() {
+                      let foo <- create Foo()
+                      foo.x[0] = 3
+                      destroy foo
+                  }
+              }
+            `,
+			expected: 1,
+		},
+		"nested": {
+			code: `
+              pub contract C {
+                  pub struct Bar {
+                      pub let foo: Foo
+
+                      init() {
+                          self.foo = Foo()
+                      }
+                  }
+
+                  pub struct Foo {
+                      access(contract) let x: [Int]
+
+                      init() {
+                          self.x = [3]
+                      }
+                  }
+
+                  pub fun bar() {
+                      let bar = Bar()
+                      bar.foo.x[0] = 3
+                  }
+              }
+            `,
+			expected: 1,
+		},
+		"contract": {
+			code: `
+              pub contract Foo {
+                  pub let x: [Int]
+
+                  init() {
+                      self.x = [3]
+                  }
+              }
+
+              pub fun bar() {
+                  Foo.x[0] = 1
+              }
+            `,
+			expected: 1,
+		},
+		"contract nested struct": {
+			code: `
+              pub contract Foo {
+                  pub let x: S
+
+                  pub struct S {
+                      pub var y: [Int]
+
+                      init() {
+                          self.y = [3]
+                      }
+                  }
+
+                  init() {
+                      self.x = S()
+                  }
+              }
+
+              pub fun bar() {
+                  Foo.x.y[0] = 1
+              }
+            `,
+			expected: 1,
+		},
+		"contract struct init": {
+			code: `
+              pub contract Foo {
+                  pub let x: S
+
+                  pub struct S {
+                      pub let y: [Int]
+
+                      init() {
+                          self.y = [3]
+                      }
+                  }
+
+                  init() {
+                      self.x = S()
+                      self.x.y[1] = 2
+                  }
+              }
+            `,
+			expected: 1,
+		},
+		"pub set": {
+			code: `
+              pub contract C {
+                  pub struct Foo {
+                      pub(set) var x: {Int: Int}
+
+                      init() {
+                          self.x = {3: 3}
+                      }
+                  }
+
+                  pub fun bar() {
+                      let foo = Foo()
+                      foo.x[0] = 3
+                  }
+              }
+            `,
+			expected: 0,
+		},
+		"self containing": {
+			code: `
+              pub contract C {
+                  pub struct Foo {
+                      pub let x: {Int: Int}
+
+                      init() {
+                          self.x = {3: 3}
+                      }
+
+                      pub fun bar() {
+                          let foo = Foo()
+                          foo.x[0] = 3
+                      }
+                  }
+              }
+            `,
+			expected: 0,
+		},
+		"inner reference": {
+			code: `
+              pub fun main() {
+                  let foo = Foo()
+                  var arrayRef = &foo.ref.arr as &[String]
+                  arrayRef[0] = "y"
+              }
+
+              pub struct Foo {
+                  pub let ref: &Bar
+
+                  init() {
+                      self.ref = &Bar() as &Bar
+                  }
+              }
+
+              pub struct Bar {
+                  pub let arr: [String]
+
+                  init() {
+                      self.arr = ["x"]
+                  }
+              }
+            `,
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			checker, err := ParseAndCheck(t, test.code)
+
+			// The candidates must be the ranges reported by the checker
+
+			errs := ExpectCheckerErrors(t, err, test.expected)
+
+			var expectedRanges []ast.Range
+			for _, err := range errs {
+				var externalMutationError *sema.ExternalMutationError
+				require.ErrorAs(t, err, &externalMutationError)
+				expectedRanges = append(expectedRanges, externalMutationError.Range)
+			}
+
+			ranges := sema.FindExternalMutations(checker.Program)
+			require.Equal(t, expectedRanges, ranges)
+		})
+	}
+}
@@ -19,6 +19,7 @@
 package checker
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -227,6 +228,51 @@ func TestCheckIsSubtype(t *testing.T) {
 	}
 }
 
+func TestCheckMetaTypeComparison(t *testing.T) {
+
+	t.Parallel()
+
+	for _, operation := range []string{"<", "<=", ">", ">="} {
+
+		operation := operation
+
+		t.Run(operation, func(t *testing.T) {
+
+			t.Parallel()
+
+			checker, err := ParseAndCheck(t,
+				fmt.Sprintf(
+					`
+                      let result = Type<Int>() %s Type<String>()
+                    `,
+					operation,
+				),
+			)
+
+			require.NoError(t, err)
+
+			assert.Equal(t,
+				sema.BoolType,
+				RequireGlobalValue(t, checker.Elaboration, "result"),
+			)
+		})
+	}
+
+	t.Run("type and number", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let result = Type<Int>() < 1
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		require.IsType(t, &sema.InvalidBinaryOperandError{}, errs[0])
+		require.IsType(t, &sema.InvalidBinaryOperandsError{}, errs[1])
+	})
+}
+
 func TestCheckIsInstance_Redeclaration(t *testing.T) {
 
 	t.Parallel()
@@ -296,6 +296,80 @@ func TestInterpretMetaTypeIdentifier(t *testing.T) {
 	})
 }
 
+func TestInterpretMetaTypeComparison(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("operators", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          let less = Type<Int>() < Type<String>()
+          let lessEqual = Type<Int>() <= Type<Int>()
+          let greater = Type<Int>() > Type<String>()
+          let greaterEqual = Type<String>() >= Type<Int>()
+        `)
+
+		for name, expected := range map[string]bool{ //nolint:maprangecheck
+			"less":         true,
+			"lessEqual":    true,
+			"greater":      false,
+			"greaterEqual": true,
+		} {
+			AssertValuesEqual(
+				t,
+				inter,
+				interpreter.BoolValue(expected),
+				inter.Globals[name].GetValue(),
+			)
+		}
+	})
+
+	t.Run("sort", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          struct S {}
+
+          fun sort(_ types: [Type]): [Type] {
+              var i = 1
+              while i < types.length {
+                  var j = i
+                  while j > 0 && types[j] < types[j - 1] {
+                      let type = types[j]
+                      types[j] = types[j - 1]
+                      types[j - 1] = type
+                      j = j - 1
+                  }
+                  i = i + 1
+              }
+              return types
+          }
+
+          fun test(): String {
+              let sorted = sort([Type<String>(), Type<S>(), Type<[Int]>(), Type<Bool>(), Type<Int>()])
+              var identifiers = ""
+              for type in sorted {
+                  identifiers = identifiers.concat(type.identifier).concat(" ")
+              }
+              return identifiers
+          }
+        `)
+
+		result, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewUnmeteredStringValue("Bool Int S.test.S String [Int] "),
+			result,
+		)
+	})
+}
+
 func TestInterpretIsInstance(t *testing.T) {
 
 	t.Parallel()
@@ -370,6 +444,32 @@ func TestInterpretIsInstance(t *testing.T) {
               let r <- create R()
               let sType = Type<@S>()
               let result = r.isInstance(sType)
+            `,
+			result: false,
+		},
+		{
+			name: "resource R is an instance of restricted type with conforming restriction",
+			code: `
+              resource interface I {}
+
+              resource R: I {}
+
+              let r <- create R()
+              let restrictedType = Type<@AnyResource{I}>()
+              let result = r.isInstance(restrictedType)
+            `,
+			result: true,
+		},
+		{
+			name: "resource R is not an instance of restricted type with non-conforming restriction",
+			code: `
+              resource interface I {}
+
+              resource R {}
+
+              let r <- create R()
+              let restrictedType = Type<@AnyResource{I}>()
+              let result = r.isInstance(restrictedType)
             `,
 			result: false,
 		},
@@ -746,3 +846,91 @@ func TestInterpretGetType(t *testing.T) {
 		})
 	}
 }
+
+func TestInterpretSubtypesOf(t *testing.T) {
+
+	t.Parallel()
+
+	valueDeclarations := stdlib.StandardLibraryFunctions{
+		stdlib.SubtypesOfFunction,
+	}
+
+	inter, err := parseCheckAndInterpretWithOptions(t,
+		`
+          struct interface I {}
+
+          struct interface J {}
+
+          struct A: I {}
+
+          struct B: I, J {}
+
+          struct C: J {}
+
+          let subtypesOfI = subtypesOf(Type<AnyStruct{I}>())
+          let subtypesOfIJ = subtypesOf(Type<AnyStruct{I, J}>())
+          let subtypesOfA = subtypesOf(Type<A>())
+          let subtypesOfInt = subtypesOf(Type<Int>())
+        `,
+		ParseCheckAndInterpretOptions{
+			CheckerOptions: []sema.Option{
+				sema.WithPredeclaredValues(valueDeclarations.ToSemaValueDeclarations()),
+			},
+			Options: []interpreter.Option{
+				interpreter.WithPredeclaredValues(valueDeclarations.ToInterpreterValueDeclarations()),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	typeValues := func(names ...string) interpreter.Value {
+		values := make([]interpreter.Value, len(names))
+		for i, name := range names {
+			values[i] = interpreter.TypeValue{
+				Type: interpreter.CompositeStaticType{
+					Location:            TestLocation,
+					QualifiedIdentifier: name,
+					TypeID:              TestLocation.TypeID(nil, name),
+				},
+			}
+		}
+
+		return interpreter.NewArrayValue(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeMetaType,
+			},
+			common.Address{},
+			values...,
+		)
+	}
+
+	AssertValuesEqual(
+		t,
+		inter,
+		typeValues("A", "B"),
+		inter.Globals["subtypesOfI"].GetValue(),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		typeValues("B"),
+		inter.Globals["subtypesOfIJ"].GetValue(),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		typeValues("A"),
+		inter.Globals["subtypesOfA"].GetValue(),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		typeValues(),
+		inter.Globals["subtypesOfInt"].GetValue(),
+	)
+}
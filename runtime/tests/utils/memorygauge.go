@@ -0,0 +1,50 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// RecordingMemoryGauge is a memory gauge which records the kinds of all metered memory usages,
+// in the order in which they were metered.
+type RecordingMemoryGauge struct {
+	kinds []common.MemoryKind
+}
+
+var _ common.MemoryGauge = &RecordingMemoryGauge{}
+
+func NewRecordingMemoryGauge() *RecordingMemoryGauge {
+	return &RecordingMemoryGauge{}
+}
+
+func (g *RecordingMemoryGauge) MeterMemory(usage common.MemoryUsage) error {
+	g.kinds = append(g.kinds, usage.Kind)
+	return nil
+}
+
+// ChargedKinds returns the kinds of the metered memory usages, in the order in which they were metered.
+func (g *RecordingMemoryGauge) ChargedKinds() []common.MemoryKind {
+	return g.kinds
+}
+
+// Reset removes all recorded kinds.
+func (g *RecordingMemoryGauge) Reset() {
+	g.kinds = nil
+}
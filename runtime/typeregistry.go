@@ -0,0 +1,506 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/errors"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// ExportValueWithRegistry converts a runtime value to its native Go representation, like ExportValue,
+// but the composite types of the exported value only refer to the full type definitions by type ID,
+// which are returned in a type registry, keyed by type ID.
+// This avoids repeating the full type definition for each composite in large exports.
+//
+// The composite types in the exported value, e.g. the types of composites
+// and the element types of arrays, are stubs, i.e. they only have a location and a qualified identifier,
+// so their ID is the ID of the full type definition.
+// The composite types in the registry refer to other composite types using stubs as well.
+func ExportValueWithRegistry(
+	value interpreter.Value,
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+) (
+	cadence.Value,
+	map[string]cadence.Type,
+	error,
+) {
+	exported, err := ExportValue(value, inter, getLocationRange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registry := &typeRegistry{
+		gauge: inter,
+		types: map[string]cadence.Type{},
+		stubs: map[string]cadence.CompositeType{},
+	}
+
+	return registry.registerValueTypes(exported), registry.types, nil
+}
+
+// typeRegistry replaces the composite types of exported values with stubs,
+// and collects the full type definitions, see ExportValueWithRegistry.
+type typeRegistry struct {
+	gauge common.MemoryGauge
+	// types contains the full type definitions, keyed by type ID
+	types map[string]cadence.Type
+	// stubs contains the stubs of the registered types, keyed by type ID
+	stubs map[string]cadence.CompositeType
+}
+
+// registerValueTypes replaces the types of the given exported value and its nested values with stubs.
+//
+// NOTE: the value is modified in place, so it must not share nested values with other values.
+func (r *typeRegistry) registerValueTypes(value cadence.Value) cadence.Value {
+	switch v := value.(type) {
+	case cadence.Optional:
+		if v.Value != nil {
+			v.Value = r.registerValueTypes(v.Value)
+		}
+		return v
+
+	case cadence.Array:
+		for i, element := range v.Values {
+			v.Values[i] = r.registerValueTypes(element)
+		}
+		if v.ArrayType != nil {
+			v.ArrayType = r.registerType(v.ArrayType).(cadence.ArrayType)
+		}
+		return v
+
+	case cadence.Dictionary:
+		for i, pair := range v.Pairs {
+			v.Pairs[i] = cadence.KeyValuePair{
+				Key:   r.registerValueTypes(pair.Key),
+				Value: r.registerValueTypes(pair.Value),
+			}
+		}
+		if v.DictionaryType != nil {
+			v.DictionaryType = r.registerType(v.DictionaryType)
+		}
+		return v
+
+	case cadence.Struct:
+		r.registerFieldValueTypes(v.Fields)
+		if v.StructType != nil {
+			v.StructType = r.registerType(v.StructType).(*cadence.StructType)
+		}
+		return v
+
+	case cadence.Resource:
+		r.registerFieldValueTypes(v.Fields)
+		if v.ResourceType != nil {
+			v.ResourceType = r.registerType(v.ResourceType).(*cadence.ResourceType)
+		}
+		return v
+
+	case cadence.Event:
+		r.registerFieldValueTypes(v.Fields)
+		if v.EventType != nil {
+			v.EventType = r.registerType(v.EventType).(*cadence.EventType)
+		}
+		return v
+
+	case cadence.Contract:
+		r.registerFieldValueTypes(v.Fields)
+		if v.ContractType != nil {
+			v.ContractType = r.registerType(v.ContractType).(*cadence.ContractType)
+		}
+		return v
+
+	case cadence.Enum:
+		r.registerFieldValueTypes(v.Fields)
+		if v.EnumType != nil {
+			v.EnumType = r.registerType(v.EnumType).(*cadence.EnumType)
+		}
+		return v
+
+	case cadence.TypeValue:
+		if v.StaticType != nil {
+			v.StaticType = r.registerType(v.StaticType)
+		}
+		return v
+
+	default:
+		return value
+	}
+}
+
+func (r *typeRegistry) registerFieldValueTypes(fields []cadence.Value) {
+	for i, field := range fields {
+		fields[i] = r.registerValueTypes(field)
+	}
+}
+
+// registerType registers the composite types contained in the given type,
+// and returns the type with the composite types replaced with stubs.
+func (r *typeRegistry) registerType(t cadence.Type) cadence.Type {
+	compositeType, ok := t.(cadence.CompositeType)
+	if !ok {
+		return mapContainedTypes(t, r.registerType)
+	}
+
+	typeID := compositeType.ID()
+	if stub, ok := r.stubs[typeID]; ok {
+		return stub
+	}
+
+	stub := newCompositeTypeStub(r.gauge, compositeType)
+
+	// NOTE: record the stub before registering the field types,
+	// as composite types may be recursive
+
+	r.stubs[typeID] = stub
+
+	definition := copyCompositeType(compositeType)
+
+	fields := compositeType.CompositeFields()
+	definitionFields := make([]cadence.Field, len(fields))
+	for i, field := range fields {
+		definitionFields[i] = cadence.Field{
+			Identifier: field.Identifier,
+			Type:       r.registerType(field.Type),
+		}
+	}
+	definition.SetCompositeFields(definitionFields)
+
+	r.types[typeID] = definition
+
+	return stub
+}
+
+// newCompositeTypeStub returns a stub of the given composite type,
+// i.e. a composite type of the same kind, which only has the location and the qualified identifier.
+func newCompositeTypeStub(gauge common.MemoryGauge, t cadence.CompositeType) cadence.CompositeType {
+	location := t.CompositeTypeLocation()
+	qualifiedIdentifier := t.CompositeTypeQualifiedIdentifier()
+
+	switch t.(type) {
+	case *cadence.StructType:
+		return cadence.NewMeteredStructType(gauge, location, qualifiedIdentifier, nil, nil)
+	case *cadence.ResourceType:
+		return cadence.NewMeteredResourceType(gauge, location, qualifiedIdentifier, nil, nil)
+	case *cadence.EventType:
+		return cadence.NewMeteredEventType(gauge, location, qualifiedIdentifier, nil, nil)
+	case *cadence.ContractType:
+		return cadence.NewMeteredContractType(gauge, location, qualifiedIdentifier, nil, nil)
+	case *cadence.EnumType:
+		return cadence.NewMeteredEnumType(gauge, location, qualifiedIdentifier, nil, nil, nil)
+	default:
+		panic(errors.NewUnreachableError())
+	}
+}
+
+// ImportValueWithRegistry converts a Cadence value which only refers to composite types by type ID,
+// and the type registry which contains the full type definitions, keyed by type ID,
+// e.g. as returned by ExportValueWithRegistry, to a runtime value, like Importer.ImportValue.
+//
+// The composite types of the value, and the composite types of the registry,
+// are resolved from the registry by type ID.
+// Composite types which are not in the registry are used as is.
+func (im *Importer) ImportValueWithRegistry(
+	inter *interpreter.Interpreter,
+	getLocationRange func() interpreter.LocationRange,
+	value cadence.Value,
+	registry map[string]cadence.Type,
+	expectedType sema.Type,
+) (interpreter.Value, error) {
+	resolver := &registryTypeResolver{
+		registry: registry,
+		resolved: map[string]cadence.CompositeType{},
+	}
+
+	resolvedValue, err := resolver.resolveValueTypes(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return im.ImportValue(inter, getLocationRange, resolvedValue, expectedType)
+}
+
+// registryTypeResolver replaces the composite types of values with the full type definitions
+// of a type registry, see Importer.ImportValueWithRegistry.
+type registryTypeResolver struct {
+	registry map[string]cadence.Type
+	// resolved contains the resolved composite types, keyed by type ID
+	resolved map[string]cadence.CompositeType
+}
+
+// resolveValueTypes replaces the types of the given value and its nested values
+// with the full type definitions of the registry.
+//
+// The given value is not modified, the values with resolved types are copies.
+func (r *registryTypeResolver) resolveValueTypes(value cadence.Value) (cadence.Value, error) {
+	switch v := value.(type) {
+	case cadence.Optional:
+		if v.Value == nil {
+			return v, nil
+		}
+		inner, err := r.resolveValueTypes(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		v.Value = inner
+		return v, nil
+
+	case cadence.Array:
+		values, err := r.resolveValuesTypes(v.Values)
+		if err != nil {
+			return nil, err
+		}
+		v.Values = values
+		if v.ArrayType != nil {
+			arrayType, err := r.resolveType(v.ArrayType)
+			if err != nil {
+				return nil, err
+			}
+			v.ArrayType = arrayType.(cadence.ArrayType)
+		}
+		return v, nil
+
+	case cadence.Dictionary:
+		pairs := make([]cadence.KeyValuePair, len(v.Pairs))
+		for i, pair := range v.Pairs {
+			key, err := r.resolveValueTypes(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.resolveValueTypes(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			pairs[i] = cadence.KeyValuePair{
+				Key:   key,
+				Value: value,
+			}
+		}
+		v.Pairs = pairs
+		if v.DictionaryType != nil {
+			dictionaryType, err := r.resolveType(v.DictionaryType)
+			if err != nil {
+				return nil, err
+			}
+			v.DictionaryType = dictionaryType
+		}
+		return v, nil
+
+	case cadence.Struct:
+		if v.StructType == nil {
+			return v, nil
+		}
+		fields, structType, err := r.resolveCompositeValueTypes(v.Fields, v.StructType)
+		if err != nil {
+			return nil, err
+		}
+		v.Fields = fields
+		v.StructType = structType.(*cadence.StructType)
+		return v, nil
+
+	case cadence.Resource:
+		if v.ResourceType == nil {
+			return v, nil
+		}
+		fields, resourceType, err := r.resolveCompositeValueTypes(v.Fields, v.ResourceType)
+		if err != nil {
+			return nil, err
+		}
+		v.Fields = fields
+		v.ResourceType = resourceType.(*cadence.ResourceType)
+		return v, nil
+
+	case cadence.Event:
+		if v.EventType == nil {
+			return v, nil
+		}
+		fields, eventType, err := r.resolveCompositeValueTypes(v.Fields, v.EventType)
+		if err != nil {
+			return nil, err
+		}
+		v.Fields = fields
+		v.EventType = eventType.(*cadence.EventType)
+		return v, nil
+
+	case cadence.Contract:
+		if v.ContractType == nil {
+			return v, nil
+		}
+		fields, contractType, err := r.resolveCompositeValueTypes(v.Fields, v.ContractType)
+		if err != nil {
+			return nil, err
+		}
+		v.Fields = fields
+		v.ContractType = contractType.(*cadence.ContractType)
+		return v, nil
+
+	case cadence.Enum:
+		if v.EnumType == nil {
+			return v, nil
+		}
+		fields, enumType, err := r.resolveCompositeValueTypes(v.Fields, v.EnumType)
+		if err != nil {
+			return nil, err
+		}
+		v.Fields = fields
+		v.EnumType = enumType.(*cadence.EnumType)
+		return v, nil
+
+	case cadence.TypeValue:
+		if v.StaticType != nil {
+			staticType, err := r.resolveType(v.StaticType)
+			if err != nil {
+				return nil, err
+			}
+			v.StaticType = staticType
+		}
+		return v, nil
+
+	default:
+		return value, nil
+	}
+}
+
+func (r *registryTypeResolver) resolveValuesTypes(values []cadence.Value) ([]cadence.Value, error) {
+	resolved := make([]cadence.Value, len(values))
+	for i, value := range values {
+		resolvedValue, err := r.resolveValueTypes(value)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = resolvedValue
+	}
+	return resolved, nil
+}
+
+// resolveCompositeValueTypes resolves the types of the given field values and the given composite type.
+//
+// The field values are paired with the fields of the composite type,
+// so the resolved composite type must have a field for each value,
+// i.e. composite types which only refer to a definition must be in the registry.
+func (r *registryTypeResolver) resolveCompositeValueTypes(
+	fields []cadence.Value,
+	compositeType cadence.CompositeType,
+) (
+	[]cadence.Value,
+	cadence.CompositeType,
+	error,
+) {
+	resolvedType, err := r.resolveType(compositeType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedCompositeType := resolvedType.(cadence.CompositeType)
+	if len(resolvedCompositeType.CompositeFields()) < len(fields) {
+		return nil, nil, errors.NewDefaultUserError(
+			"cannot import value: missing registry definition of type `%s`",
+			compositeType.ID(),
+		)
+	}
+
+	resolvedFields, err := r.resolveValuesTypes(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resolvedFields, resolvedCompositeType, nil
+}
+
+// resolveType returns the given type with the contained composite types
+// replaced with the full type definitions of the registry.
+func (r *registryTypeResolver) resolveType(t cadence.Type) (cadence.Type, error) {
+	compositeType, ok := t.(cadence.CompositeType)
+	if !ok {
+		var err error
+		resolved := mapContainedTypes(t, func(t cadence.Type) cadence.Type {
+			if err != nil {
+				return t
+			}
+			var resolved cadence.Type
+			resolved, err = r.resolveType(t)
+			return resolved
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resolved, nil
+	}
+
+	typeID := compositeType.ID()
+	if resolved, ok := r.resolved[typeID]; ok {
+		return resolved, nil
+	}
+
+	definition, ok := r.registry[typeID]
+	if !ok {
+		return t, nil
+	}
+
+	definitionType, ok := definition.(cadence.CompositeType)
+	if !ok ||
+		definitionType.ID() != typeID ||
+		compositeKindOfType(definitionType) != compositeKindOfType(compositeType) {
+
+		return nil, errors.NewDefaultUserError(
+			"cannot import value: invalid registry definition of type `%s`",
+			typeID,
+		)
+	}
+
+	resolved := copyCompositeType(definitionType)
+
+	// NOTE: record the resolved type before resolving the field types,
+	// as composite types may be recursive
+
+	r.resolved[typeID] = resolved
+
+	fields := definitionType.CompositeFields()
+	resolvedFields := make([]cadence.Field, len(fields))
+	for i, field := range fields {
+		fieldType, err := r.resolveType(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		field.Type = fieldType
+		resolvedFields[i] = field
+	}
+	resolved.SetCompositeFields(resolvedFields)
+
+	return resolved, nil
+}
+
+func compositeKindOfType(t cadence.CompositeType) common.CompositeKind {
+	switch t.(type) {
+	case *cadence.StructType:
+		return common.CompositeKindStructure
+	case *cadence.ResourceType:
+		return common.CompositeKindResource
+	case *cadence.EventType:
+		return common.CompositeKindEvent
+	case *cadence.ContractType:
+		return common.CompositeKindContract
+	case *cadence.EnumType:
+		return common.CompositeKindEnum
+	default:
+		return common.CompositeKindUnknown
+	}
+}
@@ -0,0 +1,256 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/interpreter"
+	. "github.com/onflow/cadence/runtime/tests/utils"
+)
+
+const testTypeRegistryCode = `
+  pub struct Inner {
+      pub let y: String
+
+      init(y: String) {
+          self.y = y
+      }
+  }
+
+  pub struct S {
+      pub let x: Int
+      pub let inner: Inner
+
+      init(x: Int) {
+          self.x = x
+          self.inner = Inner(y: "inner")
+      }
+  }
+
+  pub fun test(): [S] {
+      return [S(x: 1), S(x: 2)]
+  }
+`
+
+func TestExportValueWithRegistry(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreterWithProgram(t, testTypeRegistryCode)
+
+	err := inter.Interpret()
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	actual, registry, err := ExportValueWithRegistry(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	stub := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+	}
+
+	innerStub := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "Inner",
+	}
+
+	// Each type is only defined once, in the registry
+
+	assert.Equal(t,
+		map[string]cadence.Type{
+			"S.test.S": &cadence.StructType{
+				Location:            TestLocation,
+				QualifiedIdentifier: "S",
+				Fields: []cadence.Field{
+					{
+						Identifier: "x",
+						Type:       cadence.IntType{},
+					},
+					{
+						Identifier: "inner",
+						Type:       innerStub,
+					},
+				},
+			},
+			"S.test.Inner": &cadence.StructType{
+				Location:            TestLocation,
+				QualifiedIdentifier: "Inner",
+				Fields: []cadence.Field{
+					{
+						Identifier: "y",
+						Type:       cadence.StringType{},
+					},
+				},
+			},
+		},
+		registry,
+	)
+
+	// The value only refers to the types by ID
+
+	newInner := func() cadence.Struct {
+		return cadence.NewStruct([]cadence.Value{
+			cadence.String("inner"),
+		}).WithType(innerStub)
+	}
+
+	assert.Equal(t,
+		cadence.NewArray([]cadence.Value{
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(1),
+				newInner(),
+			}).WithType(stub),
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(2),
+				newInner(),
+			}).WithType(stub),
+		}).WithType(cadence.VariableSizedArrayType{
+			ElementType: stub,
+		}),
+		actual,
+	)
+
+	elements := actual.(cadence.Array).Values
+	assert.Same(t,
+		elements[0].(cadence.Struct).StructType,
+		elements[1].(cadence.Struct).StructType,
+	)
+}
+
+func TestImportValueWithRegistry(t *testing.T) {
+
+	t.Parallel()
+
+	inter := newTestInterpreterWithProgram(t, testTypeRegistryCode)
+
+	err := inter.Interpret()
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	exported, registry, err := ExportValueWithRegistry(value, inter, interpreter.ReturnEmptyLocationRange)
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		imported, err := NewImporter().ImportValueWithRegistry(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			exported,
+			registry,
+			nil,
+		)
+		require.NoError(t, err)
+
+		AssertValuesEqual(t, inter, value, imported)
+	})
+
+	t.Run("missing definition", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Without the registry, the stubs have no fields
+
+		_, err := NewImporter().ImportValueWithRegistry(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			exported,
+			nil,
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import value: missing registry definition of type `S.test.S`")
+	})
+
+	t.Run("invalid definition", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := NewImporter().ImportValueWithRegistry(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			exported,
+			map[string]cadence.Type{
+				"S.test.S": &cadence.ResourceType{
+					Location:            TestLocation,
+					QualifiedIdentifier: "S",
+				},
+			},
+			nil,
+		)
+		require.Error(t, err)
+		assertUserError(t, err)
+
+		assert.EqualError(t, err, "cannot import value: invalid registry definition of type `S.test.S`")
+	})
+}
+
+func TestRegistryTypeResolverFieldDocStrings(t *testing.T) {
+
+	t.Parallel()
+
+	stub := &cadence.StructType{
+		Location:            TestLocation,
+		QualifiedIdentifier: "S",
+	}
+
+	resolver := &registryTypeResolver{
+		registry: map[string]cadence.Type{
+			"S.test.S": &cadence.StructType{
+				Location:            TestLocation,
+				QualifiedIdentifier: "S",
+				Fields: []cadence.Field{
+					{
+						Identifier: "x",
+						Type:       cadence.IntType{},
+						DocString:  "The x coordinate",
+					},
+				},
+			},
+		},
+		resolved: map[string]cadence.CompositeType{},
+	}
+
+	resolved, err := resolver.resolveType(stub)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]cadence.Field{
+			{
+				Identifier: "x",
+				Type:       cadence.IntType{},
+				DocString:  "The x coordinate",
+			},
+		},
+		resolved.(*cadence.StructType).Fields,
+	)
+}
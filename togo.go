@@ -0,0 +1,217 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+// ToGoOptions configures the conversion of values to generic Go values, see ToGoWithOptions.
+type ToGoOptions struct {
+	// OmitNilFields omits composite fields which have a nil optional value.
+	// By default, such fields are included with a nil value.
+	OmitNilFields bool
+	// IncludeCompositeMetadata includes the synthetic entries ToGoKindKey and ToGoTypeKey
+	// in the maps of converted composites, i.e. the composite kind (e.g. "Resource" or "Struct")
+	// and the qualified identifier of the composite type, if any.
+	IncludeCompositeMetadata bool
+	// PathsAsStrings converts paths to their full string form, e.g. "/public/foo", see Path.FullString.
+	// By default, paths are converted using Path.ToGoValue, i.e. to nil.
+	PathsAsStrings bool
+	// CompositesAsPairs converts composites to slices of pairs of field names and converted field values,
+	// i.e. [][2]any, in field order, instead of maps, e.g. for formats which lack ordered maps.
+	// The entries of IncludeCompositeMetadata are the leading pairs.
+	CompositesAsPairs bool
+}
+
+const (
+	// ToGoKindKey is the key of the composite kind entry, see ToGoOptions.IncludeCompositeMetadata
+	ToGoKindKey = "__kind"
+	// ToGoTypeKey is the key of the composite type entry, see ToGoOptions.IncludeCompositeMetadata
+	ToGoTypeKey = "__type"
+)
+
+// ToGo converts the given value to a generic Go value, using the default options.
+//
+// See ToGoWithOptions.
+func ToGo(value Value) any {
+	return ToGoWithOptions(value, ToGoOptions{})
+}
+
+// ToGoWithOptions converts the given value to a generic Go value.
+//
+// Unlike Value.ToGoValue, composites are converted to maps from field names to the converted field values,
+// instead of slices of the converted field values.
+// Optionals are converted to their converted inner value, or nil.
+// Arrays and dictionaries are converted to slices and maps of converted values.
+// All other values are converted using Value.ToGoValue.
+//
+// The keys of dictionaries are converted to hashable values, see toGoDictionaryKey.
+func ToGoWithOptions(value Value, options ToGoOptions) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+
+	case Optional:
+		if v.Value == nil {
+			return nil
+		}
+		return ToGoWithOptions(v.Value, options)
+
+	case Array:
+		result := make([]any, len(v.Values))
+		for i, element := range v.Values {
+			result[i] = ToGoWithOptions(element, options)
+		}
+		return result
+
+	case Dictionary:
+		result := make(map[any]any, len(v.Pairs))
+		for _, pair := range v.Pairs {
+			result[toGoDictionaryKey(pair.Key)] = ToGoWithOptions(pair.Value, options)
+		}
+		return result
+
+	case Struct, Resource, Event, Contract, Enum:
+		fields, values, _ := compositeFieldsAndValues(v)
+
+		if options.CompositesAsPairs {
+			return compositeToGoPairs(v, fields, values, options)
+		}
+
+		result := compositeToGo(fields, values, options)
+
+		if options.IncludeCompositeMetadata {
+			kind, qualifiedIdentifier := compositeKindAndQualifiedIdentifier(v)
+			result[ToGoKindKey] = kind
+			if qualifiedIdentifier != "" {
+				result[ToGoTypeKey] = qualifiedIdentifier
+			}
+		}
+
+		return result
+
+	case Path:
+		if options.PathsAsStrings {
+			return v.FullString()
+		}
+		return v.ToGoValue()
+
+	default:
+		return value.ToGoValue()
+	}
+}
+
+// toGoDictionaryKey converts the given dictionary key to a hashable Go value.
+//
+// Scalars are converted using Value.ToGoValue. Composites, e.g. enums, paths, and type values
+// are converted to their string form, e.g. "/public/foo": their Go values are either not hashable,
+// or nil, so all their entries would collapse into a single entry.
+func toGoDictionaryKey(key Value) any {
+	switch key.(type) {
+	case Struct, Resource, Event, Contract, Enum, Path, TypeValue:
+		return key.String()
+	default:
+		return key.ToGoValue()
+	}
+}
+
+func compositeToGo(fields []Field, values []Value, options ToGoOptions) map[string]any {
+	result := make(map[string]any, len(fields))
+
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+		value := values[i]
+
+		if options.OmitNilFields && isNil(value) {
+			continue
+		}
+
+		result[field.Identifier] = ToGoWithOptions(value, options)
+	}
+
+	return result
+}
+
+func compositeToGoPairs(value Value, fields []Field, values []Value, options ToGoOptions) [][2]any {
+	result := make([][2]any, 0, len(fields)+2)
+
+	if options.IncludeCompositeMetadata {
+		kind, qualifiedIdentifier := compositeKindAndQualifiedIdentifier(value)
+		result = append(result, [2]any{ToGoKindKey, kind})
+		if qualifiedIdentifier != "" {
+			result = append(result, [2]any{ToGoTypeKey, qualifiedIdentifier})
+		}
+	}
+
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+		value := values[i]
+
+		if options.OmitNilFields && isNil(value) {
+			continue
+		}
+
+		result = append(result, [2]any{
+			field.Identifier,
+			ToGoWithOptions(value, options),
+		})
+	}
+
+	return result
+}
+
+func compositeKindAndQualifiedIdentifier(value Value) (kind string, qualifiedIdentifier string) {
+	switch v := value.(type) {
+	case Struct:
+		kind = "Struct"
+		if v.StructType != nil {
+			qualifiedIdentifier = v.StructType.QualifiedIdentifier
+		}
+	case Resource:
+		kind = "Resource"
+		if v.ResourceType != nil {
+			qualifiedIdentifier = v.ResourceType.QualifiedIdentifier
+		}
+	case Event:
+		kind = "Event"
+		if v.EventType != nil {
+			qualifiedIdentifier = v.EventType.QualifiedIdentifier
+		}
+	case Contract:
+		kind = "Contract"
+		if v.ContractType != nil {
+			qualifiedIdentifier = v.ContractType.QualifiedIdentifier
+		}
+	case Enum:
+		kind = "Enum"
+		if v.EnumType != nil {
+			qualifiedIdentifier = v.EnumType.QualifiedIdentifier
+		}
+	}
+	return
+}
+
+func isNil(value Value) bool {
+	if value == nil {
+		return true
+	}
+	optional, ok := value.(Optional)
+	return ok && optional.Value == nil
+}
@@ -0,0 +1,296 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestToGo(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []Field{
+			{
+				Identifier: "a",
+				Type:       IntType{},
+			},
+			{
+				Identifier: "b",
+				Type:       NewOptionalType(StringType{}),
+			},
+			{
+				Identifier: "c",
+				Type: VariableSizedArrayType{
+					ElementType: NewOptionalType(StringType{}),
+				},
+			},
+		},
+	}
+
+	foo := NewStruct([]Value{
+		NewInt(1),
+		NewOptional(nil),
+		NewArray([]Value{
+			NewOptional(String("x")),
+			NewOptional(nil),
+		}),
+	}).WithType(fooType)
+
+	t.Run("include nil fields", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			map[string]any{
+				"a": big.NewInt(1),
+				"b": nil,
+				"c": []any{"x", nil},
+			},
+			ToGo(foo),
+		)
+	})
+
+	t.Run("omit nil fields", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			map[string]any{
+				"a": big.NewInt(1),
+				"c": []any{"x", nil},
+			},
+			ToGoWithOptions(foo, ToGoOptions{
+				OmitNilFields: true,
+			}),
+		)
+	})
+
+	t.Run("dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		dictionary := NewDictionary([]KeyValuePair{
+			{
+				Key:   String("foo"),
+				Value: foo,
+			},
+		})
+
+		assert.Equal(t,
+			map[any]any{
+				"foo": map[string]any{
+					"a": big.NewInt(1),
+					"c": []any{"x", nil},
+				},
+			},
+			ToGoWithOptions(dictionary, ToGoOptions{
+				OmitNilFields: true,
+			}),
+		)
+	})
+
+	t.Run("dictionary with enum keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		enumType := &EnumType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "E",
+			RawType:             UInt8Type{},
+			Fields: []Field{
+				{
+					Identifier: "rawValue",
+					Type:       UInt8Type{},
+				},
+			},
+		}
+
+		newEnum := func(rawValue uint8) Enum {
+			return NewEnum([]Value{
+				NewUInt8(rawValue),
+			}).WithType(enumType)
+		}
+
+		dictionary := NewDictionary([]KeyValuePair{
+			{
+				Key:   newEnum(1),
+				Value: String("a"),
+			},
+			{
+				Key:   newEnum(2),
+				Value: String("b"),
+			},
+		})
+
+		assert.Equal(t,
+			map[any]any{
+				newEnum(1).String(): "a",
+				newEnum(2).String(): "b",
+			},
+			ToGo(dictionary),
+		)
+	})
+
+	t.Run("dictionary with path keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		dictionary := NewDictionary([]KeyValuePair{
+			{
+				Key:   NewPath("public", "foo"),
+				Value: String("a"),
+			},
+			{
+				Key:   NewPath("storage", "bar"),
+				Value: String("b"),
+			},
+		})
+
+		assert.Equal(t,
+			map[any]any{
+				"/public/foo":  "a",
+				"/storage/bar": "b",
+			},
+			ToGo(dictionary),
+		)
+	})
+
+	t.Run("paths as strings", func(t *testing.T) {
+
+		t.Parallel()
+
+		array := NewArray([]Value{
+			NewPath("storage", "foo"),
+			NewPath("private", "bar"),
+			NewPath("public", "baz"),
+		})
+
+		assert.Equal(t,
+			[]any{"/storage/foo", "/private/bar", "/public/baz"},
+			ToGoWithOptions(array, ToGoOptions{
+				PathsAsStrings: true,
+			}),
+		)
+
+		assert.Equal(t,
+			[]any{nil, nil, nil},
+			ToGo(array),
+		)
+	})
+
+	t.Run("composite metadata", func(t *testing.T) {
+
+		t.Parallel()
+
+		barType := &ResourceType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Bar",
+			Fields: []Field{
+				{
+					Identifier: "uuid",
+					Type:       UInt64Type{},
+				},
+			},
+		}
+
+		bar := NewResource([]Value{
+			NewUInt64(1),
+		}).WithType(barType)
+
+		options := ToGoOptions{
+			IncludeCompositeMetadata: true,
+		}
+
+		assert.Equal(t,
+			map[string]any{
+				"__kind": "Resource",
+				"__type": "Bar",
+				"uuid":   uint64(1),
+			},
+			ToGoWithOptions(bar, options),
+		)
+
+		assert.Equal(t,
+			map[string]any{
+				"__kind": "Struct",
+				"__type": "Foo",
+				"a":      big.NewInt(1),
+				"b":      nil,
+				"c":      []any{"x", nil},
+			},
+			ToGoWithOptions(foo, options),
+		)
+	})
+
+	t.Run("composites as pairs", func(t *testing.T) {
+
+		t.Parallel()
+
+		nested := NewStruct([]Value{
+			NewInt(2),
+			NewOptional(String("y")),
+			NewArray([]Value{foo}),
+		}).WithType(fooType)
+
+		assert.Equal(t,
+			[][2]any{
+				{"a", big.NewInt(2)},
+				{"b", "y"},
+				{"c", []any{
+					[][2]any{
+						{"a", big.NewInt(1)},
+						{"b", nil},
+						{"c", []any{"x", nil}},
+					},
+				}},
+			},
+			ToGoWithOptions(nested, ToGoOptions{
+				CompositesAsPairs: true,
+			}),
+		)
+	})
+
+	t.Run("composites as pairs with metadata", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			[][2]any{
+				{"__kind", "Struct"},
+				{"__type", "Foo"},
+				{"a", big.NewInt(1)},
+				{"c", []any{"x", nil}},
+			},
+			ToGoWithOptions(foo, ToGoOptions{
+				CompositesAsPairs:        true,
+				IncludeCompositeMetadata: true,
+				OmitNilFields:            true,
+			}),
+		)
+	})
+}
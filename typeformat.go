@@ -0,0 +1,159 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formatTypeMaxLineLength is the length of a formatted function type
+// above which the parameters are formatted on separate lines
+const formatTypeMaxLineLength = 80
+
+const formatTypeIndentation = "    "
+
+// FormatType returns a readable representation of the given type, e.g. for display in tooling.
+//
+// Unlike Type.ID, the representation is not compact:
+// Composite and interface types are formatted using their qualified identifiers,
+// function types include their parameter labels and identifiers,
+// and the restrictions of restricted types are sorted.
+// Function types are formatted in Cadence syntax, like sema.FunctionType.QualifiedString,
+// e.g. `((_ a: Int, b: String): Bool)`.
+// Function types with long parameter lists are formatted on multiple lines,
+// with one parameter per line.
+func FormatType(t Type) string {
+	switch t := t.(type) {
+	case nil:
+		return ""
+
+	case OptionalType:
+		return fmt.Sprintf("%s?", FormatType(t.Type))
+
+	case VariableSizedArrayType:
+		return fmt.Sprintf("[%s]", FormatType(t.ElementType))
+
+	case ConstantSizedArrayType:
+		return fmt.Sprintf("[%s; %d]", FormatType(t.ElementType), t.Size)
+
+	case DictionaryType:
+		return fmt.Sprintf(
+			"{%s: %s}",
+			FormatType(t.KeyType),
+			FormatType(t.ElementType),
+		)
+
+	case ReferenceType:
+		formatted := fmt.Sprintf("&%s", FormatType(t.Type))
+		if t.Authorized {
+			formatted = "auth " + formatted
+		}
+		return formatted
+
+	case CapabilityType:
+		if t.BorrowType != nil {
+			return fmt.Sprintf("Capability<%s>", FormatType(t.BorrowType))
+		}
+		return "Capability"
+
+	case CompositeType:
+		return t.CompositeTypeQualifiedIdentifier()
+
+	case InterfaceType:
+		return t.InterfaceTypeQualifiedIdentifier()
+
+	case *RestrictedType:
+		restrictions := make([]string, len(t.Restrictions))
+		for i, restriction := range t.Restrictions {
+			restrictions[i] = FormatType(restriction)
+		}
+		sort.Strings(restrictions)
+
+		return fmt.Sprintf(
+			"%s{%s}",
+			FormatType(t.Type),
+			strings.Join(restrictions, ", "),
+		)
+
+	case *FunctionType:
+		return formatFunctionType(t)
+
+	default:
+		return t.ID()
+	}
+}
+
+func formatFunctionType(t *FunctionType) string {
+	parameters := make([]string, len(t.Parameters))
+	multiLine := false
+	for i, parameter := range t.Parameters {
+		formatted := formatParameter(parameter)
+		if strings.Contains(formatted, "\n") {
+			multiLine = true
+		}
+		parameters[i] = formatted
+	}
+
+	returnType := "Void"
+	if t.ReturnType != nil {
+		returnType = FormatType(t.ReturnType)
+	}
+
+	if !multiLine {
+		formatted := fmt.Sprintf(
+			"((%s): %s)",
+			strings.Join(parameters, ", "),
+			returnType,
+		)
+		if len(formatted) <= formatTypeMaxLineLength {
+			return formatted
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("((\n")
+	for i, parameter := range parameters {
+		builder.WriteString(formatTypeIndentation)
+		builder.WriteString(strings.ReplaceAll(parameter, "\n", "\n"+formatTypeIndentation))
+		if i < len(parameters)-1 {
+			builder.WriteRune(',')
+		}
+		builder.WriteRune('\n')
+	}
+	builder.WriteString("): ")
+	builder.WriteString(returnType)
+	builder.WriteRune(')')
+	return builder.String()
+}
+
+func formatParameter(parameter Parameter) string {
+	var builder strings.Builder
+	if parameter.Label != "" {
+		builder.WriteString(parameter.Label)
+		builder.WriteRune(' ')
+	}
+	if parameter.Identifier != "" {
+		builder.WriteString(parameter.Identifier)
+		builder.WriteString(": ")
+	}
+	builder.WriteString(FormatType(parameter.Type))
+	return builder.String()
+}
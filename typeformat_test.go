@@ -0,0 +1,172 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestFormatType(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &ResourceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+	}
+
+	barType := &ResourceInterfaceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Bar",
+	}
+
+	bazType := &ResourceInterfaceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Baz",
+	}
+
+	type testCase struct {
+		label    string
+		ty       Type
+		expected string
+	}
+
+	testCases := []testCase{
+		{
+			label:    "primitive",
+			ty:       IntType{},
+			expected: "Int",
+		},
+		{
+			label: "containers",
+			ty: DictionaryType{
+				KeyType: StringType{},
+				ElementType: OptionalType{
+					Type: ConstantSizedArrayType{
+						Size:        2,
+						ElementType: fooType,
+					},
+				},
+			},
+			expected: "{String: [Foo; 2]?}",
+		},
+		{
+			label: "restricted",
+			ty: &RestrictedType{
+				Type:         AnyResourceType{},
+				Restrictions: []Type{bazType, barType},
+			},
+			expected: "AnyResource{Bar, Baz}",
+		},
+		{
+			label: "reference to restricted",
+			ty: ReferenceType{
+				Authorized: true,
+				Type: &RestrictedType{
+					Type:         fooType,
+					Restrictions: []Type{barType},
+				},
+			},
+			expected: "auth &Foo{Bar}",
+		},
+		{
+			label: "function",
+			ty: &FunctionType{
+				Parameters: []Parameter{
+					{
+						Label:      "_",
+						Identifier: "a",
+						Type:       IntType{},
+					},
+					{
+						Identifier: "b",
+						Type:       VariableSizedArrayType{ElementType: StringType{}},
+					},
+				},
+				ReturnType: BoolType{},
+			},
+			expected: "((_ a: Int, b: [String]): Bool)",
+		},
+		{
+			label: "function, multi-line",
+			ty: &FunctionType{
+				Parameters: []Parameter{
+					{
+						Identifier: "vault",
+						Type: &RestrictedType{
+							Type:         fooType,
+							Restrictions: []Type{bazType, barType},
+						},
+					},
+					{
+						Identifier: "receiver",
+						Type: CapabilityType{
+							BorrowType: ReferenceType{Type: barType},
+						},
+					},
+					{
+						Identifier: "callback",
+						Type: &FunctionType{
+							Parameters: []Parameter{
+								{
+									Identifier: "someVeryLongParameterName",
+									Type:       UFix64Type{},
+								},
+								{
+									Identifier: "anotherVeryLongParameterName",
+									Type:       AddressType{},
+								},
+							},
+							ReturnType: VoidType{},
+						},
+					},
+				},
+				ReturnType: OptionalType{Type: fooType},
+			},
+			expected: "((\n" +
+				"    vault: Foo{Bar, Baz},\n" +
+				"    receiver: Capability<&Bar>,\n" +
+				"    callback: ((\n" +
+				"        someVeryLongParameterName: UFix64,\n" +
+				"        anotherVeryLongParameterName: Address\n" +
+				"    ): Void)\n" +
+				"): Foo?)",
+		},
+		{
+			label:    "function, without return type",
+			ty:       &FunctionType{},
+			expected: "((): Void)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, FormatType(testCase.ty))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CanonicalTypeID returns the canonical ID of the given type,
+// i.e. the type ID which is also used internally, e.g. by the checker and the interpreter.
+//
+// Unlike Type.ID, the ID is always derived from the structure of the type,
+// so two equivalent types compare equal by ID even if they were constructed independently,
+// e.g. restricted types and function types without a precomputed ID.
+//
+// NOTE: the function is not named TypeID, as TypeID is already the type which is only known by its ID.
+func CanonicalTypeID(t Type) string {
+	switch t := t.(type) {
+	case nil:
+		return ""
+
+	case OptionalType:
+		return fmt.Sprintf("%s?", CanonicalTypeID(t.Type))
+
+	case VariableSizedArrayType:
+		return fmt.Sprintf("[%s]", CanonicalTypeID(t.ElementType))
+
+	case ConstantSizedArrayType:
+		return fmt.Sprintf("[%s;%d]", CanonicalTypeID(t.ElementType), t.Size)
+
+	case DictionaryType:
+		return fmt.Sprintf(
+			"{%s:%s}",
+			CanonicalTypeID(t.KeyType),
+			CanonicalTypeID(t.ElementType),
+		)
+
+	case ReferenceType:
+		id := fmt.Sprintf("&%s", CanonicalTypeID(t.Type))
+		if t.Authorized {
+			id = "auth" + id
+		}
+		return id
+
+	case CapabilityType:
+		if t.BorrowType != nil {
+			return fmt.Sprintf("Capability<%s>", CanonicalTypeID(t.BorrowType))
+		}
+		return "Capability"
+
+	case *RestrictedType:
+		var builder strings.Builder
+		builder.WriteString(CanonicalTypeID(t.Type))
+		builder.WriteRune('{')
+		for i, restriction := range t.Restrictions {
+			if i > 0 {
+				builder.WriteRune(',')
+			}
+			builder.WriteString(CanonicalTypeID(restriction))
+		}
+		builder.WriteRune('}')
+		return builder.String()
+
+	case *FunctionType:
+		var builder strings.Builder
+		builder.WriteString("((")
+		for i, parameter := range t.Parameters {
+			if i > 0 {
+				builder.WriteRune(',')
+			}
+			builder.WriteString(CanonicalTypeID(parameter.Type))
+		}
+		builder.WriteString("):")
+		builder.WriteString(CanonicalTypeID(t.ReturnType))
+		builder.WriteRune(')')
+		return builder.String()
+
+	default:
+		return t.ID()
+	}
+}
+
+// CanonicalizeType returns the canonical form of the given type,
+// i.e. a copy of the type in which the restrictions of all contained restricted types
+// are sorted by their canonical type IDs, see CanonicalTypeID, and duplicate restrictions are removed,
+// e.g. so serialized forms of equivalent types are stable.
+//
+// Restricted types and function types in the result have their canonical type IDs.
+// Like for CanonicalTypeID, composite and interface types are not traversed.
+// The given type is not modified.
+func CanonicalizeType(t Type) Type {
+	switch t := t.(type) {
+	case nil:
+		return nil
+
+	case OptionalType:
+		return OptionalType{
+			Type: CanonicalizeType(t.Type),
+		}
+
+	case VariableSizedArrayType:
+		return VariableSizedArrayType{
+			ElementType: CanonicalizeType(t.ElementType),
+		}
+
+	case ConstantSizedArrayType:
+		return ConstantSizedArrayType{
+			ElementType: CanonicalizeType(t.ElementType),
+			Size:        t.Size,
+		}
+
+	case DictionaryType:
+		return DictionaryType{
+			KeyType:     CanonicalizeType(t.KeyType),
+			ElementType: CanonicalizeType(t.ElementType),
+		}
+
+	case ReferenceType:
+		return ReferenceType{
+			Authorized: t.Authorized,
+			Type:       CanonicalizeType(t.Type),
+		}
+
+	case CapabilityType:
+		return CapabilityType{
+			BorrowType: CanonicalizeType(t.BorrowType),
+		}
+
+	case *RestrictedType:
+		restrictionIDs := make(map[string]struct{}, len(t.Restrictions))
+		restrictions := make([]Type, 0, len(t.Restrictions))
+		for _, restriction := range t.Restrictions {
+			restriction = CanonicalizeType(restriction)
+			id := CanonicalTypeID(restriction)
+			if _, ok := restrictionIDs[id]; ok {
+				continue
+			}
+			restrictionIDs[id] = struct{}{}
+			restrictions = append(restrictions, restriction)
+		}
+
+		sort.SliceStable(restrictions, func(i, j int) bool {
+			return CanonicalTypeID(restrictions[i]) < CanonicalTypeID(restrictions[j])
+		})
+
+		canonical := NewRestrictedType("", CanonicalizeType(t.Type), restrictions)
+		return canonical.WithID(CanonicalTypeID(canonical))
+
+	case *FunctionType:
+		parameters := make([]Parameter, len(t.Parameters))
+		for i, parameter := range t.Parameters {
+			parameter.Type = CanonicalizeType(parameter.Type)
+			parameters[i] = parameter
+		}
+
+		canonical := NewFunctionType("", parameters, CanonicalizeType(t.ReturnType))
+		return canonical.WithID(CanonicalTypeID(canonical))
+
+	default:
+		return t
+	}
+}
+
+// TypeFingerprint returns a short, stable fingerprint of the given type,
+// i.e. the SHA-256 hash of the canonical type ID of its canonical form, see CanonicalTypeID and CanonicalizeType,
+// e.g. to cache data derived from the type of exported values.
+//
+// Structurally equal types have the same fingerprint, even if they were constructed independently,
+// or if the restrictions of their restricted types are in a different order.
+// Like their type IDs, composite and interface types are identified by their location and qualified identifier,
+// so the fingerprint does not change if their fields change.
+func TypeFingerprint(t Type) [32]byte {
+	return sha256.Sum256([]byte(CanonicalTypeID(CanonicalizeType(t))))
+}
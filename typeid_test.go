@@ -0,0 +1,414 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestCanonicalTypeID(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+	}
+
+	barType := &ResourceInterfaceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Bar",
+	}
+
+	type testCase struct {
+		label    string
+		ty       Type
+		expected string
+	}
+
+	testCases := []testCase{
+		{
+			label:    "primitive",
+			ty:       IntType{},
+			expected: string(sema.IntType.ID()),
+		},
+		{
+			label:    "variable-sized array",
+			ty:       VariableSizedArrayType{ElementType: StringType{}},
+			expected: "[String]",
+		},
+		{
+			label:    "constant-sized array",
+			ty:       ConstantSizedArrayType{ElementType: UInt8Type{}, Size: 2},
+			expected: "[UInt8;2]",
+		},
+		{
+			label:    "optional",
+			ty:       OptionalType{Type: OptionalType{Type: BoolType{}}},
+			expected: "Bool??",
+		},
+		{
+			label: "dictionary",
+			ty: DictionaryType{
+				KeyType:     StringType{},
+				ElementType: IntType{},
+			},
+			expected: "{String:Int}",
+		},
+		{
+			label:    "composite",
+			ty:       fooType,
+			expected: string(utils.TestLocation.TypeID(nil, "Foo")),
+		},
+		{
+			label: "composite without location",
+			ty: &StructType{
+				QualifiedIdentifier: "Foo",
+			},
+			expected: "Foo",
+		},
+		{
+			label: "array of composites",
+			ty: VariableSizedArrayType{
+				ElementType: OptionalType{Type: fooType},
+			},
+			expected: "[S.test.Foo?]",
+		},
+		{
+			label: "restricted without ID",
+			ty: &RestrictedType{
+				Type:         AnyResourceType{},
+				Restrictions: []Type{barType},
+			},
+			expected: "AnyResource{S.test.Bar}",
+		},
+		{
+			label: "reference to restricted without ID",
+			ty: ReferenceType{
+				Authorized: true,
+				Type: &RestrictedType{
+					Type:         AnyResourceType{},
+					Restrictions: []Type{barType},
+				},
+			},
+			expected: "auth&AnyResource{S.test.Bar}",
+		},
+		{
+			label: "function without ID",
+			ty: &FunctionType{
+				Parameters: []Parameter{
+					{Label: "_", Identifier: "a", Type: IntType{}},
+					{Identifier: "b", Type: fooType},
+				},
+				ReturnType: VoidType{},
+			},
+			expected: "((Int,S.test.Foo):Void)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.label, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, CanonicalTypeID(testCase.ty))
+		})
+	}
+
+	t.Run("independently constructed equivalent types", func(t *testing.T) {
+
+		t.Parallel()
+
+		newType := func(typeID string) Type {
+			return VariableSizedArrayType{
+				ElementType: (&RestrictedType{
+					Type: AnyStructType{},
+					Restrictions: []Type{
+						&StructInterfaceType{
+							Location:            common.StringLocation("test"),
+							QualifiedIdentifier: "I",
+						},
+					},
+				}).WithID(typeID),
+			}
+		}
+
+		a := newType("")
+		b := newType("AnyStruct{S.test.I}")
+
+		assert.NotEqual(t, a.ID(), b.ID())
+		assert.Equal(t, b.ID(), CanonicalTypeID(a))
+		assert.Equal(t, CanonicalTypeID(a), CanonicalTypeID(b))
+	})
+}
+
+func TestTypeFingerprint(t *testing.T) {
+
+	t.Parallel()
+
+	newFooType := func() *StructType {
+		return &StructType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Foo",
+		}
+	}
+
+	newType := func(fooType *StructType) Type {
+		return DictionaryType{
+			KeyType: StringType{},
+			ElementType: VariableSizedArrayType{
+				ElementType: OptionalType{
+					Type: &RestrictedType{
+						Type: fooType,
+						Restrictions: []Type{
+							&StructInterfaceType{
+								Location:            utils.TestLocation,
+								QualifiedIdentifier: "Bar",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("equal", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The types are constructed independently
+
+		assert.Equal(t,
+			TypeFingerprint(newType(newFooType())),
+			TypeFingerprint(newType(newFooType())),
+		)
+
+		assert.Equal(t,
+			TypeFingerprint(IntType{}),
+			TypeFingerprint(IntType{}),
+		)
+	})
+
+	t.Run("reordered restrictions", func(t *testing.T) {
+
+		t.Parallel()
+
+		newInterfaceType := func(qualifiedIdentifier string) *StructInterfaceType {
+			return &StructInterfaceType{
+				Location:            utils.TestLocation,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}
+		}
+
+		assert.Equal(t,
+			TypeFingerprint(&RestrictedType{
+				Type: AnyStructType{},
+				Restrictions: []Type{
+					newInterfaceType("I1"),
+					newInterfaceType("I2"),
+				},
+			}),
+			TypeFingerprint(&RestrictedType{
+				Type: AnyStructType{},
+				Restrictions: []Type{
+					newInterfaceType("I2"),
+					newInterfaceType("I1"),
+				},
+			}),
+		)
+	})
+
+	t.Run("different", func(t *testing.T) {
+
+		t.Parallel()
+
+		otherFooType := newFooType()
+		otherFooType.QualifiedIdentifier = "Baz"
+
+		fingerprints := map[[32]byte]Type{}
+
+		for _, ty := range []Type{
+			IntType{},
+			StringType{},
+			OptionalType{Type: IntType{}},
+			VariableSizedArrayType{ElementType: IntType{}},
+			ConstantSizedArrayType{ElementType: IntType{}, Size: 2},
+			ConstantSizedArrayType{ElementType: IntType{}, Size: 3},
+			ReferenceType{Type: IntType{}},
+			ReferenceType{Type: IntType{}, Authorized: true},
+			newType(newFooType()),
+			newType(otherFooType),
+		} {
+			fingerprint := TypeFingerprint(ty)
+			if other, ok := fingerprints[fingerprint]; ok {
+				t.Fatalf("types %s and %s have the same fingerprint", ty.ID(), other.ID())
+			}
+			fingerprints[fingerprint] = ty
+		}
+	})
+}
+
+func TestCanonicalizeType(t *testing.T) {
+
+	t.Parallel()
+
+	newInterfaceType := func(identifier string) *StructInterfaceType {
+		return &StructInterfaceType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: identifier,
+		}
+	}
+
+	aType := newInterfaceType("A")
+	bType := newInterfaceType("B")
+	cType := newInterfaceType("C")
+
+	t.Run("restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := NewRestrictedType(
+			"AnyStruct{S.test.C,S.test.A,S.test.B}",
+			AnyStructType{},
+			[]Type{cType, aType, bType},
+		)
+
+		canonical := CanonicalizeType(ty)
+
+		assert.Equal(t,
+			NewRestrictedType(
+				"AnyStruct{S.test.A,S.test.B,S.test.C}",
+				AnyStructType{},
+				[]Type{aType, bType, cType},
+			),
+			canonical,
+		)
+
+		// The given type is not modified
+		assert.Equal(t, []Type{cType, aType, bType}, ty.Restrictions)
+	})
+
+	t.Run("duplicate restrictions", func(t *testing.T) {
+
+		t.Parallel()
+
+		canonical := CanonicalizeType(&RestrictedType{
+			Type:         AnyStructType{},
+			Restrictions: []Type{bType, aType, bType},
+		})
+
+		assert.Equal(t,
+			NewRestrictedType(
+				"AnyStruct{S.test.A,S.test.B}",
+				AnyStructType{},
+				[]Type{aType, bType},
+			),
+			canonical,
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		newType := func(restrictions ...Type) Type {
+			return DictionaryType{
+				KeyType: StringType{},
+				ElementType: VariableSizedArrayType{
+					ElementType: OptionalType{
+						Type: CapabilityType{
+							BorrowType: ReferenceType{
+								Authorized: true,
+								Type: &RestrictedType{
+									Type:         AnyStructType{},
+									Restrictions: restrictions,
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+
+		expected := newType(aType, bType, cType).(DictionaryType)
+		expected.ElementType.(VariableSizedArrayType).
+			ElementType.(OptionalType).
+			Type.(CapabilityType).
+			BorrowType.(ReferenceType).
+			Type.(*RestrictedType).
+			WithID("AnyStruct{S.test.A,S.test.B,S.test.C}")
+
+		assert.Equal(t, expected, CanonicalizeType(newType(bType, cType, aType)))
+	})
+
+	t.Run("function type", func(t *testing.T) {
+
+		t.Parallel()
+
+		canonical := CanonicalizeType(&FunctionType{
+			Parameters: []Parameter{
+				{
+					Label:      "x",
+					Identifier: "x",
+					Type: &RestrictedType{
+						Type:         AnyStructType{},
+						Restrictions: []Type{bType, aType},
+					},
+				},
+			},
+			ReturnType: VoidType{},
+		})
+
+		assert.Equal(t,
+			NewFunctionType(
+				"((AnyStruct{S.test.A,S.test.B}):Void)",
+				[]Parameter{
+					{
+						Label:      "x",
+						Identifier: "x",
+						Type: NewRestrictedType(
+							"AnyStruct{S.test.A,S.test.B}",
+							AnyStructType{},
+							[]Type{aType, bType},
+						),
+					},
+				},
+				VoidType{},
+			),
+			canonical,
+		)
+	})
+
+	t.Run("other types", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, IntType{}, CanonicalizeType(IntType{}))
+		assert.Equal(t, aType, CanonicalizeType(aType))
+		assert.Nil(t, CanonicalizeType(nil))
+	})
+}
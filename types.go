@@ -878,6 +878,9 @@ func (t DictionaryType) ID() string {
 type Field struct {
 	Identifier string
 	Type       Type
+	// DocString is the documentation comment of the field, if any.
+	// It is only set for types exported with doc comments
+	DocString string
 }
 
 // Fields are always created in an array, which must be metered ahead of time.
@@ -921,6 +924,22 @@ type CompositeType interface {
 	CompositeInitializers() [][]Parameter
 }
 
+// CompositeTypeFields returns the fields of the given composite type,
+// in the order of the fields of the values of the type.
+//
+// The order is authoritative: the i-th field describes the i-th element of the Fields of a value
+// which has the given type, e.g. a Struct exported by the runtime.
+// The returned slice is a copy, i.e. it may be modified without affecting the type.
+func CompositeTypeFields(t CompositeType) []Field {
+	fields := t.CompositeFields()
+	if fields == nil {
+		return nil
+	}
+	result := make([]Field, len(fields))
+	copy(result, fields)
+	return result
+}
+
 // StructType
 
 type StructType struct {
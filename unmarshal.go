@@ -0,0 +1,299 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+
+// Unmarshal converts the given value to a Go value, and stores the result in the value pointed to by out,
+// e.g. to convert an exported struct to a specific Go struct.
+//
+// Composites are converted to Go structs by field name, like FromGoStruct,
+// i.e. the fields of the composite are stored in the exported fields of the Go struct
+// which have the name of the field as the value of the FromGoStructTag tag, e.g. `cadence:"id"`,
+// or as the name of the Go field, if the field has no tag.
+// Fields of the composite which have no Go field are ignored.
+//
+// Arrays and dictionaries are converted to Go slices, arrays, and maps.
+// Optionals are converted to their converted inner value, or the zero value, if nil.
+// Values are stored in pointers by allocating a new value, if the pointer is nil,
+// and values are stored as-is in Go values of the Cadence value type, e.g. cadence.Value or cadence.UInt8.
+// Values are stored in empty interfaces as converted by ToGo.
+// All other values are converted using Value.ToGoValue. Numbers are checked for overflow.
+func Unmarshal(value Value, out any) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("cannot unmarshal value into %T: not a non-nil pointer", out)
+	}
+
+	err := unmarshalValue(value, target.Elem())
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal value: %w", err)
+	}
+
+	return nil
+}
+
+func unmarshalValue(value Value, target reflect.Value) error {
+	targetType := target.Type()
+
+	if value != nil {
+		valueType := reflect.TypeOf(value)
+		if valueType == targetType ||
+			(valueType.AssignableTo(targetType) && targetType.NumMethod() > 0) {
+
+			// The target is the type of the value, or an interface like Value
+			target.Set(reflect.ValueOf(value))
+			return nil
+		}
+	}
+
+	if optional, ok := value.(Optional); ok {
+		if optional.Value == nil {
+			target.Set(reflect.Zero(targetType))
+			return nil
+		}
+		value = optional.Value
+	}
+
+	if value == nil {
+		target.Set(reflect.Zero(targetType))
+		return nil
+	}
+
+	switch targetType.Kind() {
+	case reflect.Ptr:
+		if targetType == bigIntType {
+			break
+		}
+		if target.IsNil() {
+			target.Set(reflect.New(targetType.Elem()))
+		}
+		return unmarshalValue(value, target.Elem())
+
+	case reflect.Interface:
+		if targetType.NumMethod() == 0 {
+			target.Set(reflect.ValueOf(ToGo(value)))
+			return nil
+		}
+	}
+
+	switch value := value.(type) {
+	case Struct, Resource, Event, Contract, Enum:
+		return unmarshalComposite(value, target)
+
+	case Array:
+		return unmarshalArray(value, target)
+
+	case Dictionary:
+		return unmarshalDictionary(value, target)
+	}
+
+	return unmarshalScalar(value, target)
+}
+
+func unmarshalComposite(value Value, target reflect.Value) error {
+	targetType := target.Type()
+
+	if targetType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal composite into Go value of type %s", targetType)
+	}
+
+	goFields := map[string]reflect.Value{}
+	for i := 0; i < targetType.NumField(); i++ {
+		name, ok := goStructFieldName(targetType.Field(i))
+		if !ok {
+			continue
+		}
+		goFields[name] = target.Field(i)
+	}
+
+	fields, values, _ := compositeFieldsAndValues(value)
+
+	for i, field := range fields {
+		if i >= len(values) {
+			break
+		}
+
+		goField, ok := goFields[field.Identifier]
+		if !ok {
+			continue
+		}
+
+		err := unmarshalValue(values[i], goField)
+		if err != nil {
+			return fmt.Errorf("field `%s`: %w", field.Identifier, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalArray(value Array, target reflect.Value) error {
+	targetType := target.Type()
+
+	switch targetType.Kind() {
+	case reflect.Slice:
+		target.Set(reflect.MakeSlice(targetType, len(value.Values), len(value.Values)))
+
+	case reflect.Array:
+		if targetType.Len() != len(value.Values) {
+			return fmt.Errorf(
+				"cannot unmarshal array of %d elements into Go value of type %s",
+				len(value.Values),
+				targetType,
+			)
+		}
+
+	default:
+		return fmt.Errorf("cannot unmarshal array into Go value of type %s", targetType)
+	}
+
+	for i, element := range value.Values {
+		err := unmarshalValue(element, target.Index(i))
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalDictionary(value Dictionary, target reflect.Value) error {
+	targetType := target.Type()
+
+	if targetType.Kind() != reflect.Map {
+		return fmt.Errorf("cannot unmarshal dictionary into Go value of type %s", targetType)
+	}
+
+	result := reflect.MakeMapWithSize(targetType, len(value.Pairs))
+
+	for _, pair := range value.Pairs {
+		key := reflect.New(targetType.Key()).Elem()
+		err := unmarshalValue(pair.Key, key)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", pair.Key, err)
+		}
+
+		element := reflect.New(targetType.Elem()).Elem()
+		err = unmarshalValue(pair.Value, element)
+		if err != nil {
+			return fmt.Errorf("value of key %s: %w", pair.Key, err)
+		}
+
+		result.SetMapIndex(key, element)
+	}
+
+	target.Set(result)
+
+	return nil
+}
+
+func unmarshalScalar(value Value, target reflect.Value) error {
+	targetType := target.Type()
+
+	goValue := reflect.ValueOf(value.ToGoValue())
+	if !goValue.IsValid() {
+		return fmt.Errorf(
+			"cannot unmarshal value of type `%s` into Go value of type %s",
+			value.Type().ID(),
+			targetType,
+		)
+	}
+
+	if bigInt, ok := goValue.Interface().(*big.Int); ok && targetType != bigIntType {
+		switch targetType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !bigInt.IsInt64() || target.OverflowInt(bigInt.Int64()) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetInt(bigInt.Int64())
+			return nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if !bigInt.IsUint64() || target.OverflowUint(bigInt.Uint64()) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetUint(bigInt.Uint64())
+			return nil
+		}
+	}
+
+	switch goValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch targetType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if target.OverflowInt(goValue.Int()) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetInt(goValue.Int())
+			return nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if goValue.Int() < 0 || target.OverflowUint(uint64(goValue.Int())) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetUint(uint64(goValue.Int()))
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch targetType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if goValue.Uint() > uint64(1<<63-1) || target.OverflowInt(int64(goValue.Uint())) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetInt(int64(goValue.Uint()))
+			return nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if target.OverflowUint(goValue.Uint()) {
+				return newUnmarshalOverflowError(value, targetType)
+			}
+			target.SetUint(goValue.Uint())
+			return nil
+		}
+	}
+
+	if goValue.Type().AssignableTo(targetType) {
+		target.Set(goValue)
+		return nil
+	}
+
+	if goValue.Kind() == targetType.Kind() && goValue.Type().ConvertibleTo(targetType) {
+		// e.g. a string to a named string type
+		target.Set(goValue.Convert(targetType))
+		return nil
+	}
+
+	return fmt.Errorf(
+		"cannot unmarshal value of type `%s` into Go value of type %s",
+		value.Type().ID(),
+		targetType,
+	)
+}
+
+func newUnmarshalOverflowError(value Value, targetType reflect.Type) error {
+	return fmt.Errorf("cannot unmarshal %s into Go value of type %s: overflow", value, targetType)
+}
@@ -0,0 +1,243 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestUnmarshal(t *testing.T) {
+
+	t.Parallel()
+
+	innerType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Inner",
+		Fields: []Field{
+			{
+				Identifier: "x",
+				Type:       IntType{},
+			},
+		},
+	}
+
+	outerType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Outer",
+		Fields: []Field{
+			{
+				Identifier: "id",
+				Type:       UInt64Type{},
+			},
+			{
+				Identifier: "name",
+				Type:       StringType{},
+			},
+			{
+				Identifier: "tags",
+				Type:       VariableSizedArrayType{ElementType: StringType{}},
+			},
+			{
+				Identifier: "scores",
+				Type:       DictionaryType{KeyType: StringType{}, ElementType: UInt8Type{}},
+			},
+			{
+				Identifier: "inner",
+				Type:       innerType,
+			},
+			{
+				Identifier: "optionalInner",
+				Type:       OptionalType{Type: innerType},
+			},
+			{
+				Identifier: "balance",
+				Type:       UFix64Type{},
+			},
+			{
+				Identifier: "ignored",
+				Type:       BoolType{},
+			},
+		},
+	}
+
+	newInner := func(x int) Struct {
+		return NewStruct([]Value{NewInt(x)}).WithType(innerType)
+	}
+
+	value := NewStruct([]Value{
+		NewUInt64(42),
+		String("test"),
+		NewArray([]Value{String("a"), String("b")}),
+		NewDictionary([]KeyValuePair{
+			{Key: String("a"), Value: NewUInt8(1)},
+		}),
+		newInner(2),
+		NewOptional(newInner(3)),
+		UFix64(150000000),
+		NewBool(true),
+	}).WithType(outerType)
+
+	t.Run("struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		type inner struct {
+			X int64 `cadence:"x"`
+		}
+
+		type outer struct {
+			ID            uint64           `cadence:"id"`
+			Name          string           `cadence:"name"`
+			Tags          []string         `cadence:"tags"`
+			Scores        map[string]uint8 `cadence:"scores"`
+			Inner         inner            `cadence:"inner"`
+			OptionalInner *inner           `cadence:"optionalInner"`
+			Balance       UFix64           `cadence:"balance"`
+			Ignored       bool             `cadence:"-"`
+		}
+
+		var actual outer
+		err := Unmarshal(value, &actual)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			outer{
+				ID:            42,
+				Name:          "test",
+				Tags:          []string{"a", "b"},
+				Scores:        map[string]uint8{"a": 1},
+				Inner:         inner{X: 2},
+				OptionalInner: &inner{X: 3},
+				Balance:       UFix64(150000000),
+			},
+			actual,
+		)
+	})
+
+	t.Run("untagged, values", func(t *testing.T) {
+
+		t.Parallel()
+
+		type inner struct {
+			X *big.Int `cadence:"x"`
+		}
+
+		type outer struct {
+			Name  Value
+			Inner inner  `cadence:"inner"`
+			Other string `cadence:"other"`
+		}
+
+		actual := outer{
+			Other: "unchanged",
+		}
+		err := Unmarshal(value, &actual)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			outer{
+				Inner: inner{X: big.NewInt(2)},
+				Other: "unchanged",
+			},
+			actual,
+		)
+
+		var name struct {
+			Name Value `cadence:"name"`
+		}
+		err = Unmarshal(value, &name)
+		require.NoError(t, err)
+		assert.Equal(t, String("test"), name.Name)
+	})
+
+	t.Run("nil optional", func(t *testing.T) {
+
+		t.Parallel()
+
+		actual := &struct {
+			X int `cadence:"x"`
+		}{}
+
+		err := Unmarshal(NewOptional(nil), &actual)
+		require.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		var actual struct {
+			Name int `cadence:"name"`
+		}
+
+		err := Unmarshal(value, &actual)
+		require.EqualError(t,
+			err,
+			"cannot unmarshal value: field `name`: "+
+				"cannot unmarshal value of type `String` into Go value of type int",
+		)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+
+		t.Parallel()
+
+		var actual struct {
+			Inner struct {
+				X int8 `cadence:"x"`
+			} `cadence:"inner"`
+		}
+
+		err := Unmarshal(
+			NewStruct([]Value{
+				NewUInt64(1),
+				String(""),
+				NewArray(nil),
+				NewDictionary(nil),
+				newInner(1000),
+				NewOptional(nil),
+				UFix64(0),
+				NewBool(false),
+			}).WithType(outerType),
+			&actual,
+		)
+		require.EqualError(t,
+			err,
+			"cannot unmarshal value: field `inner`: field `x`: "+
+				"cannot unmarshal 1000 into Go value of type int8: overflow",
+		)
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+
+		t.Parallel()
+
+		var actual struct{}
+
+		err := Unmarshal(value, actual)
+		require.EqualError(t, err, "cannot unmarshal value into struct {}: not a non-nil pointer")
+	})
+}
@@ -0,0 +1,449 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// ValidateValue checks that the given value structurally conforms to the given type,
+// without an interpreter, e.g. before a user-supplied value is imported.
+//
+// Composites must have a conforming value for each field of the composite type,
+// array elements and dictionary keys and values must conform to the element types,
+// and only optional types admit nil. Non-optional values conform to an optional type
+// if they conform to its inner type.
+// Abstract types, e.g. AnyStruct or Integer, admit the values of all their subtypes.
+// Interface types admit all composites of the same kind,
+// as conformances are not known without the program,
+// so restricted types only validate the restricted type.
+// Bytes conform to arrays of UInt8 values, as they are imported as such.
+// All other values must have the given type.
+//
+// The returned error contains the path of the invalid value, see Query.
+func ValidateValue(value Value, ty Type) error {
+	return validateValue(value, ty, "")
+}
+
+func validateValue(value Value, ty Type, path string) error {
+	if ty == nil {
+		return nil
+	}
+
+	if value == nil {
+		return newValidationError(path, "missing value of type `%s`", ty.ID())
+	}
+
+	switch ty := ty.(type) {
+	case AnyType:
+		return nil
+
+	case AnyStructType:
+		if isResourceValue(value) {
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return nil
+
+	case AnyResourceType:
+		if !isResourceValue(value) {
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return nil
+
+	case OptionalType:
+		if optional, ok := value.(Optional); ok {
+			if optional.Value == nil {
+				return nil
+			}
+			value = optional.Value
+		}
+		return validateValue(value, ty.Type, path)
+	}
+
+	if optional, ok := value.(Optional); ok {
+		if optional.Value == nil {
+			return newValidationError(path, "unexpected nil, expected value of type `%s`", ty.ID())
+		}
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	switch ty := ty.(type) {
+	case VariableSizedArrayType:
+		return validateArray(value, ty, ty.ElementType, -1, path)
+
+	case ConstantSizedArrayType:
+		return validateArray(value, ty, ty.ElementType, int(ty.Size), path)
+
+	case DictionaryType:
+		return validateDictionary(value, ty, path)
+
+	case *StructType, *ResourceType, *EventType, *ContractType, *EnumType:
+		return validateComposite(value, ty.(CompositeType), path)
+
+	case *StructInterfaceType:
+		return validateCompositeKind(value, ty, common.CompositeKindStructure, path)
+
+	case *ResourceInterfaceType:
+		return validateCompositeKind(value, ty, common.CompositeKindResource, path)
+
+	case *ContractInterfaceType:
+		return validateCompositeKind(value, ty, common.CompositeKindContract, path)
+
+	case *RestrictedType:
+		// The restrictions are interfaces, so only the restricted type can be validated
+		return validateValue(value, ty.Type, path)
+
+	case NumberType, SignedNumberType,
+		IntegerType, SignedIntegerType,
+		FixedPointType, SignedFixedPointType:
+
+		if !isNumberValueOfAbstractType(value, ty) {
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return nil
+
+	case PathType, CapabilityPathType, StoragePathType, PublicPathType, PrivatePathType:
+		pathValue, ok := value.(Path)
+		if !ok || !isPathOfType(pathValue, ty) {
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return nil
+
+	case CapabilityType:
+		capability, ok := value.(Capability)
+		if !ok ||
+			(ty.BorrowType != nil &&
+				capability.BorrowType != nil &&
+				CanonicalTypeID(ty.BorrowType) != CanonicalTypeID(capability.BorrowType)) {
+
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return nil
+	}
+
+	switch value.(type) {
+	case Struct, Resource, Event, Contract, Enum, Array, Dictionary:
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	valueType := value.Type()
+	if valueType == nil || valueType.ID() != ty.ID() {
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	return nil
+}
+
+func validateArray(value Value, ty ArrayType, elementType Type, size int, path string) error {
+
+	// Bytes are imported as arrays of UInt8 values
+
+	if bytes, ok := value.(Bytes); ok {
+		if _, ok := elementType.(UInt8Type); !ok {
+			return newValidationTypeMismatchError(path, ty, value)
+		}
+		return validateArraySize(ty, size, len(bytes), path)
+	}
+
+	array, ok := value.(Array)
+	if !ok {
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	err := validateArraySize(ty, size, len(array.Values), path)
+	if err != nil {
+		return err
+	}
+
+	for i, element := range array.Values {
+		err := validateValue(element, elementType, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateArraySize(ty ArrayType, size int, count int, path string) error {
+	if size >= 0 && count != size {
+		return newValidationError(
+			path,
+			"expected %d elements for type `%s`, got %d",
+			size,
+			ty.ID(),
+			count,
+		)
+	}
+	return nil
+}
+
+func validateDictionary(value Value, ty DictionaryType, path string) error {
+	dictionary, ok := value.(Dictionary)
+	if !ok {
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	for _, pair := range dictionary.Pairs {
+		if pair.Key == nil {
+			return newValidationError(path, "missing key of type `%s`", ty.KeyType.ID())
+		}
+
+		elementPath := fmt.Sprintf("%s[%s]", path, pair.Key)
+
+		err := validateValue(pair.Key, ty.KeyType, elementPath)
+		if err != nil {
+			return err
+		}
+
+		err = validateValue(pair.Value, ty.ElementType, elementPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateComposite(value Value, ty CompositeType, path string) error {
+	valueType := compositeTypeOfValue(value)
+	_, values, ok := compositeFieldsAndValues(value)
+	if !ok ||
+		compositeKind(value) != compositeKindOfType(ty) ||
+		(valueType != nil && valueType.ID() != ty.ID()) {
+
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+
+	fields := ty.CompositeFields()
+
+	for i, field := range fields {
+		fieldPath := field.Identifier
+		if path != "" {
+			fieldPath = path + "." + field.Identifier
+		}
+
+		if i >= len(values) {
+			return newValidationError(fieldPath, "missing field of type `%s`", field.Type.ID())
+		}
+
+		err := validateValue(values[i], field.Type, fieldPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(values) > len(fields) {
+		return newValidationError(
+			path,
+			"expected %d fields for type `%s`, got %d",
+			len(fields),
+			ty.ID(),
+			len(values),
+		)
+	}
+
+	return nil
+}
+
+func validateCompositeKind(value Value, ty Type, kind common.CompositeKind, path string) error {
+	if compositeKind(value) != kind {
+		return newValidationTypeMismatchError(path, ty, value)
+	}
+	return nil
+}
+
+func compositeKind(value Value) common.CompositeKind {
+	switch value.(type) {
+	case Struct:
+		return common.CompositeKindStructure
+	case Resource:
+		return common.CompositeKindResource
+	case Event:
+		return common.CompositeKindEvent
+	case Contract:
+		return common.CompositeKindContract
+	case Enum:
+		return common.CompositeKindEnum
+	default:
+		return common.CompositeKindUnknown
+	}
+}
+
+// compositeTypeOfValue returns the type of the given composite value, if any.
+//
+// NOTE: Value.Type cannot be used, as it returns a non-nil interface for a nil composite type.
+func compositeTypeOfValue(value Value) CompositeType {
+	switch value := value.(type) {
+	case Struct:
+		if value.StructType != nil {
+			return value.StructType
+		}
+	case Resource:
+		if value.ResourceType != nil {
+			return value.ResourceType
+		}
+	case Event:
+		if value.EventType != nil {
+			return value.EventType
+		}
+	case Contract:
+		if value.ContractType != nil {
+			return value.ContractType
+		}
+	case Enum:
+		if value.EnumType != nil {
+			return value.EnumType
+		}
+	}
+	return nil
+}
+
+func compositeKindOfType(ty CompositeType) common.CompositeKind {
+	switch ty.(type) {
+	case *StructType:
+		return common.CompositeKindStructure
+	case *ResourceType:
+		return common.CompositeKindResource
+	case *EventType:
+		return common.CompositeKindEvent
+	case *ContractType:
+		return common.CompositeKindContract
+	case *EnumType:
+		return common.CompositeKindEnum
+	default:
+		return common.CompositeKindUnknown
+	}
+}
+
+// isResourceValue returns true if the given value is a resource,
+// or contains a resource.
+func isResourceValue(value Value) bool {
+	switch value := value.(type) {
+	case Resource:
+		return true
+
+	case Optional:
+		return value.Value != nil && isResourceValue(value.Value)
+
+	case Array:
+		for _, element := range value.Values {
+			if isResourceValue(element) {
+				return true
+			}
+		}
+
+	case Dictionary:
+		for _, pair := range value.Pairs {
+			if isResourceValue(pair.Value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isNumberValueOfAbstractType(value Value, ty Type) bool {
+	var signed, integer, fixedPoint bool
+
+	switch value.(type) {
+	case Int, Int8, Int16, Int32, Int64, Int128, Int256:
+		signed = true
+		integer = true
+	case UInt, UInt8, UInt16, UInt32, UInt64, UInt128, UInt256,
+		Word8, Word16, Word32, Word64:
+		integer = true
+	case Fix64:
+		signed = true
+		fixedPoint = true
+	case UFix64:
+		fixedPoint = true
+	default:
+		return false
+	}
+
+	switch ty.(type) {
+	case NumberType:
+		return true
+	case SignedNumberType:
+		return signed
+	case IntegerType:
+		return integer
+	case SignedIntegerType:
+		return signed && integer
+	case FixedPointType:
+		return fixedPoint
+	case SignedFixedPointType:
+		return signed && fixedPoint
+	default:
+		return false
+	}
+}
+
+func isPathOfType(path Path, ty Type) bool {
+	domain := common.PathDomainFromIdentifier(path.Domain)
+
+	switch ty.(type) {
+	case PathType:
+		return domain != common.PathDomainUnknown
+	case CapabilityPathType:
+		return domain == common.PathDomainPublic ||
+			domain == common.PathDomainPrivate
+	case StoragePathType:
+		return domain == common.PathDomainStorage
+	case PublicPathType:
+		return domain == common.PathDomainPublic
+	case PrivatePathType:
+		return domain == common.PathDomainPrivate
+	default:
+		return false
+	}
+}
+
+func newValidationError(path string, format string, arguments ...any) error {
+	message := fmt.Sprintf(format, arguments...)
+	if path == "" {
+		return fmt.Errorf("invalid value: %s", message)
+	}
+	return fmt.Errorf("invalid value at `%s`: %s", path, message)
+}
+
+func newValidationTypeMismatchError(path string, expectedType Type, value Value) error {
+	actualType := "unknown"
+	switch value := value.(type) {
+	case Struct, Resource, Event, Contract, Enum:
+		if valueType := compositeTypeOfValue(value); valueType != nil {
+			actualType = valueType.ID()
+		}
+	default:
+		if valueType := value.Type(); valueType != nil {
+			actualType = valueType.ID()
+		}
+	}
+
+	return newValidationError(
+		path,
+		"expected value of type `%s`, got value of type `%s`",
+		expectedType.ID(),
+		actualType,
+	)
+}
@@ -0,0 +1,290 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestValidateValue(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []Field{
+			{
+				Identifier: "a",
+				Type:       IntType{},
+			},
+			{
+				Identifier: "b",
+				Type:       NewOptionalType(StringType{}),
+			},
+			{
+				Identifier: "c",
+				Type: VariableSizedArrayType{
+					ElementType: UInt8Type{},
+				},
+			},
+		},
+	}
+
+	barType := &ResourceType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Bar",
+		Fields: []Field{
+			{
+				Identifier: "uuid",
+				Type:       UInt64Type{},
+			},
+		},
+	}
+
+	newFoo := func(a, b, c Value) Struct {
+		return NewStruct([]Value{a, b, c}).WithType(fooType)
+	}
+
+	t.Run("conforming", func(t *testing.T) {
+
+		t.Parallel()
+
+		for name, test := range map[string]struct {
+			value Value
+			ty    Type
+		}{
+			"Int": {
+				value: NewInt(1),
+				ty:    IntType{},
+			},
+			"nil": {
+				value: NewOptional(nil),
+				ty:    NewOptionalType(IntType{}),
+			},
+			"some": {
+				value: NewOptional(NewInt(1)),
+				ty:    NewOptionalType(IntType{}),
+			},
+			"non-optional for optional": {
+				value: NewInt(1),
+				ty:    NewOptionalType(IntType{}),
+			},
+			"abstract integer": {
+				value: NewUInt8(1),
+				ty:    IntegerType{},
+			},
+			"abstract signed fixed-point": {
+				value: Fix64(-1),
+				ty:    SignedFixedPointType{},
+			},
+			"AnyStruct": {
+				value: String("foo"),
+				ty:    AnyStructType{},
+			},
+			"constant-sized array": {
+				value: NewArray([]Value{NewInt(1), NewInt(2)}),
+				ty: ConstantSizedArrayType{
+					ElementType: IntType{},
+					Size:        2,
+				},
+			},
+			"dictionary": {
+				value: NewDictionary([]KeyValuePair{
+					{
+						Key:   String("foo"),
+						Value: NewOptional(nil),
+					},
+				}),
+				ty: DictionaryType{
+					KeyType:     StringType{},
+					ElementType: NewOptionalType(IntType{}),
+				},
+			},
+			"struct": {
+				value: newFoo(
+					NewInt(1),
+					NewOptional(nil),
+					NewArray([]Value{NewUInt8(2)}),
+				),
+				ty: fooType,
+			},
+			"struct without type": {
+				value: NewStruct([]Value{
+					NewInt(1),
+					NewOptional(String("x")),
+					NewArray(nil),
+				}),
+				ty: fooType,
+			},
+			"resource": {
+				value: NewResource([]Value{NewUInt64(1)}).WithType(barType),
+				ty:    AnyResourceType{},
+			},
+			"storage path": {
+				value: Path{Domain: "storage", Identifier: "foo"},
+				ty:    StoragePathType{},
+			},
+			"bytes for variable-sized array": {
+				value: NewBytes([]byte{1, 2}),
+				ty:    VariableSizedArrayType{ElementType: UInt8Type{}},
+			},
+			"bytes for constant-sized array": {
+				value: NewBytes([]byte{1, 2}),
+				ty:    ConstantSizedArrayType{ElementType: UInt8Type{}, Size: 2},
+			},
+		} {
+			test := test
+
+			t.Run(name, func(t *testing.T) {
+
+				t.Parallel()
+
+				require.NoError(t, ValidateValue(test.value, test.ty))
+			})
+		}
+	})
+
+	t.Run("non-conforming", func(t *testing.T) {
+
+		t.Parallel()
+
+		for name, test := range map[string]struct {
+			value Value
+			ty    Type
+			err   string
+		}{
+			"mismatched scalar": {
+				value: String("1"),
+				ty:    IntType{},
+				err:   "invalid value: expected value of type `Int`, got value of type `String`",
+			},
+			"nil for non-optional": {
+				value: NewOptional(nil),
+				ty:    IntType{},
+				err:   "invalid value: unexpected nil, expected value of type `Int`",
+			},
+			"mismatched abstract type": {
+				value: NewInt(-1),
+				ty:    FixedPointType{},
+				err:   "invalid value: expected value of type `FixedPoint`, got value of type `Int`",
+			},
+			"resource for AnyStruct": {
+				value: NewResource([]Value{NewUInt64(1)}).WithType(barType),
+				ty:    AnyStructType{},
+				err:   "invalid value: expected value of type `AnyStruct`, got value of type `S.test.Bar`",
+			},
+			"constant-sized array size": {
+				value: NewArray([]Value{NewInt(1)}),
+				ty: ConstantSizedArrayType{
+					ElementType: IntType{},
+					Size:        2,
+				},
+				err: "invalid value: expected 2 elements for type `[Int;2]`, got 1",
+			},
+			"array element": {
+				value: NewArray([]Value{NewInt(1), String("2")}),
+				ty: VariableSizedArrayType{
+					ElementType: IntType{},
+				},
+				err: "invalid value at `[1]`: expected value of type `Int`, got value of type `String`",
+			},
+			"dictionary value": {
+				value: NewDictionary([]KeyValuePair{
+					{
+						Key:   String("foo"),
+						Value: NewBool(true),
+					},
+				}),
+				ty: DictionaryType{
+					KeyType:     StringType{},
+					ElementType: IntType{},
+				},
+				err: "invalid value at `[\"foo\"]`: expected value of type `Int`, got value of type `Bool`",
+			},
+			"missing field": {
+				value: NewStruct([]Value{NewInt(1)}).WithType(fooType),
+				ty:    fooType,
+				err:   "invalid value at `b`: missing field of type `String?`",
+			},
+			"field type": {
+				value: newFoo(
+					NewInt(1),
+					NewOptional(NewInt(2)),
+					NewArray(nil),
+				),
+				ty:  fooType,
+				err: "invalid value at `b`: expected value of type `String`, got value of type `Int`",
+			},
+			"nested field element": {
+				value: newFoo(
+					NewInt(1),
+					NewOptional(nil),
+					NewArray([]Value{NewInt(2)}),
+				),
+				ty:  fooType,
+				err: "invalid value at `c[0]`: expected value of type `UInt8`, got value of type `Int`",
+			},
+			"composite kind": {
+				value: NewResource([]Value{NewUInt64(1)}),
+				ty:    fooType,
+				err:   "invalid value: expected value of type `S.test.Foo`, got value of type `unknown`",
+			},
+			"composite type": {
+				value: NewStruct([]Value{NewUInt64(1)}).WithType(&StructType{
+					Location:            utils.TestLocation,
+					QualifiedIdentifier: "Baz",
+				}),
+				ty:  fooType,
+				err: "invalid value: expected value of type `S.test.Foo`, got value of type `S.test.Baz`",
+			},
+			"path domain": {
+				value: Path{Domain: "public", Identifier: "foo"},
+				ty:    StoragePathType{},
+				err:   "invalid value: expected value of type `StoragePath`, got value of type `Path`",
+			},
+			"bytes length": {
+				value: NewBytes([]byte{1, 2}),
+				ty:    ConstantSizedArrayType{ElementType: UInt8Type{}, Size: 3},
+				err:   "invalid value: expected 3 elements for type `[UInt8;3]`, got 2",
+			},
+			"bytes for non-UInt8 array": {
+				value: NewBytes([]byte{1, 2}),
+				ty:    VariableSizedArrayType{ElementType: IntType{}},
+				err:   "invalid value: expected value of type `[Int]`, got value of type `Bytes`",
+			},
+		} {
+			test := test
+
+			t.Run(name, func(t *testing.T) {
+
+				t.Parallel()
+
+				err := ValidateValue(test.value, test.ty)
+				require.Error(t, err)
+				assert.EqualError(t, err, test.err)
+			})
+		}
+	})
+}
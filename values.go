@@ -22,6 +22,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"strings"
 	"unicode/utf8"
 	"unsafe"
 
@@ -405,6 +406,12 @@ func (v Int) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v Int) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // Int8
 
 type Int8 int8
@@ -630,6 +637,12 @@ func (v Int128) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v Int128) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // Int256
 
 type Int256 struct {
@@ -693,6 +706,12 @@ func (v Int256) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v Int256) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // UInt
 
 type UInt struct {
@@ -752,6 +771,12 @@ func (v UInt) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v UInt) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // UInt8
 
 type UInt8 uint8
@@ -977,6 +1002,12 @@ func (v UInt128) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v UInt128) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // UInt256
 
 type UInt256 struct {
@@ -1040,6 +1071,12 @@ func (v UInt256) String() string {
 	return format.BigInt(v.Value)
 }
 
+// Text returns the string representation of the integer in the given base,
+// which must be between 2 and 62, see big.Int.Text.
+func (v UInt256) Text(base int) string {
+	return v.Value.Text(base)
+}
+
 // Word8
 
 type Word8 uint8
@@ -1487,6 +1524,9 @@ func NewMeteredKeyValuePair(gauge common.MemoryGauge, key, value Value) KeyValue
 type Struct struct {
 	StructType *StructType
 	Fields     []Value
+	// StorageID is the ID of the storage slab of the struct, if it is stored.
+	// It is optional metadata and is nil if the struct is not stored or the ID was not included on export
+	StorageID *StorageID
 }
 
 var _ Value = Struct{}
@@ -1561,11 +1601,76 @@ func formatComposite(typeID string, fields []Field, values []Value) string {
 	return format.Composite(typeID, preparedFields)
 }
 
+// compositeFieldsAndValues returns the field declarations of the type of the given composite value,
+// and the field values of the composite value. The result is false if the value is not a composite.
+func compositeFieldsAndValues(value Value) (fields []Field, values []Value, ok bool) {
+	var compositeType CompositeType
+
+	switch v := value.(type) {
+	case Struct:
+		if v.StructType != nil {
+			compositeType = v.StructType
+		}
+		values = v.Fields
+	case Resource:
+		if v.ResourceType != nil {
+			compositeType = v.ResourceType
+		}
+		values = v.Fields
+	case Event:
+		if v.EventType != nil {
+			compositeType = v.EventType
+		}
+		values = v.Fields
+	case Contract:
+		if v.ContractType != nil {
+			compositeType = v.ContractType
+		}
+		values = v.Fields
+	case Enum:
+		if v.EnumType != nil {
+			compositeType = v.EnumType
+		}
+		values = v.Fields
+	default:
+		return nil, nil, false
+	}
+
+	if compositeType != nil {
+		fields = compositeType.CompositeFields()
+	}
+
+	return fields, values, true
+}
+
+// StorageID
+
+// StorageID is the ID of the storage slab of a stored composite,
+// i.e. the address of the account the composite is stored in, and the index of the slab.
+type StorageID struct {
+	Address Address
+	Index   [8]byte
+}
+
+func (id StorageID) String() string {
+	return fmt.Sprintf(
+		"0x%x.%d",
+		binary.BigEndian.Uint64(id.Address[:]),
+		binary.BigEndian.Uint64(id.Index[:]),
+	)
+}
+
 // Resource
 
 type Resource struct {
 	ResourceType *ResourceType
 	Fields       []Value
+	// Owner is the address of the account which owns the resource.
+	// It is optional metadata and is nil if the owner is unknown or was not included on export
+	Owner *Address
+	// StorageID is the ID of the storage slab of the resource, if it is stored.
+	// It is optional metadata and is nil if the resource is not stored or the ID was not included on export
+	StorageID *StorageID
 }
 
 var _ Value = Resource{}
@@ -1604,6 +1709,11 @@ func (v Resource) WithType(typ *ResourceType) Resource {
 	return v
 }
 
+func (v Resource) WithOwner(owner Address) Resource {
+	v.Owner = &owner
+	return v
+}
+
 func (v Resource) ToGoValue() any {
 	ret := make([]any, len(v.Fields))
 
@@ -1679,6 +1789,9 @@ func (v Event) String() string {
 type Contract struct {
 	ContractType *ContractType
 	Fields       []Value
+	// StorageID is the ID of the storage slab of the contract, if it is stored.
+	// It is optional metadata and is nil if the contract is not stored or the ID was not included on export
+	StorageID *StorageID
 }
 
 var _ Value = Contract{}
@@ -1795,6 +1908,47 @@ func NewMeteredPath(gauge common.MemoryGauge, domain, identifier string) Path {
 	return NewPath(domain, identifier)
 }
 
+// NewPathChecked returns a new path with the given domain and identifier,
+// like NewPath, but returns an error if the domain is not a known path domain
+// (i.e. storage, private, or public), or if the identifier is not a valid identifier.
+func NewPathChecked(domain, identifier string) (Path, error) {
+	if common.PathDomainFromIdentifier(domain) == common.PathDomainUnknown {
+		return Path{}, errors.NewDefaultUserError("invalid path domain: `%s`", domain)
+	}
+
+	if !isValidIdentifier(identifier) {
+		return Path{}, errors.NewDefaultUserError("invalid path identifier: `%s`", identifier)
+	}
+
+	return NewPath(domain, identifier), nil
+}
+
+// isValidIdentifier returns true if the given string is a valid identifier,
+// i.e. it starts with a letter or an underscore,
+// followed by letters, digits, or underscores.
+func isValidIdentifier(identifier string) bool {
+	if len(identifier) == 0 {
+		return false
+	}
+
+	for i, r := range identifier {
+		switch {
+		case r >= 'a' && r <= 'z',
+			r >= 'A' && r <= 'Z',
+			r == '_':
+			continue
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 func (Path) isValue() {}
 
 func (Path) Type() Type {
@@ -1816,6 +1970,12 @@ func (v Path) String() string {
 	)
 }
 
+// FullString returns the full string form of the path, i.e. `/domain/identifier`,
+// e.g. `/public/foo`.
+func (v Path) FullString() string {
+	return "/" + v.Domain + "/" + v.Identifier
+}
+
 // TypeValue
 
 type TypeValue struct {
@@ -1876,6 +2036,60 @@ func NewMeteredCapability(gauge common.MemoryGauge, path Path, address Address,
 	return NewCapability(path, address, borrowType)
 }
 
+// NewCapabilityFromStrings returns a new capability for the given address and path strings,
+// e.g. `0x1` and `/public/foo`, and the given borrow type.
+//
+// The address must be a hex-encoded address, optionally prefixed with `0x`,
+// and the path must be a public or private path in its full string form, see Path.FullString.
+// The path is validated like in NewPathChecked.
+func NewCapabilityFromStrings(addressHex, pathString string, borrowType Type) (Capability, error) {
+	address, err := parseAddressHex(addressHex)
+	if err != nil {
+		return Capability{}, err
+	}
+
+	path, err := parsePathString(pathString)
+	if err != nil {
+		return Capability{}, err
+	}
+
+	switch common.PathDomainFromIdentifier(path.Domain) {
+	case common.PathDomainPublic, common.PathDomainPrivate:
+		break
+	default:
+		return Capability{}, errors.NewDefaultUserError(
+			"invalid capability path: `%s` is not a public or private path",
+			pathString,
+		)
+	}
+
+	return NewCapability(path, address, borrowType), nil
+}
+
+func parseAddressHex(addressHex string) (Address, error) {
+	trimmed := strings.TrimPrefix(addressHex, "0x")
+	if trimmed == "" {
+		return Address{}, errors.NewDefaultUserError("invalid address: `%s`", addressHex)
+	}
+
+	address, err := common.HexToAddress(trimmed)
+	if err != nil {
+		return Address{}, errors.NewDefaultUserError("invalid address: `%s`: %s", addressHex, err)
+	}
+
+	return Address(address), nil
+}
+
+// parsePathString parses the given path in its full string form, e.g. `/public/foo`.
+func parsePathString(pathString string) (Path, error) {
+	parts := strings.Split(pathString, "/")
+	if len(parts) != 3 || parts[0] != "" {
+		return Path{}, errors.NewDefaultUserError("invalid path: `%s`", pathString)
+	}
+
+	return NewPathChecked(parts[1], parts[2])
+}
+
 func (Capability) isValue() {}
 
 func (v Capability) Type() Type {
@@ -1902,6 +2116,9 @@ func (v Capability) String() string {
 type Enum struct {
 	EnumType *EnumType
 	Fields   []Value
+	// StorageID is the ID of the storage slab of the enum, if it is stored.
+	// It is optional metadata and is nil if the enum is not stored or the ID was not included on export
+	StorageID *StorageID
 }
 
 var _ Value = Enum{}
@@ -21,12 +21,14 @@ package cadence
 import (
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/sema"
 	"github.com/onflow/cadence/runtime/tests/utils"
 )
@@ -599,3 +601,181 @@ func TestNewUInt256FromBig(t *testing.T) {
 	_, err = NewUInt256FromBig(aboveMax)
 	require.Error(t, err)
 }
+
+func TestBigIntText(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("Int256", func(t *testing.T) {
+
+		t.Parallel()
+
+		max, err := NewInt256FromBig(sema.Int256TypeMaxIntBig)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			"57896044618658097711785492504343953926634992332820282019728792003956564819967",
+			max.Text(10),
+		)
+		assert.Equal(t,
+			"7"+strings.Repeat("f", 63),
+			max.Text(16),
+		)
+
+		min, err := NewInt256FromBig(sema.Int256TypeMinIntBig)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			"-57896044618658097711785492504343953926634992332820282019728792003956564819968",
+			min.Text(10),
+		)
+		assert.Equal(t,
+			"-8"+strings.Repeat("0", 63),
+			min.Text(16),
+		)
+	})
+
+	t.Run("Int", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, "-255", NewInt(-255).Text(10))
+		assert.Equal(t, "-ff", NewInt(-255).Text(16))
+	})
+
+	t.Run("UInt", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, "255", NewUInt(255).Text(10))
+		assert.Equal(t, "ff", NewUInt(255).Text(16))
+	})
+}
+
+func TestPathFullString(t *testing.T) {
+
+	t.Parallel()
+
+	for _, domain := range common.AllPathDomains {
+
+		domain := domain
+
+		t.Run(domain.Identifier(), func(t *testing.T) {
+
+			t.Parallel()
+
+			path := NewPath(domain.Identifier(), "foo")
+
+			assert.Equal(t, "/"+domain.Identifier()+"/foo", path.FullString())
+		})
+	}
+}
+
+func TestNewPathChecked(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, domain := range []string{"storage", "private", "public"} {
+			for _, identifier := range []string{"foo", "_foo", "Foo_Bar1", "x"} {
+				path, err := NewPathChecked(domain, identifier)
+				require.NoError(t, err)
+				assert.Equal(t, NewPath(domain, identifier), path)
+			}
+		}
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, domain := range []string{"", "foo", "Storage", "/storage"} {
+			_, err := NewPathChecked(domain, "foo")
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("invalid identifier", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, identifier := range []string{"", "1foo", "foo-bar", "foo bar", "foo/bar", "föö"} {
+			_, err := NewPathChecked("storage", identifier)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestNewCapabilityFromStrings(t *testing.T) {
+
+	t.Parallel()
+
+	borrowType := ReferenceType{
+		Type: IntType{},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, addressHex := range []string{"0x1", "1", "0x0000000000000001"} {
+			for _, domain := range []string{"public", "private"} {
+				capability, err := NewCapabilityFromStrings(addressHex, "/"+domain+"/foo", borrowType)
+				require.NoError(t, err)
+
+				assert.Equal(t,
+					NewCapability(
+						NewPath(domain, "foo"),
+						BytesToAddress([]byte{0x1}),
+						borrowType,
+					),
+					capability,
+				)
+			}
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, addressHex := range []string{"", "0x", "0xzz", "0x000000000000000001", "foo"} {
+			_, err := NewCapabilityFromStrings(addressHex, "/public/foo", borrowType)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid address")
+		}
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, pathString := range []string{
+			"",
+			"public/foo",
+			"/public",
+			"/public/",
+			"/public/foo/bar",
+			"/foo/bar",
+			"/public/1foo",
+			"//public/foo",
+		} {
+			_, err := NewCapabilityFromStrings("0x1", pathString, borrowType)
+			require.Error(t, err, pathString)
+		}
+	})
+
+	t.Run("storage path", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := NewCapabilityFromStrings("0x1", "/storage/foo", borrowType)
+		require.EqualError(t,
+			err,
+			"invalid capability path: `/storage/foo` is not a public or private path",
+		)
+	})
+}